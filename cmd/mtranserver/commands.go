@@ -0,0 +1,208 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/models"
+)
+
+// runCommand 执行子命令，不是子命令时返回 false
+func runCommand(args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+	switch args[0] {
+	case "export":
+		return true, runExport(args[1:])
+	case "import":
+		return true, runImport(args[1:])
+	case "verify":
+		return true, runVerify(args[1:])
+	case "gc":
+		return true, runGC(args[1:])
+	default:
+		return false, fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	output := fs.String("o", "models.tar.gz", "Output file")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] export [-o file] <from_to>...\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Export downloaded models into a bundle, e.g. export -o models.tar.gz en_ja ja_en\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("no language pairs to export")
+	}
+
+	pairs := make([]models.Pair, 0, fs.NArg())
+	for _, arg := range fs.Args() {
+		p, err := models.ParsePair(arg)
+		if err != nil {
+			return err
+		}
+		pairs = append(pairs, p)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	manifest, err := models.ExportBundle(f, config.GetConfig().ModelDir, pairs)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(*output)
+		return err
+	}
+
+	fmt.Printf("Exported %d language pair(s), %d file(s) to %s\n", len(manifest.Pairs), len(manifest.Files), *output)
+	return nil
+}
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] import <file>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Verify and import a model bundle created by export\n")
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected one bundle file")
+	}
+
+	return importFile(fs.Arg(0))
+}
+
+func importFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	manifest, err := models.ImportBundle(f, config.GetConfig().ModelDir)
+	if err != nil {
+		return err
+	}
+	for _, p := range manifest.Pairs {
+		fmt.Printf("Imported %s -> %s\n", p.From, p.To)
+	}
+	return nil
+}
+
+func runVerify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	repair := fs.Bool("repair", false, "Quarantine corrupt files and download them again")
+	bundle := fs.String("bundle", "", "Import this bundle after quarantining corrupt files, for offline repair")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] verify [--repair] [--bundle file] [from_to]...\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Verify model files against the records, all downloaded pairs when none given\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	pairs := make([]models.Pair, 0, fs.NArg())
+	for _, arg := range fs.Args() {
+		p, err := models.ParsePair(arg)
+		if err != nil {
+			return err
+		}
+		pairs = append(pairs, p)
+	}
+
+	modelDir := config.GetConfig().ModelDir
+	if len(pairs) == 0 {
+		var err error
+		if pairs, err = models.InstalledPairs(modelDir); err != nil {
+			return err
+		}
+	}
+
+	// 使用模型包修复时先隔离损坏的文件，再导入模型包，不从网络下载
+	if *bundle != "" {
+		for _, p := range pairs {
+			if c := models.CheckModel(modelDir, p, true); !c.Healthy {
+				printCheck(c)
+			}
+		}
+		if err := importFile(*bundle); err != nil {
+			return err
+		}
+	}
+
+	unhealthy := 0
+	for _, p := range pairs {
+		c := models.VerifyModel(modelDir, p, *repair && *bundle == "")
+		printCheck(c)
+		if !c.Healthy {
+			unhealthy++
+		}
+	}
+	if unhealthy > 0 {
+		return fmt.Errorf("%d of %d language pair(s) are not healthy", unhealthy, len(pairs))
+	}
+	return nil
+}
+
+func runGC(args []string) error {
+	fs := flag.NewFlagSet("gc", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "Only list what would be removed")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] gc [--dry-run]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Remove unused model files, kept versions beyond --model-keep-versions and --model-disk-quota\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	// 已注册的本地模型的工作目录不清理
+	if err := models.LoadLocalModels(); err != nil {
+		return err
+	}
+	result, err := models.GC(config.GetConfig().ModelDir, *dryRun)
+	if err != nil {
+		return err
+	}
+	for _, path := range result.Removed {
+		fmt.Println(path)
+	}
+	verb := "Removed"
+	if *dryRun {
+		verb = "Would remove"
+	}
+	fmt.Printf("%s %d path(s), %.1f MB\n", verb, len(result.Removed), float64(result.FreedBytes)/(1<<20))
+	return nil
+}
+
+func printCheck(c models.ModelCheck) {
+	status := "ok"
+	switch {
+	case c.Repaired && c.Healthy:
+		status = "repaired"
+	case !c.Healthy:
+		status = "unhealthy"
+	}
+	fmt.Printf("%s -> %s: %s\n", c.From, c.To, status)
+	for _, f := range c.Files {
+		if f.Status == models.FileOK {
+			continue
+		}
+		fmt.Printf("  %s: %s", f.File, f.Status)
+		if f.Quarantined != "" {
+			fmt.Printf(", moved to %s", f.Quarantined)
+		}
+		fmt.Println()
+	}
+	if c.Error != "" {
+		fmt.Printf("  error: %s\n", c.Error)
+	}
+}
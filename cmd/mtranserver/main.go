@@ -42,23 +42,69 @@ func main() {
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "MTranServer %s - Ultra-low resource consumption, ultra-fast offline translation server\n\n", version.GetVersion())
 		fmt.Fprintf(os.Stderr, "Usage:\n")
-		fmt.Fprintf(os.Stderr, "  %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s [options] export [-o file] <from_to>...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s [options] import <file>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s [options] verify [--repair] [--bundle file] [from_to]...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s [options] gc [--dry-run]\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
 		fmt.Fprintf(os.Stderr, "  MT_LOG_LEVEL           Log level (debug, info, warn, error)\n")
+		fmt.Fprintf(os.Stderr, "  MT_LOG_FORMAT          Log format (text, json)\n")
+		fmt.Fprintf(os.Stderr, "  MT_LOG_FILE            Log file path, logs go to stdout/stderr when empty\n")
+		fmt.Fprintf(os.Stderr, "  MT_LOG_MAX_SIZE        Rotate log file at this size in MB\n")
+		fmt.Fprintf(os.Stderr, "  MT_LOG_MAX_BACKUPS     Number of rotated log files to keep\n")
+		fmt.Fprintf(os.Stderr, "  MT_LOG_MAX_AGE         Days to keep rotated log files\n")
+		fmt.Fprintf(os.Stderr, "  MT_LOG_ROTATE_DAILY    Rotate log file daily (true/false)\n")
 		fmt.Fprintf(os.Stderr, "  MT_CONFIG_DIR          Configuration directory\n")
+		fmt.Fprintf(os.Stderr, "  MT_CONFIG_FILE         Config file (default: <config-dir>/config.yml)\n")
 		fmt.Fprintf(os.Stderr, "  MT_MODEL_DIR           Model directory\n")
+		fmt.Fprintf(os.Stderr, "  MT_MODEL_MIRRORS       Comma separated model mirror base URLs (http(s):// or file://)\n")
+		fmt.Fprintf(os.Stderr, "  MT_RECORDS_URL         Comma separated records.json URLs\n")
+		fmt.Fprintf(os.Stderr, "  MT_RECORDS_CHANNEL     Mozilla records channel: production, preview\n")
+		fmt.Fprintf(os.Stderr, "  MT_RECORDS_SOURCES     Comma separated extra records sources, later ones take precedence\n")
+		fmt.Fprintf(os.Stderr, "  MT_RECORDS_REFRESH_INTERVAL  Seconds between background records.json refreshes, 0 to disable\n")
+		fmt.Fprintf(os.Stderr, "  MT_MODEL_AUTO_UPGRADE  Upgrade running engines after a records refresh finds new versions (default: true)\n")
+		fmt.Fprintf(os.Stderr, "  MT_PIVOT_LANGUAGE      Preferred pivot language (default: en)\n")
+		fmt.Fprintf(os.Stderr, "  MT_MAX_PIVOT_HOPS      Maximum language pairs chained for one translation (default: 2)\n")
+		fmt.Fprintf(os.Stderr, "  MT_DETECTOR_HIGH_ACCURACY  Use the high accuracy language detector (true/false)\n")
+		fmt.Fprintf(os.Stderr, "  MT_DETECTOR_MIN_DISTANCE   Minimum relative distance between detected languages, 0-0.99 (default: 0)\n")
+		fmt.Fprintf(os.Stderr, "  MT_DETECTOR_MIN_CONFIDENCE Confidence below which request hints adjust detection, segmentation is unaffected (default: 0.5)\n")
+		fmt.Fprintf(os.Stderr, "  MT_DETECTOR_MAX_LANGUAGES  Maximum languages detected in one text (default: 2)\n")
+		fmt.Fprintf(os.Stderr, "  MT_MODEL_ARCHITECTURE  Preferred model architectures, such as tiny,base-memory, fast or quality\n")
+		fmt.Fprintf(os.Stderr, "  MT_LOCAL_MODELS_DIR    Directory with a models.json manifest of custom models\n")
+		fmt.Fprintf(os.Stderr, "  MT_MODEL_KEEP_VERSIONS Number of previous model versions to keep per pair\n")
+		fmt.Fprintf(os.Stderr, "  MT_MODEL_DISK_QUOTA    Model directory disk quota in MB, 0 for unlimited\n")
 		fmt.Fprintf(os.Stderr, "  MT_HOST                Server host address\n")
 		fmt.Fprintf(os.Stderr, "  MT_PORT                Server port\n")
 		fmt.Fprintf(os.Stderr, "  MT_ENABLE_UI           Enable Web UI (true/false)\n")
 		fmt.Fprintf(os.Stderr, "  MT_OFFLINE             Enable offline mode (true/false)\n")
 		fmt.Fprintf(os.Stderr, "  MT_WORKER_IDLE_TIMEOUT Worker idle timeout in seconds\n")
+		fmt.Fprintf(os.Stderr, "  MT_TLS_CERT            TLS certificate file, enables HTTPS\n")
+		fmt.Fprintf(os.Stderr, "  MT_TLS_KEY             TLS private key file\n")
+		fmt.Fprintf(os.Stderr, "  MT_TLS_CLIENT_CA       CA bundle for client certificates, enables mTLS\n")
+		fmt.Fprintf(os.Stderr, "  MT_TLS_CLIENT_AUTH     Client certificate mode (require, verify-if-given)\n")
 		fmt.Fprintf(os.Stderr, "  MT_API_TOKEN           API access token\n")
+		fmt.Fprintf(os.Stderr, "  MT_TOKENS_FILE         API tokens file (default: <config-dir>/tokens.json)\n")
+		fmt.Fprintf(os.Stderr, "  MT_TRUSTED_PROXIES     Comma separated reverse proxy IPs or CIDRs trusted for X-Forwarded-For\n")
+		fmt.Fprintf(os.Stderr, "  MT_RATE_LIMIT_API      Rate limit for core API (e.g. requests=60,chars=100000,window=1m,key=token)\n")
+		fmt.Fprintf(os.Stderr, "  MT_RATE_LIMIT_PLUGIN   Rate limit for plugin compatible API\n")
+		fmt.Fprintf(os.Stderr, "  MT_CORS_API            CORS policy for core API (e.g. origins=https://app.example.com,max-age=10m)\n")
+		fmt.Fprintf(os.Stderr, "  MT_CORS_PLUGIN         CORS policy for plugin compatible API\n")
+		fmt.Fprintf(os.Stderr, "  MT_MAX_BODY_SIZE       Maximum request body size in bytes, 0 for unlimited\n")
+		fmt.Fprintf(os.Stderr, "  MT_MAX_TEXT_LENGTH     Maximum characters per text, 0 for unlimited\n")
+		fmt.Fprintf(os.Stderr, "  MT_MAX_BATCH_SIZE      Maximum number of texts per request, 0 for unlimited\n")
+		fmt.Fprintf(os.Stderr, "  MT_CHUNK_SIZE          Split long texts into chunks of this many characters, 0 to disable\n")
+		fmt.Fprintf(os.Stderr, "  MT_MODEL_LOADING       Requests while a model downloads (wait, accept: 202, reject: 503)\n")
+		fmt.Fprintf(os.Stderr, "  MT_OTEL_ENDPOINT       OTLP/HTTP trace endpoint (e.g. http://localhost:4318)\n")
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  %s --host 127.0.0.1 --port 8080\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s --ui --offline\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  MT_PORT=9000 %s\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s export -o models.tar.gz en_ja ja_en\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --offline import models.tar.gz\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s verify --repair\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "\nMore information: https://github.com/xxnuo/MTranServer\n")
 	}
 
@@ -66,7 +112,16 @@ func main() {
 
 	flag.Parse()
 
-	logger.SetLevel(cfg.LogLevel)
+	if err := config.LoadFile(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config file: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := logger.Configure(cfg.LoggerOptions()); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to configure logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Close()
 
 	if *versionFlag || *versionShortFlag {
 		fmt.Printf("MTranServer %s\n", version.GetVersion())
@@ -74,6 +129,13 @@ func main() {
 		os.Exit(0)
 	}
 
+	if handled, err := runCommand(flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	} else if handled {
+		return
+	}
+
 	if err := server.Run(); err != nil {
 		logger.Fatal("Server error: %v", err)
 	}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/logger"
+)
+
+var (
+	globalStore   = NewStore("")
+	globalStoreMu sync.RWMutex
+)
+
+// TokensFilePath 返回令牌文件路径，未配置时使用配置目录下的 tokens.json
+func TokensFilePath(cfg *config.Config) string {
+	if cfg.TokensFile != "" {
+		return cfg.TokensFile
+	}
+	return filepath.Join(cfg.ConfigDir, "tokens.json")
+}
+
+// InitStore 从配置的令牌文件加载全局令牌存储
+func InitStore(cfg *config.Config) error {
+	store := NewStore(TokensFilePath(cfg))
+	if err := store.Load(); err != nil {
+		return err
+	}
+	store.SetLegacyToken(cfg.APIToken)
+
+	globalStoreMu.Lock()
+	globalStore = store
+	globalStoreMu.Unlock()
+	return nil
+}
+
+// GetStore 返回全局令牌存储
+func GetStore() *Store {
+	globalStoreMu.RLock()
+	defer globalStoreMu.RUnlock()
+	return globalStore
+}
+
+// ValidateFile 检查令牌文件能否被正确加载，不修改文件
+func ValidateFile(path string) error {
+	_, _, err := readTokensFile(path)
+	return err
+}
+
+// Watch 定期检查全局令牌存储的令牌文件，修改后重新加载
+func Watch(ctx context.Context, interval time.Duration) {
+	store := GetStore()
+	last := modTime(store.Path())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			path := store.Path()
+			mt := modTime(path)
+			if mt.Equal(last) {
+				continue
+			}
+			if err := store.Reload(path); err != nil {
+				logger.Error("Failed to reload API tokens: %v", err)
+			}
+			// 重新加载时可能回写哈希后的令牌，以回写后的时间为准
+			last = modTime(path)
+		}
+	}
+}
+
+func modTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
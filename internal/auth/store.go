@@ -0,0 +1,584 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xxnuo/MTranServer/internal/logger"
+)
+
+type Scope string
+
+const (
+	// ScopeAPI 核心接口：/translate、/languages 等
+	ScopeAPI Scope = "api"
+	// ScopePlugin 插件兼容接口：/imme、/kiss、/deepl、/google、/hcfy
+	ScopePlugin Scope = "plugin"
+	// ScopeAdmin 管理接口：/admin/*
+	ScopeAdmin Scope = "admin"
+)
+
+var AllScopes = []Scope{ScopeAPI, ScopePlugin, ScopeAdmin}
+
+const (
+	tokenPrefix      = "mt_"
+	usageSaveEvery   = 5 * time.Second
+	usageDateLayout  = "2006-01-02"
+	legacyTokenID    = "default"
+	legacyTokenLabel = "MT_API_TOKEN"
+)
+
+var (
+	ErrInvalidToken  = errors.New("invalid token")
+	ErrScopeDenied   = errors.New("token is not allowed to access this endpoint")
+	ErrPairDenied    = errors.New("token is not allowed to translate this language pair")
+	ErrQuotaExceeded = errors.New("daily character quota exceeded")
+	ErrTokenNotFound = errors.New("token not found")
+)
+
+// Token 访问令牌，文件中只保存哈希值
+type Token struct {
+	ID         string    `json:"id"`
+	Label      string    `json:"label"`
+	Hash       string    `json:"hash,omitempty"`
+	Plain      string    `json:"token,omitempty"`
+	Scopes     []Scope   `json:"scopes"`
+	Pairs      []string  `json:"pairs,omitempty"`
+	DailyQuota int64     `json:"daily_quota,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TokenInfo 对外展示的令牌信息，不含哈希
+type TokenInfo struct {
+	ID         string    `json:"id"`
+	Label      string    `json:"label"`
+	Scopes     []Scope   `json:"scopes"`
+	Pairs      []string  `json:"pairs,omitempty"`
+	DailyQuota int64     `json:"daily_quota,omitempty"`
+	UsedToday  int64     `json:"used_today"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type tokensFile struct {
+	Tokens []*Token `json:"tokens"`
+}
+
+type usageData struct {
+	Date  string           `json:"date"`
+	Chars map[string]int64 `json:"chars"`
+}
+
+type Store struct {
+	mu        sync.RWMutex
+	path      string
+	usagePath string
+	tokens    []*Token
+	legacy    *Token
+	usage     usageData
+	dirty     bool
+	lastSave  time.Time
+	now       func() time.Time
+}
+
+// NewStore 创建令牌存储，path 为空时仅保存在内存中
+func NewStore(path string) *Store {
+	s := &Store{
+		path: path,
+		now:  time.Now,
+	}
+	s.usagePath = usagePathFor(path)
+	s.usage = usageData{Date: s.today(), Chars: make(map[string]int64)}
+	return s
+}
+
+// usagePathFor 返回令牌文件对应的用量文件路径，如 tokens_usage.json
+func usagePathFor(path string) string {
+	if path == "" {
+		return ""
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "_usage" + ext
+}
+
+func (t *Token) HasScope(scope Scope) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsPair 检查令牌是否允许翻译该语言对，规则形如 en-ja、*-ja、en-*、*
+// 源语言为 auto 时只检查目标语言，检测到源语言后需要再次检查
+func (t *Token) AllowsPair(fromLang, toLang string) bool {
+	if len(t.Pairs) == 0 {
+		return true
+	}
+	for _, p := range t.Pairs {
+		if p == "*" {
+			return true
+		}
+		from, to, ok := splitPair(p)
+		if !ok {
+			continue
+		}
+		if to != "*" && to != toLang {
+			continue
+		}
+		if from == "*" || fromLang == "auto" || from == fromLang {
+			return true
+		}
+	}
+	return false
+}
+
+// splitPair 拆分语言对，语言代码本身可能包含 "-"（如 zh-Hans-en），按已知的 "*" 或逐位尝试
+func splitPair(pair string) (string, string, bool) {
+	if strings.HasPrefix(pair, "*-") {
+		return "*", pair[2:], true
+	}
+	if strings.HasSuffix(pair, "-*") {
+		return pair[:len(pair)-2], "*", true
+	}
+	if i := strings.Index(pair, ">"); i > 0 {
+		return pair[:i], pair[i+1:], true
+	}
+	parts := strings.Split(pair, "-")
+	switch len(parts) {
+	case 2:
+		return parts[0], parts[1], true
+	case 3:
+		// zh-Hans-en 或 en-zh-Hans
+		if len(parts[1]) == 4 {
+			return parts[0] + "-" + parts[1], parts[2], true
+		}
+		return parts[0], parts[1] + "-" + parts[2], true
+	case 4:
+		return parts[0] + "-" + parts[1], parts[2] + "-" + parts[3], true
+	}
+	return "", "", false
+}
+
+func (t *Token) info(used int64) TokenInfo {
+	return TokenInfo{
+		ID:         t.ID,
+		Label:      t.Label,
+		Scopes:     t.Scopes,
+		Pairs:      t.Pairs,
+		DailyQuota: t.DailyQuota,
+		UsedToday:  used,
+		CreatedAt:  t.CreatedAt,
+	}
+}
+
+func hashToken(raw string) string {
+	h := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(h[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (s *Store) today() string {
+	return s.now().Format(usageDateLayout)
+}
+
+// readTokensFile 读取并校验令牌文件，明文令牌替换为哈希并补全 ID 和作用域，不写入磁盘
+// 返回的 rewrite 表示内容有变化，需要回写文件
+func readTokensFile(path string) (tokens []*Token, rewrite bool, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, false, fmt.Errorf("failed to read tokens file: %w", err)
+	}
+
+	var file tokensFile
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, false, fmt.Errorf("failed to parse tokens file: %w", err)
+		}
+	}
+
+	for _, t := range file.Tokens {
+		if t.Plain != "" {
+			t.Hash = hashToken(t.Plain)
+			t.Plain = ""
+			rewrite = true
+		}
+		if t.Hash == "" {
+			return nil, false, fmt.Errorf("token %q has neither token nor hash", t.Label)
+		}
+		if t.ID == "" {
+			id, err := randomHex(4)
+			if err != nil {
+				return nil, false, err
+			}
+			t.ID = id
+			rewrite = true
+		}
+		if len(t.Scopes) == 0 {
+			t.Scopes = []Scope{ScopeAPI, ScopePlugin}
+		}
+	}
+	return file.Tokens, rewrite, nil
+}
+
+// Load 从文件加载令牌和用量，文件中的明文令牌会被哈希后回写
+func (s *Store) Load() error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, rewrite, err := readTokensFile(s.path)
+	if err != nil {
+		return err
+	}
+	s.tokens = tokens
+
+	if rewrite {
+		logger.Info("Hashing plaintext tokens in %s", s.path)
+		if err := s.saveTokensLocked(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.loadUsageLocked(); err != nil {
+		logger.Warn("Failed to load token usage, starting from zero: %v", err)
+	}
+
+	logger.Debug("Loaded %d API token(s) from %s", len(s.tokens), s.path)
+	return nil
+}
+
+func (s *Store) loadUsageLocked() error {
+	data, err := os.ReadFile(s.usagePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var usage usageData
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return err
+	}
+	if usage.Date == s.today() && usage.Chars != nil {
+		s.usage = usage
+	}
+	return nil
+}
+
+func writeJSONAtomic(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *Store) saveTokensLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	if err := writeJSONAtomic(s.path, tokensFile{Tokens: s.tokens}); err != nil {
+		return fmt.Errorf("failed to save tokens file: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) saveUsageLocked() error {
+	if s.usagePath == "" || !s.dirty {
+		return nil
+	}
+	if err := writeJSONAtomic(s.usagePath, s.usage); err != nil {
+		return fmt.Errorf("failed to save token usage: %w", err)
+	}
+	s.dirty = false
+	s.lastSave = s.now()
+	return nil
+}
+
+// Reload 从 path 重新加载令牌，用于令牌文件被修改或路径变化
+// 路径不变时保留内存中的用量，路径变化时先保存当前用量再读取新路径的用量
+func (s *Store) Reload(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, rewrite, err := readTokensFile(path)
+	if err != nil {
+		return err
+	}
+
+	if path != s.path {
+		if err := s.saveUsageLocked(); err != nil {
+			logger.Warn("%v", err)
+		}
+		s.path = path
+		s.usagePath = usagePathFor(path)
+		s.usage = usageData{Date: s.today(), Chars: make(map[string]int64)}
+		s.dirty = false
+		if err := s.loadUsageLocked(); err != nil {
+			logger.Warn("Failed to load token usage, starting from zero: %v", err)
+		}
+	}
+	s.tokens = tokens
+
+	if rewrite {
+		logger.Info("Hashing plaintext tokens in %s", s.path)
+		if err := s.saveTokensLocked(); err != nil {
+			return err
+		}
+	}
+
+	logger.Debug("Reloaded %d API token(s) from %s", len(s.tokens), s.path)
+	return nil
+}
+
+// Path 返回令牌文件路径
+func (s *Store) Path() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.path
+}
+
+// FlushUsage 立即将用量写入磁盘
+func (s *Store) FlushUsage() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saveUsageLocked()
+}
+
+// SetLegacyToken 设置 MT_API_TOKEN 单令牌，拥有全部权限且不限额
+func (s *Store) SetLegacyToken(raw string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if raw == "" {
+		s.legacy = nil
+		return
+	}
+	s.legacy = &Token{
+		ID:     legacyTokenID,
+		Label:  legacyTokenLabel,
+		Hash:   hashToken(raw),
+		Scopes: AllScopes,
+	}
+}
+
+// Enabled 是否配置了任意令牌，未配置时不启用鉴权
+func (s *Store) Enabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.legacy != nil || len(s.tokens) > 0
+}
+
+// Authenticate 以常量时间比较查找令牌
+func (s *Store) Authenticate(raw string) (*Token, error) {
+	if raw == "" {
+		return nil, ErrInvalidToken
+	}
+	hash := []byte(hashToken(raw))
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found *Token
+	if s.legacy != nil && subtle.ConstantTimeCompare(hash, []byte(s.legacy.Hash)) == 1 {
+		found = s.legacy
+	}
+	for _, t := range s.tokens {
+		if subtle.ConstantTimeCompare(hash, []byte(t.Hash)) == 1 && found == nil {
+			found = t
+		}
+	}
+
+	if found == nil {
+		return nil, ErrInvalidToken
+	}
+	return found, nil
+}
+
+// Authorize 认证令牌并检查作用域
+func (s *Store) Authorize(raw string, scope Scope) (*Token, error) {
+	t, err := s.Authenticate(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !t.HasScope(scope) {
+		return t, ErrScopeDenied
+	}
+	return t, nil
+}
+
+// Charge 扣减令牌当日字符额度
+func (s *Store) Charge(t *Token, chars int64) error {
+	if t == nil || chars <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if today := s.today(); s.usage.Date != today {
+		s.usage = usageData{Date: today, Chars: make(map[string]int64)}
+	}
+
+	used := s.usage.Chars[t.ID]
+	if t.DailyQuota > 0 && used+chars > t.DailyQuota {
+		return ErrQuotaExceeded
+	}
+
+	s.usage.Chars[t.ID] = used + chars
+	s.dirty = true
+
+	if s.now().Sub(s.lastSave) >= usageSaveEvery {
+		if err := s.saveUsageLocked(); err != nil {
+			logger.Warn("%v", err)
+		}
+	}
+	return nil
+}
+
+// Refund 退还 Charge 扣减的当日字符额度，跨天后不再退还
+func (s *Store) Refund(t *Token, chars int64) {
+	if t == nil || chars <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.usage.Date != s.today() {
+		return
+	}
+	s.usage.Chars[t.ID] = max(s.usage.Chars[t.ID]-chars, 0)
+	s.dirty = true
+}
+
+// UsedToday 返回令牌当日已用字符数
+func (s *Store) UsedToday(id string) int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.usage.Date != s.today() {
+		return 0
+	}
+	return s.usage.Chars[id]
+}
+
+// Create 创建新令牌，返回令牌信息和仅此一次可见的明文
+func (s *Store) Create(label string, scopes []Scope, pairs []string, dailyQuota int64) (TokenInfo, string, error) {
+	for _, scope := range scopes {
+		if scope != ScopeAPI && scope != ScopePlugin && scope != ScopeAdmin {
+			return TokenInfo{}, "", fmt.Errorf("unknown scope: %s", scope)
+		}
+	}
+	for _, p := range pairs {
+		if _, _, ok := splitPair(p); !ok && p != "*" {
+			return TokenInfo{}, "", fmt.Errorf("invalid language pair: %s", p)
+		}
+	}
+	if len(scopes) == 0 {
+		scopes = []Scope{ScopeAPI, ScopePlugin}
+	}
+
+	secret, err := randomHex(24)
+	if err != nil {
+		return TokenInfo{}, "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	id, err := randomHex(4)
+	if err != nil {
+		return TokenInfo{}, "", fmt.Errorf("failed to generate token id: %w", err)
+	}
+	raw := tokenPrefix + secret
+
+	t := &Token{
+		ID:         id,
+		Label:      label,
+		Hash:       hashToken(raw),
+		Scopes:     scopes,
+		Pairs:      pairs,
+		DailyQuota: dailyQuota,
+		CreatedAt:  s.now().UTC(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens = append(s.tokens, t)
+	if err := s.saveTokensLocked(); err != nil {
+		s.tokens = s.tokens[:len(s.tokens)-1]
+		return TokenInfo{}, "", err
+	}
+
+	logger.Info("Created API token %s (%s)", t.ID, t.Label)
+	return t.info(0), raw, nil
+}
+
+// Delete 吊销令牌
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, t := range s.tokens {
+		if t.ID != id {
+			continue
+		}
+		s.tokens = append(s.tokens[:i:i], s.tokens[i+1:]...)
+		if err := s.saveTokensLocked(); err != nil {
+			return err
+		}
+		delete(s.usage.Chars, id)
+		s.dirty = true
+		logger.Info("Revoked API token %s (%s)", t.ID, t.Label)
+		return nil
+	}
+	return ErrTokenNotFound
+}
+
+// List 列出文件中配置的令牌及当日用量
+func (s *Store) List() []TokenInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	today := s.today()
+	infos := make([]TokenInfo, 0, len(s.tokens))
+	for _, t := range s.tokens {
+		var used int64
+		if s.usage.Date == today {
+			used = s.usage.Chars[t.ID]
+		}
+		infos = append(infos, t.info(used))
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].CreatedAt.Before(infos[j].CreatedAt)
+	})
+	return infos
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAndAuthorize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	store := NewStore(path)
+
+	info, raw, err := store.Create("plugin-only", []Scope{ScopePlugin}, nil, 0)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(raw, tokenPrefix))
+	assert.True(t, store.Enabled())
+
+	tok, err := store.Authorize(raw, ScopePlugin)
+	require.NoError(t, err)
+	assert.Equal(t, info.ID, tok.ID)
+
+	_, err = store.Authorize(raw, ScopeAPI)
+	assert.ErrorIs(t, err, ErrScopeDenied)
+
+	_, err = store.Authorize("wrong", ScopePlugin)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), raw)
+	assert.Contains(t, string(data), hashToken(raw))
+}
+
+func TestLoadHashesPlaintextTokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	data, _ := json.Marshal(tokensFile{Tokens: []*Token{{Label: "from-file", Plain: "secret"}}})
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	store := NewStore(path)
+	require.NoError(t, store.Load())
+
+	tok, err := store.Authorize("secret", ScopeAPI)
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", tok.Label)
+	assert.NotEmpty(t, tok.ID)
+
+	rewritten, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(rewritten), `"secret"`)
+}
+
+func TestValidateFileDoesNotWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	data, _ := json.Marshal(tokensFile{Tokens: []*Token{{Label: "from-file", Plain: "secret"}}})
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	require.NoError(t, ValidateFile(path))
+	unchanged, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, data, unchanged)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"tokens":[{"label":"broken"}]}`), 0600))
+	assert.Error(t, ValidateFile(path))
+}
+
+func TestReloadPicksUpEditedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	store := NewStore(path)
+	_, raw, err := store.Create("existing", nil, nil, 10)
+	require.NoError(t, err)
+	tok, err := store.Authenticate(raw)
+	require.NoError(t, err)
+	require.NoError(t, store.Charge(tok, 4))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var file tokensFile
+	require.NoError(t, json.Unmarshal(data, &file))
+	file.Tokens = append(file.Tokens, &Token{Label: "added", Plain: "new-secret"})
+	data, _ = json.Marshal(file)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	require.NoError(t, store.Reload(path))
+	_, err = store.Authenticate("new-secret")
+	assert.NoError(t, err)
+	// 路径不变时保留内存中的用量
+	assert.Equal(t, int64(4), store.UsedToday(tok.ID))
+
+	rewritten, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(rewritten), `"new-secret"`)
+
+	// 文件无效时保留原有令牌
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0600))
+	assert.Error(t, store.Reload(path))
+	_, err = store.Authenticate(raw)
+	assert.NoError(t, err)
+}
+
+func TestLegacyTokenHasAllScopes(t *testing.T) {
+	store := NewStore("")
+	assert.False(t, store.Enabled())
+
+	store.SetLegacyToken("legacy")
+	for _, scope := range AllScopes {
+		_, err := store.Authorize("legacy", scope)
+		assert.NoError(t, err)
+	}
+
+	store.SetLegacyToken("")
+	assert.False(t, store.Enabled())
+}
+
+func TestAllowsPair(t *testing.T) {
+	tok := &Token{Pairs: []string{"en-zh-Hans", "*-ja", "de-*"}}
+
+	assert.True(t, tok.AllowsPair("en", "zh-Hans"))
+	assert.True(t, tok.AllowsPair("fr", "ja"))
+	assert.True(t, tok.AllowsPair("de", "fr"))
+	assert.True(t, tok.AllowsPair("auto", "zh-Hans"))
+	assert.False(t, tok.AllowsPair("fr", "zh-Hans"))
+	assert.False(t, tok.AllowsPair("en", "fr"))
+
+	assert.True(t, (&Token{}).AllowsPair("en", "fr"))
+}
+
+func TestQuotaSurvivesRestartAndResetsDaily(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	current := time.Date(2025, 1, 1, 10, 0, 0, 0, time.Local)
+
+	store := NewStore(path)
+	store.now = func() time.Time { return current }
+	_, raw, err := store.Create("limited", nil, nil, 10)
+	require.NoError(t, err)
+
+	tok, err := store.Authenticate(raw)
+	require.NoError(t, err)
+	require.NoError(t, store.Charge(tok, 6))
+	require.NoError(t, store.FlushUsage())
+
+	restarted := NewStore(path)
+	restarted.now = func() time.Time { return current }
+	require.NoError(t, restarted.Load())
+
+	tok, err = restarted.Authenticate(raw)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), restarted.UsedToday(tok.ID))
+	assert.ErrorIs(t, restarted.Charge(tok, 5), ErrQuotaExceeded)
+	assert.NoError(t, restarted.Charge(tok, 4))
+
+	current = current.Add(24 * time.Hour)
+	assert.Equal(t, int64(0), restarted.UsedToday(tok.ID))
+	assert.NoError(t, restarted.Charge(tok, 10))
+}
+
+func TestRefundQuota(t *testing.T) {
+	current := time.Date(2025, 1, 1, 10, 0, 0, 0, time.Local)
+	store := NewStore(filepath.Join(t.TempDir(), "tokens.json"))
+	store.now = func() time.Time { return current }
+	_, raw, err := store.Create("limited", nil, nil, 10)
+	require.NoError(t, err)
+	tok, err := store.Authenticate(raw)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Charge(tok, 8))
+	store.Refund(tok, 5)
+	assert.Equal(t, int64(3), store.UsedToday(tok.ID))
+	assert.NoError(t, store.Charge(tok, 7))
+
+	// 退还不会使用量小于零，跨天后不再退还
+	store.Refund(tok, 100)
+	assert.Equal(t, int64(0), store.UsedToday(tok.ID))
+	require.NoError(t, store.Charge(tok, 4))
+	current = current.Add(24 * time.Hour)
+	store.Refund(tok, 4)
+	assert.NoError(t, store.Charge(tok, 10))
+}
+
+func TestDeleteToken(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "tokens.json"))
+	info, raw, err := store.Create("temp", nil, nil, 0)
+	require.NoError(t, err)
+	require.Len(t, store.List(), 1)
+
+	require.NoError(t, store.Delete(info.ID))
+	assert.ErrorIs(t, store.Delete(info.ID), ErrTokenNotFound)
+
+	_, err = store.Authenticate(raw)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
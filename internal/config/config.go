@@ -4,53 +4,316 @@ import (
 	"flag"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/xxnuo/MTranServer/internal/logger"
 	"github.com/xxnuo/MTranServer/internal/utils"
 )
 
 type Config struct {
-	LogLevel  string
-	HomeDir   string
-	ConfigDir string
-	ModelDir  string
+	LogLevel       string
+	LogFormat      string
+	LogFile        string
+	LogMaxSize     int
+	LogMaxBackups  int
+	LogMaxAge      int
+	LogRotateDaily bool
+	HomeDir        string
+	ConfigDir      string
+	ConfigFile     string
+	ModelDir       string
+	// ModelMirrors 模型文件镜像地址，逗号分隔，按顺序尝试
+	ModelMirrors string
+	// RecordsURL records.json 地址，逗号分隔，按顺序尝试
+	RecordsURL string
+	// RecordsChannel Mozilla records 渠道：production 或 preview，设置 RecordsURL 时不使用
+	RecordsChannel string
+	// RecordsSources 附加的记录来源，逗号分隔，靠后的优先级更高
+	RecordsSources string
+	// RecordsRefreshInterval 后台刷新 records.json 的间隔（秒），0 为不刷新
+	RecordsRefreshInterval int
+	// PivotLanguage 没有直接的语言对时优先使用的中转语言
+	PivotLanguage string
+	// MaxPivotHops 一次翻译最多经过的语言对数量，1 为不中转
+	MaxPivotHops int
+	// ModelArchitecture 模型架构偏好，逗号分隔，按顺序选择记录中可用的架构
+	ModelArchitecture string
+	// LocalModelsDir 本地模型目录，其中的 models.json 声明本地训练的模型
+	LocalModelsDir string
+	// DetectorHighAccuracy 语言检测使用高精度模式，短文本更准确，但内存占用和耗时更高
+	DetectorHighAccuracy bool
+	// DetectorMinDistance 最可能的语言与第二候选语言的最小相对距离（0 到 0.99），达不到时视为无法检测
+	DetectorMinDistance float64
+	// DetectorMinConfidence 置信度低于该值的检测结果按请求提示调整，不影响混合语言分段
+	DetectorMinConfidence float64
+	// DetectorMaxLanguages 一段文本中最多识别的语言数量
+	DetectorMaxLanguages int
+	// ModelAutoUpgrade 后台刷新的记录中有新版本时滚动升级运行中的引擎
+	ModelAutoUpgrade bool
+	// ModelKeepVersions 每个语言对保留的旧版本数量
+	ModelKeepVersions int
+	// ModelDiskQuota 模型目录的磁盘配额（MB），超出时删除最旧的保留版本，0 为不限
+	ModelDiskQuota int
+	// TrustedProxies 受信任的反向代理地址或网段，逗号分隔，只有来自这些地址的请求才使用 X-Forwarded-For 中的客户端 IP
+	TrustedProxies string
 
 	Host               string
 	Port               string
+	TLSCert            string
+	TLSKey             string
+	TLSClientCA        string
+	TLSClientAuth      string
 	EnableWebUI        bool
 	EnableOfflineMode  bool
 	WorkerIdleTimeout  int
 	WorkersPerLanguage int
 	APIToken           string
+	TokensFile         string
+	RateLimitAPI       string
+	RateLimitPlugin    string
+	CORSAPI            string
+	CORSPlugin         string
+	MaxBodySize        int
+	MaxTextLength      int
+	MaxBatchSize       int
+	ChunkSize          int
+	ModelLoading       string
+	OtelEndpoint       string
+
+	// Pairs 按语言对覆盖的配置，键为 "en-zh-Hans"，仅来自配置文件
+	Pairs map[string]PairConfig
+}
+
+// PairConfig 单个语言对的配置，零值表示使用全局配置
+type PairConfig struct {
+	WorkerIdleTimeout  int `yaml:"worker-idle-timeout"`
+	WorkersPerLanguage int `yaml:"workers-per-language"`
+	// Version 固定使用的模型版本，为空时使用最新版本
+	Version string `yaml:"version"`
+	// Architecture 模型架构偏好，格式与 model-architecture 相同
+	Architecture string `yaml:"architecture"`
 }
 
 var (
 	GlobalConfig *Config = nil
+
+	globalMu sync.RWMutex
+	// envByFlag 记录每个参数对应的环境变量，用于判断配置文件中的值是否被覆盖
+	envByFlag = make(map[string]string)
 )
 
-// GetConfig 加载配置，优先级：命令行参数 > 环境变量 > 默认值
-func GetConfig() *Config {
-	if GlobalConfig != nil {
-		return GlobalConfig
+// LoggerOptions 将日志相关配置转换为 logger 选项
+func (c *Config) LoggerOptions() logger.Options {
+	opts := logger.Options{
+		Level:      c.LogLevel,
+		Format:     c.LogFormat,
+		File:       c.LogFile,
+		MaxSizeMB:  c.LogMaxSize,
+		MaxBackups: c.LogMaxBackups,
+		MaxAge:     time.Duration(c.LogMaxAge) * 24 * time.Hour,
+	}
+	if c.LogRotateDaily {
+		opts.RotateInterval = 24 * time.Hour
+	}
+	return opts
+}
+
+// ConfigFilePath 返回配置文件路径，未指定时使用配置目录下的 config.yml
+func (c *Config) ConfigFilePath() string {
+	if c.ConfigFile != "" {
+		return c.ConfigFile
+	}
+	return filepath.Join(c.ConfigDir, "config.yml")
+}
+
+// WorkerIdleTimeoutFor 返回语言对的 Worker 空闲超时时间
+func (c *Config) WorkerIdleTimeoutFor(fromLang, toLang string) time.Duration {
+	timeout := c.WorkerIdleTimeout
+	if p, ok := c.Pairs[fromLang+"-"+toLang]; ok && p.WorkerIdleTimeout > 0 {
+		timeout = p.WorkerIdleTimeout
+	}
+	return time.Duration(timeout) * time.Second
+}
+
+// WorkersFor 返回语言对的 Worker 数量
+func (c *Config) WorkersFor(fromLang, toLang string) int {
+	workers := c.WorkersPerLanguage
+	if p, ok := c.Pairs[fromLang+"-"+toLang]; ok && p.WorkersPerLanguage > 0 {
+		workers = p.WorkersPerLanguage
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+	return workers
+}
+
+// ModelVersionFor 返回语言对固定使用的模型版本，未固定时返回空字符串
+func (c *Config) ModelVersionFor(fromLang, toLang string) string {
+	return c.Pairs[fromLang+"-"+toLang].Version
+}
+
+// PivotHops 返回一次翻译最多经过的语言对数量，未设置时为 2
+func (c *Config) PivotHops() int {
+	if c.MaxPivotHops <= 0 {
+		return 2
 	}
-	cfg := &Config{}
+	return c.MaxPivotHops
+}
+
+// DetectorLanguageLimit 返回一段文本中最多识别的语言数量，未设置时为 2
+func (c *Config) DetectorLanguageLimit() int {
+	if c.DetectorMaxLanguages <= 0 {
+		return 2
+	}
+	return c.DetectorMaxLanguages
+}
+
+// TrustedProxyList 返回受信任的代理列表，未设置时返回 nil
+func (c *Config) TrustedProxyList() []string {
+	var proxies []string
+	for _, item := range strings.Split(c.TrustedProxies, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			proxies = append(proxies, item)
+		}
+	}
+	return proxies
+}
+
+// architectureAliases 模型架构偏好的简写
+var architectureAliases = map[string][]string{
+	"fast":    {"tiny", "base-memory", "base"},
+	"quality": {"base", "base-memory", "tiny"},
+}
+
+// ArchitecturesFor 返回语言对的模型架构偏好，按优先级排序，未设置时返回 nil
+func (c *Config) ArchitecturesFor(fromLang, toLang string) []string {
+	pref := c.Pairs[fromLang+"-"+toLang].Architecture
+	if pref == "" {
+		pref = c.ModelArchitecture
+	}
+	return ParseArchitectures(pref)
+}
+
+// ParseArchitectures 解析逗号分隔的模型架构偏好，展开 fast 和 quality
+func ParseArchitectures(s string) []string {
+	var archs []string
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if alias, ok := architectureAliases[item]; ok {
+			archs = append(archs, alias...)
+		} else if item != "" {
+			archs = append(archs, item)
+		}
+	}
+	return archs
+}
+
+type binder struct {
+	fs *flag.FlagSet
+}
+
+func (b binder) String(p *string, name, env, value, usage string) {
+	envByFlag[name] = env
+	b.fs.StringVar(p, name, utils.GetEnv(env, value), usage)
+}
+
+func (b binder) Bool(p *bool, name, env string, value bool, usage string) {
+	envByFlag[name] = env
+	b.fs.BoolVar(p, name, utils.GetBoolEnv(env, value), usage)
+}
+
+func (b binder) Int(p *int, name, env string, value int, usage string) {
+	envByFlag[name] = env
+	b.fs.IntVar(p, name, utils.GetIntEnv(env, value), usage)
+}
+
+func (b binder) Float64(p *float64, name, env string, value float64, usage string) {
+	envByFlag[name] = env
+	b.fs.Float64Var(p, name, utils.GetFloatEnv(env, value), usage)
+}
+
+// bindFlags 注册所有参数，默认值取自环境变量
+func bindFlags(fs *flag.FlagSet, cfg *Config) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		panic(err)
 	}
 	cfg.HomeDir = filepath.Join(homeDir, ".config", "mtran")
-	cfg.ConfigDir = filepath.Join(cfg.HomeDir, "server")
-	cfg.ModelDir = filepath.Join(cfg.HomeDir, "models")
-
-	flag.StringVar(&cfg.LogLevel, "log-level", utils.GetEnv("MT_LOG_LEVEL", "warn"), "Log level (debug, info, warn, error)")
-	flag.StringVar(&cfg.ConfigDir, "config-dir", utils.GetEnv("MT_CONFIG_DIR", cfg.ConfigDir), "Config directory")
-	flag.StringVar(&cfg.ModelDir, "model-dir", utils.GetEnv("MT_MODEL_DIR", cfg.ModelDir), "Model directory")
-	flag.StringVar(&cfg.Host, "host", utils.GetEnv("MT_HOST", "0.0.0.0"), "Server host address")
-	flag.StringVar(&cfg.Port, "port", utils.GetEnv("MT_PORT", "8989"), "Server port")
-	flag.BoolVar(&cfg.EnableWebUI, "ui", utils.GetBoolEnv("MT_ENABLE_UI", true), "Enable web UI")
-	flag.BoolVar(&cfg.EnableOfflineMode, "offline", utils.GetBoolEnv("MT_OFFLINE", false), "Enable offline mode")
-	flag.IntVar(&cfg.WorkerIdleTimeout, "worker-idle-timeout", utils.GetIntEnv("MT_WORKER_IDLE_TIMEOUT", 60), "Worker idle timeout in seconds")
-	flag.IntVar(&cfg.WorkersPerLanguage, "workers-per-language", utils.GetIntEnv("MT_WORKERS_PER_LANGUAGE", 1), "Number of workers per language pair")
-	flag.StringVar(&cfg.APIToken, "api-token", utils.GetEnv("MT_API_TOKEN", ""), "API access token")
+	defaultConfigDir := filepath.Join(cfg.HomeDir, "server")
+	defaultModelDir := filepath.Join(cfg.HomeDir, "models")
+
+	b := binder{fs: fs}
+	b.String(&cfg.LogLevel, "log-level", "MT_LOG_LEVEL", "warn", "Log level (debug, info, warn, error)")
+	b.String(&cfg.LogFormat, "log-format", "MT_LOG_FORMAT", "text", "Log format (text, json)")
+	b.String(&cfg.LogFile, "log-file", "MT_LOG_FILE", "", "Write logs to this file instead of stdout/stderr")
+	b.Int(&cfg.LogMaxSize, "log-max-size", "MT_LOG_MAX_SIZE", 100, "Rotate the log file when it reaches this size in MB, 0 to disable")
+	b.Int(&cfg.LogMaxBackups, "log-max-backups", "MT_LOG_MAX_BACKUPS", 7, "Maximum number of rotated log files to keep, 0 to keep all")
+	b.Int(&cfg.LogMaxAge, "log-max-age", "MT_LOG_MAX_AGE", 30, "Maximum days to keep rotated log files, 0 to keep forever")
+	b.Bool(&cfg.LogRotateDaily, "log-rotate-daily", "MT_LOG_ROTATE_DAILY", false, "Rotate the log file every day")
+	b.String(&cfg.ConfigDir, "config-dir", "MT_CONFIG_DIR", defaultConfigDir, "Config directory")
+	b.String(&cfg.ConfigFile, "config", "MT_CONFIG_FILE", "", "Config file (default: <config-dir>/config.yml)")
+	b.String(&cfg.ModelDir, "model-dir", "MT_MODEL_DIR", defaultModelDir, "Model directory")
+	b.String(&cfg.ModelMirrors, "model-mirrors", "MT_MODEL_MIRRORS", "", "Comma separated base URLs of model file mirrors tried in order, http(s):// or file:// (default: Mozilla CDN)")
+	b.String(&cfg.PivotLanguage, "pivot-language", "MT_PIVOT_LANGUAGE", "en", "Preferred pivot language when there is no direct language pair")
+	b.Int(&cfg.MaxPivotHops, "max-pivot-hops", "MT_MAX_PIVOT_HOPS", 2, "Maximum number of language pairs chained for one translation, 1 disables pivoting")
+	b.String(&cfg.ModelArchitecture, "model-architecture", "MT_MODEL_ARCHITECTURE", "", "Comma separated model architectures in order of preference, such as tiny,base-memory, or fast / quality (default: architecture of the latest version)")
+	b.Bool(&cfg.DetectorHighAccuracy, "detector-high-accuracy", "MT_DETECTOR_HIGH_ACCURACY", false, "Use the high accuracy language detector, more accurate on short texts but slower and uses more memory")
+	b.Float64(&cfg.DetectorMinDistance, "detector-min-distance", "MT_DETECTOR_MIN_DISTANCE", 0, "Minimum relative distance (0-0.99) between the most likely and the next language, below which the language is unknown")
+	b.Float64(&cfg.DetectorMinConfidence, "detector-min-confidence", "MT_DETECTOR_MIN_CONFIDENCE", 0.5, "Detection results below this confidence are adjusted with request hints (does not affect mixed-language segmentation)")
+	b.Int(&cfg.DetectorMaxLanguages, "detector-max-languages", "MT_DETECTOR_MAX_LANGUAGES", 2, "Maximum number of languages detected in one text")
+	b.String(&cfg.LocalModelsDir, "local-models-dir", "MT_LOCAL_MODELS_DIR", "", "Directory with a models.json manifest of custom Bergamot models")
+	b.Int(&cfg.ModelKeepVersions, "model-keep-versions", "MT_MODEL_KEEP_VERSIONS", 0, "Number of previous model versions to keep per language pair")
+	b.Int(&cfg.ModelDiskQuota, "model-disk-quota", "MT_MODEL_DISK_QUOTA", 0, "Model directory disk quota in MB, oldest kept versions are removed when exceeded (0 for unlimited)")
+	b.String(&cfg.RecordsURL, "records-url", "MT_RECORDS_URL", "", "Comma separated records.json URLs tried in order (default: Mozilla remote settings)")
+	b.String(&cfg.RecordsChannel, "records-channel", "MT_RECORDS_CHANNEL", "preview", "Mozilla records channel when --records-url is not set (production, preview)")
+	b.String(&cfg.RecordsSources, "records-sources", "MT_RECORDS_SOURCES", "", "Comma separated extra records sources merged over records.json, later ones take precedence: local JSON files, file:// or http(s):// URLs")
+	b.Int(&cfg.RecordsRefreshInterval, "records-refresh-interval", "MT_RECORDS_REFRESH_INTERVAL", 0, "Interval in seconds for refreshing records.json in the background (0 to disable)")
+	b.Bool(&cfg.ModelAutoUpgrade, "model-auto-upgrade", "MT_MODEL_AUTO_UPGRADE", true, "Roll running engines onto newer model versions found by the background records refresh")
+	b.String(&cfg.Host, "host", "MT_HOST", "0.0.0.0", "Server host address")
+	b.String(&cfg.Port, "port", "MT_PORT", "8989", "Server port")
+	b.String(&cfg.TLSCert, "tls-cert", "MT_TLS_CERT", utils.GetEnv("HTTPS_CERT", ""), "TLS certificate file, enables HTTPS when set")
+	b.String(&cfg.TLSKey, "tls-key", "MT_TLS_KEY", utils.GetEnv("HTTPS_KEY", ""), "TLS private key file")
+	b.String(&cfg.TLSClientCA, "tls-client-ca", "MT_TLS_CLIENT_CA", "", "CA bundle for verifying client certificates, enables mutual TLS when set")
+	b.String(&cfg.TLSClientAuth, "tls-client-auth", "MT_TLS_CLIENT_AUTH", "require", "Client certificate mode when --tls-client-ca is set (require, verify-if-given)")
+	b.Bool(&cfg.EnableWebUI, "ui", "MT_ENABLE_UI", true, "Enable web UI")
+	b.Bool(&cfg.EnableOfflineMode, "offline", "MT_OFFLINE", false, "Enable offline mode")
+	b.Int(&cfg.WorkerIdleTimeout, "worker-idle-timeout", "MT_WORKER_IDLE_TIMEOUT", 60, "Worker idle timeout in seconds")
+	b.Int(&cfg.WorkersPerLanguage, "workers-per-language", "MT_WORKERS_PER_LANGUAGE", 1, "Number of workers per language pair")
+	b.String(&cfg.APIToken, "api-token", "MT_API_TOKEN", "", "API access token")
+	b.String(&cfg.TokensFile, "tokens-file", "MT_TOKENS_FILE", "", "API tokens file (default: <config-dir>/tokens.json)")
+	b.String(&cfg.TrustedProxies, "trusted-proxies", "MT_TRUSTED_PROXIES", "", "Comma separated reverse proxy IPs or CIDRs whose X-Forwarded-For header is trusted (default: none)")
+	b.String(&cfg.RateLimitAPI, "rate-limit-api", "MT_RATE_LIMIT_API", "", "Rate limit for core API, e.g. requests=60,chars=100000,window=1m,key=token")
+	b.String(&cfg.RateLimitPlugin, "rate-limit-plugin", "MT_RATE_LIMIT_PLUGIN", "", "Rate limit for plugin compatible API, same format as --rate-limit-api")
+	b.String(&cfg.CORSAPI, "cors-api", "MT_CORS_API", "", "CORS policy for core API, e.g. origins=https://app.example.com https://*.example.com,credentials=true,max-age=10m")
+	b.String(&cfg.CORSPlugin, "cors-plugin", "MT_CORS_PLUGIN", "", "CORS policy for plugin compatible API, same format as --cors-api")
+	b.Int(&cfg.MaxBodySize, "max-body-size", "MT_MAX_BODY_SIZE", 1<<20, "Maximum request body size in bytes, 0 for unlimited")
+	b.Int(&cfg.MaxTextLength, "max-text-length", "MT_MAX_TEXT_LENGTH", 100000, "Maximum characters per text, 0 for unlimited")
+	b.Int(&cfg.MaxBatchSize, "max-batch-size", "MT_MAX_BATCH_SIZE", 1000, "Maximum number of texts per request, 0 for unlimited")
+	b.Int(&cfg.ChunkSize, "chunk-size", "MT_CHUNK_SIZE", 2000, "Split texts longer than this many characters at sentence boundaries before translating, 0 to disable")
+	b.String(&cfg.ModelLoading, "model-loading", "MT_MODEL_LOADING", "wait", "Behavior of requests whose model is being downloaded (wait, accept: respond 202, reject: respond 503)")
+	b.String(&cfg.OtelEndpoint, "otel-endpoint", "MT_OTEL_ENDPOINT", "", "OTLP/HTTP trace endpoint, tracing is disabled when empty")
+}
+
+// GetConfig 加载配置，优先级：命令行参数 > 环境变量 > 配置文件 > 默认值
+// 配置文件需在 flag.Parse 之后调用 LoadFile 加载
+func GetConfig() *Config {
+	globalMu.RLock()
+	cfg := GlobalConfig
+	globalMu.RUnlock()
+	if cfg != nil {
+		return cfg
+	}
+
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	if GlobalConfig != nil {
+		return GlobalConfig
+	}
+
+	cfg = &Config{}
+	bindFlags(flag.CommandLine, cfg)
 
 	GlobalConfig = cfg
 	return cfg
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"flag"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xxnuo/MTranServer/internal/logger"
+)
+
+func writeConfigFile(t *testing.T, path, content string) {
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestReadFileRejectsInvalidOptions(t *testing.T) {
+	GetConfig()
+	path := filepath.Join(t.TempDir(), "config.yml")
+
+	for _, content := range []string{
+		"no-such-option: 1\n",
+		"config-dir: /tmp\n",
+		"port: [1, 2]\n",
+		"pairs: 3\n",
+	} {
+		writeConfigFile(t, path, content)
+		_, _, err := readFile(path)
+		assert.Error(t, err, content)
+	}
+
+	values, pairs, err := readFile(filepath.Join(t.TempDir(), "missing.yml"))
+	assert.NoError(t, err)
+	assert.Nil(t, values)
+	assert.Nil(t, pairs)
+}
+
+func TestApplyFilePrecedence(t *testing.T) {
+	t.Setenv("MT_PORT", "9000")
+	explicitFlags["host"] = "127.0.0.1"
+	t.Cleanup(func() { delete(explicitFlags, "host") })
+
+	cfg := &Config{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	bindFlags(fs, cfg)
+	require.NoError(t, fs.Set("host", "127.0.0.1"))
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	writeConfigFile(t, path, `
+host: 0.0.0.0
+port: 7000
+log-level: debug
+offline: true
+pairs:
+  en-zh-Hans:
+    worker-idle-timeout: 600
+    workers-per-language: 3
+`)
+	values, pairs, err := readFile(path)
+	require.NoError(t, err)
+	require.NoError(t, applyFile(fs, cfg, values, pairs))
+
+	assert.Equal(t, "127.0.0.1", cfg.Host)
+	assert.Equal(t, "9000", cfg.Port)
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.True(t, cfg.EnableOfflineMode)
+
+	assert.Equal(t, 600*time.Second, cfg.WorkerIdleTimeoutFor("en", "zh-Hans"))
+	assert.Equal(t, time.Duration(cfg.WorkerIdleTimeout)*time.Second, cfg.WorkerIdleTimeoutFor("en", "ja"))
+	assert.Equal(t, 3, cfg.WorkersFor("en", "zh-Hans"))
+	assert.Equal(t, 1, cfg.WorkersFor("en", "ja"))
+}
+
+func TestReloadAppliesSafeSettingsAndRejectsInvalid(t *testing.T) {
+	base := GetConfig()
+	path := filepath.Join(t.TempDir(), "config.yml")
+
+	cfg := *base
+	cfg.ConfigFile = path
+	GlobalConfig = &cfg
+	t.Cleanup(func() {
+		GlobalConfig = base
+		logger.SetLevel("info")
+	})
+
+	var notified *Config
+	Subscribe(func(prev, next *Config) { notified = next })
+
+	writeConfigFile(t, path, "log-level: debug\nworker-idle-timeout: 120\nport: 1234\n")
+	require.NoError(t, Reload())
+
+	current := GetConfig()
+	assert.Equal(t, "debug", current.LogLevel)
+	assert.Equal(t, 120, current.WorkerIdleTimeout)
+	assert.Equal(t, cfg.Port, current.Port, "port requires a restart")
+	assert.Equal(t, "debug", logger.GetLevel())
+	assert.Same(t, current, notified)
+
+	writeConfigFile(t, path, "log-level: loud\n")
+	assert.Error(t, Reload())
+	assert.Same(t, current, GetConfig())
+
+	writeConfigFile(t, path, "worker-idle-timeout: 120\ndetector-high-accuracy: true\ndetector-min-confidence: 0.7\ndetector-max-languages: 3\n")
+	require.NoError(t, Reload())
+	current = GetConfig()
+	assert.True(t, current.DetectorHighAccuracy)
+	assert.Equal(t, 0.7, current.DetectorMinConfidence)
+	assert.Equal(t, 3, current.DetectorLanguageLimit())
+
+	writeConfigFile(t, path, "detector-min-distance: 1.5\n")
+	assert.Error(t, Reload())
+	assert.Same(t, current, GetConfig())
+
+	AddValidator(func(c *Config) error {
+		if c.WorkerIdleTimeout > 1000 {
+			return assert.AnError
+		}
+		return nil
+	})
+	writeConfigFile(t, path, "worker-idle-timeout: 5000\n")
+	assert.ErrorIs(t, Reload(), assert.AnError)
+	assert.Equal(t, 120, GetConfig().WorkerIdleTimeout)
+}
+
+func TestArchitecturesFor(t *testing.T) {
+	cfg := &Config{
+		ModelArchitecture: "fast",
+		Pairs:             map[string]PairConfig{"en-ja": {Architecture: " base , tiny"}},
+	}
+	assert.Equal(t, []string{"tiny", "base-memory", "base"}, cfg.ArchitecturesFor("en", "de"))
+	assert.Equal(t, []string{"base", "tiny"}, cfg.ArchitecturesFor("en", "ja"))
+	assert.Nil(t, (&Config{}).ArchitecturesFor("en", "de"))
+}
+
+func TestLoadFileRejectsInvalid(t *testing.T) {
+	cfg := GetConfig()
+	saved, savedFlags := *cfg, maps.Clone(explicitFlags)
+	// LoadFile 将已设置的命令行参数记为显式参数，每次加载前恢复
+	// flag.CommandLine 会记住设置过的选项，因此每个用例使用不同的选项
+	reset := func() {
+		*cfg = saved
+		clear(explicitFlags)
+		maps.Copy(explicitFlags, savedFlags)
+	}
+	t.Cleanup(reset)
+	path := filepath.Join(t.TempDir(), "config.yml")
+
+	// 启动时与重新加载时使用相同的校验
+	for _, content := range []string{
+		"log-level: bogus\n",
+		"chunk-size: -1\n",
+		"max-pivot-hops: 0\n",
+		"trusted-proxies: not-an-ip\n",
+	} {
+		reset()
+		cfg.ConfigFile = path
+		writeConfigFile(t, path, content)
+		assert.Error(t, LoadFile(cfg), content)
+	}
+
+	reset()
+	cfg.ConfigFile = path
+	writeConfigFile(t, path, "records-refresh-interval: 60\n")
+	require.NoError(t, LoadFile(cfg))
+	assert.Equal(t, 60, cfg.RecordsRefreshInterval)
+}
@@ -0,0 +1,330 @@
+package config
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/xxnuo/MTranServer/internal/logger"
+)
+
+// 配置文件中不允许设置的参数，配置文件的位置由它们决定
+var fileForbidden = map[string]bool{
+	"config":     true,
+	"config-dir": true,
+}
+
+var (
+	reloadMu sync.Mutex
+	hooksMu  sync.Mutex
+
+	// explicitFlags 命令行显式设置的参数，重新加载时保持不变
+	explicitFlags = make(map[string]string)
+	validators    []func(*Config) error
+	subscribers   []func(prev, next *Config)
+)
+
+// AddValidator 注册配置校验函数，重新加载时任一校验失败则拒绝新配置
+func AddValidator(fn func(*Config) error) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	validators = append(validators, fn)
+}
+
+// Subscribe 注册配置变更回调，重新加载成功后调用
+func Subscribe(fn func(prev, next *Config)) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	subscribers = append(subscribers, fn)
+}
+
+// readFile 读取 YAML 配置文件，键与命令行参数同名，pairs 为按语言对覆盖的配置
+// 文件不存在时返回空配置
+func readFile(path string) (map[string]string, map[string]PairConfig, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var raw map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	var pairs map[string]PairConfig
+	for key, node := range raw {
+		if key == "pairs" {
+			if err := node.Decode(&pairs); err != nil {
+				return nil, nil, fmt.Errorf("invalid pairs in config file: %w", err)
+			}
+			continue
+		}
+		if fileForbidden[key] {
+			return nil, nil, fmt.Errorf("%s cannot be set in the config file", key)
+		}
+		if _, ok := envByFlag[key]; !ok {
+			return nil, nil, fmt.Errorf("unknown option in config file: %s", key)
+		}
+		if node.Kind != yaml.ScalarNode {
+			return nil, nil, fmt.Errorf("option %s in config file must be a scalar", key)
+		}
+		values[key] = node.Value
+	}
+	return values, pairs, nil
+}
+
+// applyFile 将配置文件的值写入 fs，命令行或环境变量已设置的参数不会被覆盖
+func applyFile(fs *flag.FlagSet, cfg *Config, values map[string]string, pairs map[string]PairConfig) error {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := explicitFlags[name]; ok {
+			continue
+		}
+		if env := envByFlag[name]; env != "" && os.Getenv(env) != "" {
+			continue
+		}
+		if err := fs.Set(name, values[name]); err != nil {
+			return fmt.Errorf("invalid value for %s in config file: %w", name, err)
+		}
+	}
+	cfg.Pairs = pairs
+	return nil
+}
+
+// validate 校验配置值
+func validate(cfg *Config) error {
+	switch strings.ToLower(cfg.LogLevel) {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		return fmt.Errorf("invalid log level: %s", cfg.LogLevel)
+	}
+	switch strings.ToLower(cfg.LogFormat) {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("invalid log format: %s", cfg.LogFormat)
+	}
+	if cfg.WorkerIdleTimeout <= 0 {
+		return fmt.Errorf("worker-idle-timeout must be positive")
+	}
+	switch cfg.ModelLoading {
+	case "", "wait", "accept", "reject":
+	default:
+		return fmt.Errorf("invalid model-loading: %s", cfg.ModelLoading)
+	}
+	if cfg.MaxBodySize < 0 || cfg.MaxTextLength < 0 || cfg.MaxBatchSize < 0 || cfg.ChunkSize < 0 {
+		return fmt.Errorf("request limits and chunk size must not be negative")
+	}
+	if cfg.ModelKeepVersions < 0 || cfg.ModelDiskQuota < 0 {
+		return fmt.Errorf("model-keep-versions and model-disk-quota must not be negative")
+	}
+	if cfg.MaxPivotHops < 1 {
+		return fmt.Errorf("max-pivot-hops must be at least 1")
+	}
+	if cfg.DetectorMinDistance < 0 || cfg.DetectorMinDistance > 0.99 {
+		return fmt.Errorf("detector-min-distance must be between 0 and 0.99")
+	}
+	if cfg.DetectorMinConfidence < 0 || cfg.DetectorMinConfidence > 1 {
+		return fmt.Errorf("detector-min-confidence must be between 0 and 1")
+	}
+	if cfg.DetectorMaxLanguages < 1 {
+		return fmt.Errorf("detector-max-languages must be at least 1")
+	}
+	switch cfg.RecordsChannel {
+	case "", "production", "preview":
+	default:
+		return fmt.Errorf("invalid records-channel: %s", cfg.RecordsChannel)
+	}
+	if cfg.RecordsRefreshInterval < 0 {
+		return fmt.Errorf("records-refresh-interval must not be negative")
+	}
+	for _, proxy := range cfg.TrustedProxyList() {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return fmt.Errorf("invalid trusted proxy: %s", proxy)
+			}
+		}
+	}
+	for pair, p := range cfg.Pairs {
+		if p.WorkerIdleTimeout < 0 || p.WorkersPerLanguage < 0 {
+			return fmt.Errorf("invalid override for pair %s: values must not be negative", pair)
+		}
+	}
+
+	hooksMu.Lock()
+	fns := append([]func(*Config) error(nil), validators...)
+	hooksMu.Unlock()
+	for _, fn := range fns {
+		if err := fn(cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadFile 在 flag.Parse 之后加载配置文件，并与重新加载时一样校验配置
+func LoadFile(cfg *Config) error {
+	flag.Visit(func(f *flag.Flag) {
+		explicitFlags[f.Name] = f.Value.String()
+	})
+
+	values, pairs, err := readFile(cfg.ConfigFilePath())
+	if err != nil {
+		return err
+	}
+	if err := applyFile(flag.CommandLine, cfg, values, pairs); err != nil {
+		return err
+	}
+	if values != nil || pairs != nil {
+		logger.Debug("Loaded config file %s", cfg.ConfigFilePath())
+	}
+	if err := validate(cfg); err != nil {
+		return fmt.Errorf("config rejected: %w", err)
+	}
+	return nil
+}
+
+// 可以在运行时重新加载的配置，其余配置需要重启服务才能生效
+func copyReloadable(dst, src *Config) {
+	dst.LogLevel = src.LogLevel
+	dst.LogFormat = src.LogFormat
+	dst.WorkerIdleTimeout = src.WorkerIdleTimeout
+	dst.APIToken = src.APIToken
+	dst.TokensFile = src.TokensFile
+	dst.RateLimitAPI = src.RateLimitAPI
+	dst.RateLimitPlugin = src.RateLimitPlugin
+	dst.CORSAPI = src.CORSAPI
+	dst.CORSPlugin = src.CORSPlugin
+	dst.MaxBodySize = src.MaxBodySize
+	dst.MaxTextLength = src.MaxTextLength
+	dst.MaxBatchSize = src.MaxBatchSize
+	dst.ChunkSize = src.ChunkSize
+	dst.ModelLoading = src.ModelLoading
+	dst.ModelMirrors = src.ModelMirrors
+	dst.RecordsURL = src.RecordsURL
+	dst.RecordsChannel = src.RecordsChannel
+	dst.RecordsSources = src.RecordsSources
+	dst.LocalModelsDir = src.LocalModelsDir
+	dst.ModelArchitecture = src.ModelArchitecture
+	dst.PivotLanguage = src.PivotLanguage
+	dst.MaxPivotHops = src.MaxPivotHops
+	dst.RecordsRefreshInterval = src.RecordsRefreshInterval
+	dst.ModelAutoUpgrade = src.ModelAutoUpgrade
+	dst.DetectorHighAccuracy = src.DetectorHighAccuracy
+	dst.DetectorMinDistance = src.DetectorMinDistance
+	dst.DetectorMinConfidence = src.DetectorMinConfidence
+	dst.DetectorMaxLanguages = src.DetectorMaxLanguages
+	dst.ModelKeepVersions = src.ModelKeepVersions
+	dst.ModelDiskQuota = src.ModelDiskQuota
+	dst.Pairs = src.Pairs
+}
+
+// Reload 重新读取环境变量与配置文件，校验通过后替换全局配置并通知订阅者
+// 只有可热更新的配置会生效，其余配置的变化仅记录警告
+func Reload() error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	cur := GetConfig()
+
+	next := &Config{}
+	fs := flag.NewFlagSet("reload", flag.ContinueOnError)
+	bindFlags(fs, next)
+	for name, value := range explicitFlags {
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("failed to restore flag %s: %w", name, err)
+		}
+	}
+
+	values, pairs, err := readFile(cur.ConfigFilePath())
+	if err != nil {
+		return err
+	}
+	if err := applyFile(fs, next, values, pairs); err != nil {
+		return err
+	}
+
+	merged := *cur
+	copyReloadable(&merged, next)
+
+	// 比较不可热更新的配置，提示需要重启
+	restartNeeded := *next
+	copyReloadable(&restartNeeded, cur)
+	restartNeeded.HomeDir = cur.HomeDir
+	if !reflect.DeepEqual(restartNeeded, *cur) {
+		logger.Warn("Some changed settings require a restart to take effect")
+	}
+
+	if err := validate(&merged); err != nil {
+		return fmt.Errorf("config rejected: %w", err)
+	}
+
+	globalMu.Lock()
+	GlobalConfig = &merged
+	globalMu.Unlock()
+
+	logger.SetLevel(merged.LogLevel)
+	if err := logger.SetFormat(merged.LogFormat); err != nil {
+		logger.Warn("%v", err)
+	}
+
+	hooksMu.Lock()
+	fns := append([]func(prev, next *Config){}, subscribers...)
+	hooksMu.Unlock()
+	for _, fn := range fns {
+		fn(cur, &merged)
+	}
+
+	logger.Info("Configuration reloaded")
+	return nil
+}
+
+// Watch 定期检查配置文件，变化时重新加载
+func Watch(ctx context.Context, interval time.Duration) {
+	path := GetConfig().ConfigFilePath()
+	last := modTime(path)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			mt := modTime(path)
+			if mt.Equal(last) {
+				continue
+			}
+			last = mt
+			if err := Reload(); err != nil {
+				logger.Error("Failed to reload config: %v", err)
+			}
+		}
+	}
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
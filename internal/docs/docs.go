@@ -63,45 +63,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/deepl": {
-            "post": {
-                "description": "兼容 DeepL API v2 的翻译接口",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/models": {
+            "get": {
+                "description": "列出所有语言对的模型状态，包括是否已下载、版本、磁盘占用和是否已加载",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "插件"
-                ],
-                "summary": "DeepL 翻译兼容接口",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "API Token",
-                        "name": "token",
-                        "in": "query"
-                    },
-                    {
-                        "description": "DeepL 翻译请求",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.DeeplTranslateRequest"
-                        }
-                    }
+                    "管理"
                 ],
+                "summary": "列出模型",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.DeeplTranslateResponse"
+                            "$ref": "#/definitions/handlers.ModelsResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -109,8 +89,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -127,12 +107,20 @@ const docTemplate = `{
                             }
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "ApiKeyQuery": []
+                    }
+                ]
             }
         },
-        "/google/language/translate/v2": {
+        "/admin/models/download": {
             "post": {
-                "description": "兼容 Google Translate API v2 的翻译接口",
+                "description": "在后台下载语言对的模型，省略 from 时下载目标语言的所有语言对，可通过模型状态查询进度",
                 "consumes": [
                     "application/json"
                 ],
@@ -140,31 +128,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "插件"
+                    "管理"
                 ],
-                "summary": "Google 翻译兼容接口",
+                "summary": "下载模型",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "API Key",
-                        "name": "key",
-                        "in": "query"
-                    },
-                    {
-                        "description": "Google 翻译请求",
+                        "description": "下载模型请求",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.GoogleTranslateRequest"
+                            "$ref": "#/definitions/handlers.DownloadModelRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
-                            "$ref": "#/definitions/handlers.GoogleTranslateResponse"
+                            "$ref": "#/definitions/handlers.ModelsResponse"
                         }
                     },
                     "400": {
@@ -185,8 +167,17 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -194,84 +185,79 @@ const docTemplate = `{
                             }
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "ApiKeyQuery": []
+                    }
+                ]
             }
         },
-        "/google/translate_a/single": {
+        "/admin/models/downloads": {
             "get": {
-                "description": "兼容 Google translate_a/single 的翻译接口",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "返回各语言对最近一次模型下载的进度，包括每个文件的字节数、总大小和下载速度",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "插件"
-                ],
-                "summary": "Google translate_a/single 兼容接口",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "default": "gtx",
-                        "description": "客户端类型",
-                        "name": "client",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "example": "en",
-                        "description": "源语言代码",
-                        "name": "sl",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "example": "zh-CN",
-                        "description": "目标语言代码",
-                        "name": "tl",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "default": "t",
-                        "description": "数据类型",
-                        "name": "dt",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "example": "Hello, world!",
-                        "description": "待翻译文本",
-                        "name": "q",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "API Key",
-                        "name": "key",
-                        "in": "query"
-                    }
+                    "管理"
                 ],
+                "summary": "查询下载进度",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {}
+                            "$ref": "#/definitions/handlers.DownloadsResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "ApiKeyQuery": []
+                    }
+                ]
+            }
+        },
+        "/admin/models/events": {
+            "get": {
+                "description": "以 Server-Sent Events 推送模型下载进度，连接后先推送当前所有下载的进度，事件名为 progress；records.json 刷新后语言对或版本变化时推送 records 事件",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "下载进度事件流",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DownloadProgress"
+                        }
                     },
                     "401": {
                         "description": "Unauthorized",
@@ -282,8 +268,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -291,44 +277,41 @@ const docTemplate = `{
                             }
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "ApiKeyQuery": []
+                    }
+                ]
             }
         },
-        "/hcfy": {
-            "post": {
-                "description": "兼容划词翻译自定义翻译源接口",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/models/export": {
+            "get": {
+                "description": "将已下载语言对的模型文件和记录打包为 tar.gz，可在离线环境中导入，需要经过英语中转的语言对会一并导出两段模型",
                 "produces": [
-                    "application/json"
+                    "application/gzip"
                 ],
                 "tags": [
-                    "插件"
+                    "管理"
                 ],
-                "summary": "划词翻译兼容接口",
+                "summary": "导出模型包",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "API Token",
-                        "name": "token",
-                        "in": "query"
-                    },
-                    {
-                        "description": "划词翻译请求",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.HcfyTranslateRequest"
-                        }
+                        "description": "逗号分隔的语言对，如 en_ja,ja_en",
+                        "name": "pairs",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.HcfyTranslateResponse"
+                            "type": "file"
                         }
                     },
                     "400": {
@@ -349,31 +332,17 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/health": {
-            "get": {
-                "description": "检查服务是否正常运行",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "系统"
-                ],
-                "summary": "健康检查",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -381,12 +350,20 @@ const docTemplate = `{
                             }
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "ApiKeyQuery": []
+                    }
+                ]
             }
         },
-        "/imme": {
+        "/admin/models/gc": {
             "post": {
-                "description": "为沉浸式翻译插件提供的翻译接口",
+                "description": "删除记录中已不存在的语言对、被新版本取代的文件、下载和解压中断留下的临时文件、隔离的损坏文件，以及超出保留数量和磁盘配额的旧版本，dry_run 为 true 时只返回将要删除的内容",
                 "consumes": [
                     "application/json"
                 ],
@@ -394,23 +371,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "插件"
+                    "管理"
                 ],
-                "summary": "沉浸式翻译插件接口",
+                "summary": "清理模型目录",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "API Token",
-                        "name": "token",
-                        "in": "query"
-                    },
-                    {
-                        "description": "沉浸式翻译请求",
+                        "description": "清理模型请求",
                         "name": "request",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.ImmeTranslateRequest"
+                            "$ref": "#/definitions/handlers.GCModelsRequest"
                         }
                     }
                 ],
@@ -418,7 +388,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ImmeTranslateResponse"
+                            "$ref": "#/definitions/models.GCResult"
                         }
                     },
                     "400": {
@@ -439,6 +409,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -448,44 +427,35 @@ const docTemplate = `{
                             }
                         }
                     }
-                }
-            }
-        },
-        "/kiss": {
-            "post": {
-                "description": "为简约翻译插件提供的单文本翻译接口",
-                "consumes": [
-                    "application/json"
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "ApiKeyQuery": []
+                    }
+                ]
+            }
+        },
+        "/admin/models/import": {
+            "post": {
+                "description": "校验并导入导出的模型包，所有文件与记录的哈希一致后才写入模型目录，包中的记录会合并到当前记录，离线模式下可直接使用",
+                "consumes": [
+                    "application/gzip"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "插件"
-                ],
-                "summary": "简约翻译插件接口（非聚合）",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "API Token",
-                        "name": "KEY",
-                        "in": "header"
-                    },
-                    {
-                        "description": "简约翻译请求",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.KissTranslateRequest"
-                        }
-                    }
+                    "管理"
                 ],
+                "summary": "导入模型包",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.KissTranslateResponse"
+                            "$ref": "#/definitions/models.BundleManifest"
                         }
                     },
                     "400": {
@@ -506,6 +476,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -515,11 +494,7 @@ const docTemplate = `{
                             }
                         }
                     }
-                }
-            }
-        },
-        "/languages": {
-            "get": {
+                },
                 "security": [
                     {
                         "ApiKeyAuth": []
@@ -527,30 +502,68 @@ const docTemplate = `{
                     {
                         "ApiKeyQuery": []
                     }
+                ]
+            }
+        },
+        "/admin/models/upgrade": {
+            "post": {
+                "description": "对模型版本落后于记录中最新版本或配置中固定版本的运行中引擎，在后台将新版本下载到暂存目录，启动新 Worker 并通过健康检查后逐个替换旧 Worker，切换期间请求不会失败",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "返回所有支持的翻译语言代码",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "翻译"
+                    "管理"
+                ],
+                "summary": "滚动升级运行中的引擎",
+                "parameters": [
+                    {
+                        "description": "升级请求",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpgradeModelsRequest"
+                        }
+                    }
                 ],
-                "summary": "获取支持的语言列表",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpgradeModelsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
-                                "type": "array",
-                                "items": {
-                                    "type": "string"
-                                }
+                                "type": "string"
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -558,11 +571,7 @@ const docTemplate = `{
                             }
                         }
                     }
-                }
-            }
-        },
-        "/translate": {
-            "post": {
+                },
                 "security": [
                     {
                         "ApiKeyAuth": []
@@ -570,8 +579,12 @@ const docTemplate = `{
                     {
                         "ApiKeyQuery": []
                     }
-                ],
-                "description": "翻译单个文本",
+                ]
+            }
+        },
+        "/admin/models/verify": {
+            "post": {
+                "description": "按记录中的大小和哈希校验模型文件，repair 为 true 时将损坏的文件移到模型目录下的 .quarantine 并重新下载，离线模式下需重新导入模型包，Worker 运行中的语言对只校验不修复",
                 "consumes": [
                     "application/json"
                 ],
@@ -579,17 +592,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "翻译"
+                    "管理"
                 ],
-                "summary": "单文本翻译",
+                "summary": "校验模型",
                 "parameters": [
                     {
-                        "description": "翻译请求",
+                        "description": "校验模型请求",
                         "name": "request",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.TranslateRequest"
+                            "$ref": "#/definitions/handlers.VerifyModelsRequest"
                         }
                     }
                 ],
@@ -597,7 +609,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.TranslateResponse"
+                            "$ref": "#/definitions/handlers.VerifyModelsResponse"
                         }
                     },
                     "400": {
@@ -609,8 +621,26 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -618,11 +648,7 @@ const docTemplate = `{
                             }
                         }
                     }
-                }
-            }
-        },
-        "/translate/batch": {
-            "post": {
+                },
                 "security": [
                     {
                         "ApiKeyAuth": []
@@ -630,38 +656,44 @@ const docTemplate = `{
                     {
                         "ApiKeyQuery": []
                     }
-                ],
-                "description": "批量翻译多个文本",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/admin/models/{from}/{to}": {
+            "get": {
+                "description": "获取指定语言对的模型状态",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "翻译"
+                    "管理"
                 ],
-                "summary": "批量翻译",
+                "summary": "获取模型状态",
                 "parameters": [
                     {
-                        "description": "批量翻译请求",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.TranslateBatchRequest"
-                        }
+                        "type": "string",
+                        "description": "源语言",
+                        "name": "from",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "目标语言",
+                        "name": "to",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.TranslateBatchResponse"
+                            "$ref": "#/definitions/models.ModelStatus"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -669,8 +701,17 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -678,466 +719,2545 @@ const docTemplate = `{
                             }
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "ApiKeyQuery": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "删除语言对的模型文件，语言对的 Worker 正在运行或模型正在下载时拒绝删除",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "删除模型",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "源语言",
+                        "name": "from",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "目标语言",
+                        "name": "to",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "ApiKeyQuery": []
+                    }
+                ]
             }
         },
-        "/version": {
+        "/admin/routes": {
             "get": {
-                "description": "返回当前服务的版本号",
+                "description": "返回中转语言、最大跳数和启动以来各翻译路径的使用统计，指定 from 和 to 时同时返回两者之间的翻译路径",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "系统"
+                    "管理"
+                ],
+                "summary": "查看翻译路径",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "源语言",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "目标语言",
+                        "name": "to",
+                        "in": "query"
+                    }
                 ],
-                "summary": "获取服务版本",
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RoutesResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        }
-    },
-    "definitions": {
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "ApiKeyQuery": []
+                    }
+                ]
+            }
+        },
+        "/admin/tokens": {
+            "get": {
+                "description": "列出令牌文件中的所有令牌及当日字符用量，不返回令牌明文",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "列出令牌",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/auth.TokenInfo"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "ApiKeyQuery": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "创建新的访问令牌，明文令牌仅在响应中返回一次",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "创建令牌",
+                "parameters": [
+                    {
+                        "description": "创建令牌请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "ApiKeyQuery": []
+                    }
+                ]
+            }
+        },
+        "/admin/tokens/{id}": {
+            "delete": {
+                "description": "删除指定 ID 的令牌",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "吊销令牌",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "令牌 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "ApiKeyQuery": []
+                    }
+                ]
+            }
+        },
+        "/deepl": {
+            "post": {
+                "description": "兼容 DeepL API v2 的翻译接口",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "插件"
+                ],
+                "summary": "DeepL 翻译兼容接口",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API Token",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "description": "DeepL 翻译请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DeeplTranslateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DeeplTranslateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/detect": {
+            "post": {
+                "description": "返回按置信度排序的候选语言，可限定候选语言并返回混合语言文本的分段",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "翻译"
+                ],
+                "summary": "语言检测",
+                "parameters": [
+                    {
+                        "description": "语言检测请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DetectRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DetectResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "ApiKeyQuery": []
+                    }
+                ]
+            }
+        },
+        "/google/language/translate/v2": {
+            "post": {
+                "description": "兼容 Google Translate API v2 的翻译接口",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "插件"
+                ],
+                "summary": "Google 翻译兼容接口",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API Key",
+                        "name": "key",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Google 翻译请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GoogleTranslateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GoogleTranslateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/google/language/translate/v2/detect": {
+            "post": {
+                "description": "兼容 Google Translate API v2 的语言检测接口",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "插件"
+                ],
+                "summary": "Google 语言检测兼容接口",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API Key",
+                        "name": "key",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Google 语言检测请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GoogleDetectRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GoogleDetectResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/google/translate_a/single": {
+            "get": {
+                "description": "兼容 Google translate_a/single 的翻译接口",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "插件"
+                ],
+                "summary": "Google translate_a/single 兼容接口",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "gtx",
+                        "description": "客户端类型",
+                        "name": "client",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "en",
+                        "description": "源语言代码",
+                        "name": "sl",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "zh-CN",
+                        "description": "目标语言代码",
+                        "name": "tl",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "t",
+                        "description": "数据类型",
+                        "name": "dt",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Hello, world!",
+                        "description": "待翻译文本",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "API Key",
+                        "name": "key",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {}
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/hcfy": {
+            "post": {
+                "description": "兼容划词翻译自定义翻译源接口",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "插件"
+                ],
+                "summary": "划词翻译兼容接口",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API Token",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "description": "划词翻译请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HcfyTranslateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HcfyTranslateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "检查服务是否正常运行",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "系统"
+                ],
+                "summary": "健康检查",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/imme": {
+            "post": {
+                "description": "为沉浸式翻译插件提供的翻译接口",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "插件"
+                ],
+                "summary": "沉浸式翻译插件接口",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API Token",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "description": "沉浸式翻译请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ImmeTranslateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ImmeTranslateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/kiss": {
+            "post": {
+                "description": "为简约翻译插件提供的单文本翻译接口",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "插件"
+                ],
+                "summary": "简约翻译插件接口（非聚合）",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API Token",
+                        "name": "KEY",
+                        "in": "header"
+                    },
+                    {
+                        "description": "简约翻译请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.KissTranslateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.KissTranslateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/languages": {
+            "get": {
+                "description": "返回所有支持的翻译语言代码和语言对，包括本地注册的模型，语言对中包含使用的模型架构",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "翻译"
+                ],
+                "summary": "获取支持的语言列表",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LanguagesResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "ApiKeyQuery": []
+                    }
+                ]
+            }
+        },
+        "/libre/detect": {
+            "post": {
+                "description": "兼容 LibreTranslate /detect 的语言检测接口",
+                "consumes": [
+                    "application/json",
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "插件"
+                ],
+                "summary": "LibreTranslate 语言检测兼容接口",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API Key",
+                        "name": "api_key",
+                        "in": "query"
+                    },
+                    {
+                        "description": "LibreTranslate 语言检测请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LibreDetectRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.LibreDetection"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/translate": {
+            "post": {
+                "description": "翻译单个文本",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "翻译"
+                ],
+                "summary": "单文本翻译",
+                "parameters": [
+                    {
+                        "description": "翻译请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TranslateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TranslateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "ApiKeyQuery": []
+                    }
+                ]
+            }
+        },
+        "/translate/batch": {
+            "post": {
+                "description": "批量翻译多个文本",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "翻译"
+                ],
+                "summary": "批量翻译",
+                "parameters": [
+                    {
+                        "description": "批量翻译请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TranslateBatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TranslateBatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "ApiKeyQuery": []
+                    }
+                ]
+            }
+        },
+        "/version": {
+            "get": {
+                "description": "返回当前服务的版本号",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "系统"
+                ],
+                "summary": "获取服务版本",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "auth.Scope": {
+            "type": "string",
+            "enum": [
+                "api",
+                "plugin",
+                "admin"
+            ],
+            "x-enum-varnames": [
+                "ScopeAPI",
+                "ScopePlugin",
+                "ScopeAdmin"
+            ]
+        },
+        "auth.TokenInfo": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "daily_quota": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "pairs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/auth.Scope"
+                    }
+                },
+                "used_today": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.CreateTokenRequest": {
+            "type": "object",
+            "required": [
+                "label"
+            ],
+            "properties": {
+                "daily_quota": {
+                    "type": "integer",
+                    "example": 100000
+                },
+                "label": {
+                    "type": "string",
+                    "example": "immersive-translate"
+                },
+                "pairs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "en-zh-Hans",
+                        "*-ja"
+                    ]
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/auth.Scope"
+                    },
+                    "example": [
+                        "api",
+                        "plugin"
+                    ]
+                }
+            }
+        },
+        "handlers.CreateTokenResponse": {
+            "type": "object",
+            "properties": {
+                "info": {
+                    "$ref": "#/definitions/auth.TokenInfo"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "mt_0123456789abcdef"
+                }
+            }
+        },
         "handlers.DeeplTranslateRequest": {
             "type": "object",
             "required": [
-                "target_lang",
-                "text"
+                "target_lang",
+                "text"
+            ],
+            "properties": {
+                "context": {
+                    "type": "string"
+                },
+                "enable_beta_languages": {
+                    "type": "boolean"
+                },
+                "formality": {
+                    "type": "string",
+                    "example": "default"
+                },
+                "glossary_id": {
+                    "type": "string"
+                },
+                "ignore_tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "model_type": {
+                    "type": "string",
+                    "example": "quality_optimized"
+                },
+                "non_splitting_tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "outline_detection": {
+                    "type": "string",
+                    "example": "1"
+                },
+                "preserve_formatting": {
+                    "type": "string",
+                    "example": "0"
+                },
+                "source_lang": {
+                    "type": "string",
+                    "example": "EN"
+                },
+                "split_sentences": {
+                    "type": "string",
+                    "example": "1"
+                },
+                "splitting_tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tag_handling": {
+                    "type": "string",
+                    "example": "xml"
+                },
+                "target_lang": {
+                    "type": "string",
+                    "example": "DE"
+                },
+                "text": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Hello",
+                        " world!"
+                    ]
+                }
+            }
+        },
+        "handlers.DeeplTranslateResponse": {
+            "type": "object",
+            "properties": {
+                "translations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.DeeplTranslation"
+                    }
+                }
+            }
+        },
+        "handlers.DeeplTranslation": {
+            "type": "object",
+            "properties": {
+                "detected_source_language": {
+                    "type": "string",
+                    "example": "EN"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Hallo, Welt!"
+                }
+            }
+        },
+        "handlers.DetectRequest": {
+            "type": "object",
+            "required": [
+                "text"
+            ],
+            "properties": {
+                "candidates": {
+                    "description": "Candidates 只在这些语言中检测，为空时不限制",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "en",
+                        "de",
+                        "fr"
+                    ]
+                },
+                "hints": {
+                    "description": "Hints 检测提示，文本较短或置信度较低时优先选择提示中的语言",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/services.DetectHints"
+                        }
+                    ]
+                },
+                "limit": {
+                    "description": "Limit 最多返回的候选语言数，默认 5",
+                    "type": "integer",
+                    "example": 5
+                },
+                "segments": {
+                    "description": "Segments 同时返回混合语言文本的分段",
+                    "type": "boolean",
+                    "example": false
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Hello, world!"
+                }
+            }
+        },
+        "handlers.DetectResponse": {
+            "type": "object",
+            "properties": {
+                "candidates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.LanguageCandidate"
+                    }
+                },
+                "confidence": {
+                    "type": "number",
+                    "example": 0.92
+                },
+                "language": {
+                    "description": "Language 置信度最高的语言，无法检测时为空",
+                    "type": "string",
+                    "example": "en"
+                },
+                "segments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.DetectSegment"
+                    }
+                }
+            }
+        },
+        "handlers.DetectSegment": {
+            "type": "object",
+            "properties": {
+                "confidence": {
+                    "type": "number",
+                    "example": 0.97
+                },
+                "end": {
+                    "type": "integer",
+                    "example": 13
+                },
+                "language": {
+                    "type": "string",
+                    "example": "en"
+                },
+                "start": {
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "handlers.DownloadModelRequest": {
+            "type": "object",
+            "required": [
+                "to"
+            ],
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "example": "en"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "ja"
+                }
+            }
+        },
+        "handlers.DownloadsResponse": {
+            "type": "object",
+            "properties": {
+                "downloads": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DownloadProgress"
+                    }
+                }
+            }
+        },
+        "handlers.GCModelsRequest": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "handlers.GoogleDetectRequest": {
+            "type": "object",
+            "required": [
+                "q"
+            ],
+            "properties": {
+                "q": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Hello",
+                        " world!"
+                    ]
+                }
+            }
+        },
+        "handlers.GoogleDetectResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "detections": {
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/handlers.GoogleDetection"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "handlers.GoogleDetection": {
+            "type": "object",
+            "properties": {
+                "confidence": {
+                    "type": "number",
+                    "example": 0.92
+                },
+                "isReliable": {
+                    "description": "IsReliable Google 已弃用该字段，始终为 false",
+                    "type": "boolean",
+                    "example": false
+                },
+                "language": {
+                    "type": "string",
+                    "example": "en"
+                }
+            }
+        },
+        "handlers.GoogleTranslateRequest": {
+            "type": "object",
+            "required": [
+                "q",
+                "source",
+                "target"
+            ],
+            "properties": {
+                "format": {
+                    "type": "string",
+                    "example": "text"
+                },
+                "q": {
+                    "type": "string",
+                    "example": "The Great Pyramid of Giza"
+                },
+                "source": {
+                    "type": "string",
+                    "example": "en"
+                },
+                "target": {
+                    "type": "string",
+                    "example": "zh-Hans"
+                }
+            }
+        },
+        "handlers.GoogleTranslateResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "translations": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "properties": {
+                                    "detectedSourceLanguage": {
+                                        "description": "DetectedSourceLanguage 源语言为 auto 时检测到的语言",
+                                        "type": "string",
+                                        "example": "en"
+                                    },
+                                    "translatedText": {
+                                        "type": "string",
+                                        "example": "吉萨大金字塔"
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "handlers.HcfyDict": {
+            "type": "object",
+            "properties": {
+                "pos": {
+                    "type": "string",
+                    "example": "n."
+                },
+                "terms": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "你好",
+                        "问候"
+                    ]
+                }
+            }
+        },
+        "handlers.HcfyPhonetic": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "美"
+                },
+                "ttsURI": {
+                    "type": "string",
+                    "example": "https://..."
+                },
+                "value": {
+                    "type": "string",
+                    "example": "həˈloʊ"
+                }
+            }
+        },
+        "handlers.HcfyTranslateRequest": {
+            "type": "object",
+            "required": [
+                "destination",
+                "name",
+                "text"
+            ],
+            "properties": {
+                "destination": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "中文(简体)",
+                        "英语"
+                    ]
+                },
+                "name": {
+                    "type": "string",
+                    "example": "翻译一"
+                },
+                "source": {
+                    "type": "string",
+                    "example": "英语"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Hello, word translation."
+                }
+            }
+        },
+        "handlers.HcfyTranslateResponse": {
+            "type": "object",
+            "properties": {
+                "dict": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.HcfyDict"
+                    }
+                },
+                "from": {
+                    "type": "string",
+                    "example": "英语"
+                },
+                "link": {
+                    "type": "string",
+                    "example": "https://..."
+                },
+                "phonetic": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.HcfyPhonetic"
+                    }
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "你好，划词翻译。"
+                    ]
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Hello, word translation."
+                },
+                "to": {
+                    "type": "string",
+                    "example": "中文(简体)"
+                },
+                "ttsURI": {
+                    "type": "string",
+                    "example": "https://..."
+                }
+            }
+        },
+        "handlers.ImmeTranslateRequest": {
+            "type": "object",
+            "required": [
+                "source_lang",
+                "target_lang",
+                "text_list"
+            ],
+            "properties": {
+                "source_lang": {
+                    "type": "string",
+                    "example": "en"
+                },
+                "target_lang": {
+                    "type": "string",
+                    "example": "zh-CN"
+                },
+                "text_list": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Hello",
+                        " world!",
+                        "Good morning!"
+                    ]
+                }
+            }
+        },
+        "handlers.ImmeTranslateResponse": {
+            "type": "object",
+            "properties": {
+                "translations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ImmeTranslation"
+                    }
+                }
+            }
+        },
+        "handlers.ImmeTranslation": {
+            "type": "object",
+            "properties": {
+                "detected_source_lang": {
+                    "type": "string",
+                    "example": "en"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "你好，世界！"
+                }
+            }
+        },
+        "handlers.KissTranslateRequest": {
+            "type": "object",
+            "required": [
+                "from",
+                "text",
+                "to"
             ],
             "properties": {
-                "context": {
-                    "type": "string"
-                },
-                "enable_beta_languages": {
-                    "type": "boolean"
+                "from": {
+                    "type": "string",
+                    "example": "en"
                 },
-                "formality": {
+                "text": {
                     "type": "string",
-                    "example": "default"
+                    "example": "Hello, world!"
                 },
-                "glossary_id": {
-                    "type": "string"
+                "to": {
+                    "type": "string",
+                    "example": "zh-CN"
+                }
+            }
+        },
+        "handlers.KissTranslateResponse": {
+            "type": "object",
+            "properties": {
+                "src": {
+                    "type": "string",
+                    "example": "en"
                 },
-                "ignore_tags": {
+                "text": {
+                    "type": "string",
+                    "example": "你好，世界！"
+                }
+            }
+        },
+        "handlers.LanguagesResponse": {
+            "type": "object",
+            "properties": {
+                "languages": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "model_type": {
-                    "type": "string",
-                    "example": "quality_optimized"
-                },
-                "non_splitting_tags": {
+                "pairs": {
+                    "description": "Pairs 支持的语言对及其使用的模型架构",
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/models.PairInfo"
                     }
+                }
+            }
+        },
+        "handlers.LibreDetectRequest": {
+            "type": "object",
+            "required": [
+                "q"
+            ],
+            "properties": {
+                "api_key": {
+                    "type": "string"
                 },
-                "outline_detection": {
-                    "type": "string",
-                    "example": "1"
-                },
-                "preserve_formatting": {
+                "q": {
                     "type": "string",
-                    "example": "0"
+                    "example": "Hello, world!"
+                }
+            }
+        },
+        "handlers.LibreDetection": {
+            "type": "object",
+            "properties": {
+                "confidence": {
+                    "description": "Confidence 置信度，范围 0 到 100",
+                    "type": "number",
+                    "example": 92
                 },
-                "source_lang": {
+                "language": {
                     "type": "string",
-                    "example": "EN"
+                    "example": "en"
+                }
+            }
+        },
+        "handlers.ModelsResponse": {
+            "type": "object",
+            "properties": {
+                "models": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ModelStatus"
+                    }
+                }
+            }
+        },
+        "handlers.RoutesResponse": {
+            "type": "object",
+            "properties": {
+                "max_hops": {
+                    "type": "integer",
+                    "example": 2
                 },
-                "split_sentences": {
+                "pivot_language": {
                     "type": "string",
-                    "example": "1"
+                    "example": "en"
                 },
-                "splitting_tags": {
+                "route": {
+                    "description": "Route 查询的 from 到 to 的翻译路径",
                     "type": "array",
                     "items": {
                         "type": "string"
-                    }
+                    },
+                    "example": [
+                        "ja",
+                        "en",
+                        "ko"
+                    ]
                 },
-                "tag_handling": {
+                "stats": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.RouteStats"
+                    }
+                }
+            }
+        },
+        "handlers.TranslateBatchRequest": {
+            "type": "object",
+            "required": [
+                "from",
+                "texts",
+                "to"
+            ],
+            "properties": {
+                "from": {
                     "type": "string",
-                    "example": "xml"
+                    "example": "en"
                 },
-                "target_lang": {
-                    "type": "string",
-                    "example": "DE"
+                "hints": {
+                    "description": "Hints 源语言为 auto 时的检测提示",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/services.DetectHints"
+                        }
+                    ]
                 },
-                "text": {
+                "html": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "texts": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     },
                     "example": [
                         "Hello",
-                        " world!"
+                        " world!",
+                        "Good morning!"
                     ]
+                },
+                "to": {
+                    "type": "string",
+                    "example": "zh-Hans"
                 }
             }
         },
-        "handlers.DeeplTranslateResponse": {
+        "handlers.TranslateBatchResponse": {
             "type": "object",
             "properties": {
-                "translations": {
+                "detected_languages": {
+                    "description": "DetectedLanguages 每个文本实际使用的源语言",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/handlers.DeeplTranslation"
-                    }
+                        "type": "string"
+                    },
+                    "example": [
+                        "en",
+                        "en"
+                    ]
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "你好，世界！",
+                        "早上好！"
+                    ]
+                },
+                "route": {
+                    "description": "Route 所有文本使用相同翻译路径时的路径，各文本路径不同或无需翻译时为空",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "ja",
+                        "en",
+                        "zh-Hans"
+                    ]
                 }
             }
         },
-        "handlers.DeeplTranslation": {
+        "handlers.TranslateRequest": {
             "type": "object",
+            "required": [
+                "from",
+                "text",
+                "to"
+            ],
             "properties": {
-                "detected_source_language": {
+                "from": {
                     "type": "string",
-                    "example": "EN"
+                    "example": "en"
+                },
+                "hints": {
+                    "description": "Hints 源语言为 auto 时的检测提示",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/services.DetectHints"
+                        }
+                    ]
+                },
+                "html": {
+                    "type": "boolean",
+                    "example": false
                 },
                 "text": {
                     "type": "string",
-                    "example": "Hallo, Welt!"
+                    "example": "Hello, world!"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "zh-Hans"
                 }
             }
         },
-        "handlers.GoogleTranslateRequest": {
+        "handlers.TranslateResponse": {
             "type": "object",
-            "required": [
-                "q",
-                "source",
-                "target"
-            ],
             "properties": {
-                "format": {
-                    "type": "string",
-                    "example": "text"
+                "confidence": {
+                    "description": "Confidence 源语言由检测得到时的置信度",
+                    "type": "number",
+                    "example": 0.93
                 },
-                "q": {
+                "detected_language": {
+                    "description": "DetectedLanguage 实际使用的源语言，多语言文本为占比最大的语言",
                     "type": "string",
-                    "example": "The Great Pyramid of Giza"
+                    "example": "ja"
                 },
-                "source": {
+                "result": {
                     "type": "string",
-                    "example": "en"
+                    "example": "你好，世界！"
                 },
-                "target": {
-                    "type": "string",
-                    "example": "zh-Hans"
+                "route": {
+                    "description": "Route 翻译路径，经过中转时包含中转语言，无需翻译时为空",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "ja",
+                        "en",
+                        "zh-Hans"
+                    ]
                 }
             }
         },
-        "handlers.GoogleTranslateResponse": {
+        "handlers.UpgradeModelsRequest": {
             "type": "object",
             "properties": {
-                "data": {
-                    "type": "object",
-                    "properties": {
-                        "translations": {
-                            "type": "array",
-                            "items": {
-                                "type": "object",
-                                "properties": {
-                                    "translatedText": {
-                                        "type": "string",
-                                        "example": "吉萨大金字塔"
-                                    }
-                                }
-                            }
-                        }
+                "refresh": {
+                    "description": "Refresh 升级前重新下载 records.json",
+                    "type": "boolean"
+                }
+            }
+        },
+        "handlers.UpgradeModelsResponse": {
+            "type": "object",
+            "properties": {
+                "upgrades": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.EngineUpgrade"
                     }
                 }
             }
         },
-        "handlers.HcfyDict": {
+        "handlers.VerifyModelsRequest": {
             "type": "object",
             "properties": {
-                "pos": {
-                    "type": "string",
-                    "example": "n."
-                },
-                "terms": {
+                "pairs": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     },
                     "example": [
-                        "你好",
-                        "问候"
+                        "en_ja"
                     ]
+                },
+                "repair": {
+                    "type": "boolean"
                 }
             }
         },
-        "handlers.HcfyPhonetic": {
+        "handlers.VerifyModelsResponse": {
             "type": "object",
             "properties": {
-                "name": {
-                    "type": "string",
-                    "example": "美"
-                },
-                "ttsURI": {
-                    "type": "string",
-                    "example": "https://..."
-                },
-                "value": {
-                    "type": "string",
-                    "example": "həˈloʊ"
+                "models": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ModelCheck"
+                    }
                 }
             }
         },
-        "handlers.HcfyTranslateRequest": {
+        "models.BundleFile": {
             "type": "object",
-            "required": [
-                "destination",
-                "name",
-                "text"
-            ],
             "properties": {
-                "destination": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    },
-                    "example": [
-                        "中文(简体)",
-                        "英语"
-                    ]
-                },
-                "name": {
+                "path": {
                     "type": "string",
-                    "example": "翻译一"
+                    "example": "en_ja/model.enja.intgemm.alphas.bin"
                 },
-                "source": {
-                    "type": "string",
-                    "example": "英语"
+                "sha256": {
+                    "type": "string"
                 },
-                "text": {
-                    "type": "string",
-                    "example": "Hello, word translation."
+                "size": {
+                    "type": "integer"
                 }
             }
         },
-        "handlers.HcfyTranslateResponse": {
+        "models.BundleManifest": {
             "type": "object",
             "properties": {
-                "dict": {
+                "created_at": {
+                    "type": "string"
+                },
+                "files": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/handlers.HcfyDict"
+                        "$ref": "#/definitions/models.BundleFile"
                     }
                 },
-                "from": {
-                    "type": "string",
-                    "example": "英语"
-                },
-                "link": {
-                    "type": "string",
-                    "example": "https://..."
+                "format": {
+                    "type": "integer"
                 },
-                "phonetic": {
+                "pairs": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/handlers.HcfyPhonetic"
+                        "$ref": "#/definitions/models.Pair"
                     }
+                }
+            }
+        },
+        "models.DownloadProgress": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
                 },
-                "result": {
+                "done": {
+                    "type": "boolean"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "files": {
                     "type": "array",
                     "items": {
-                        "type": "string"
-                    },
-                    "example": [
-                        "你好，划词翻译。"
-                    ]
+                        "$ref": "#/definitions/models.FileProgress"
+                    }
                 },
-                "text": {
+                "from": {
                     "type": "string",
-                    "example": "Hello, word translation."
+                    "example": "en"
+                },
+                "rate": {
+                    "description": "Rate 最近的下载速度（字节/秒）",
+                    "type": "number"
+                },
+                "started_at": {
+                    "type": "string"
                 },
                 "to": {
                     "type": "string",
-                    "example": "中文(简体)"
+                    "example": "ja"
                 },
-                "ttsURI": {
-                    "type": "string",
-                    "example": "https://..."
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "handlers.ImmeTranslateRequest": {
+        "models.FileCheck": {
             "type": "object",
-            "required": [
-                "source_lang",
-                "target_lang",
-                "text_list"
-            ],
             "properties": {
-                "source_lang": {
+                "file": {
                     "type": "string",
-                    "example": "en"
+                    "example": "model.enja.intgemm.alphas.bin"
                 },
-                "target_lang": {
+                "quarantined": {
+                    "description": "Quarantined 损坏文件移动后的路径",
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "status": {
                     "type": "string",
-                    "example": "zh-CN"
+                    "example": "ok"
+                }
+            }
+        },
+        "models.FileProgress": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
                 },
-                "text_list": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    },
-                    "example": [
-                        "Hello",
-                        " world!",
-                        "Good morning!"
-                    ]
+                "done": {
+                    "type": "boolean"
+                },
+                "file": {
+                    "type": "string",
+                    "example": "model.enja.intgemm.alphas.bin.zst"
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "handlers.ImmeTranslateResponse": {
+        "models.GCResult": {
             "type": "object",
             "properties": {
-                "translations": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "freed_bytes": {
+                    "type": "integer"
+                },
+                "removed": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/handlers.ImmeTranslation"
+                        "type": "string"
                     }
                 }
             }
         },
-        "handlers.ImmeTranslation": {
+        "models.ModelCheck": {
             "type": "object",
             "properties": {
-                "detected_source_lang": {
+                "error": {
+                    "type": "string"
+                },
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FileCheck"
+                    }
+                },
+                "from": {
                     "type": "string",
                     "example": "en"
                 },
-                "text": {
+                "healthy": {
+                    "description": "Healthy 所有文件完好且模型完整",
+                    "type": "boolean"
+                },
+                "repaired": {
+                    "type": "boolean"
+                },
+                "to": {
                     "type": "string",
-                    "example": "你好，世界！"
+                    "example": "ja"
                 }
             }
         },
-        "handlers.KissTranslateRequest": {
+        "models.ModelStatus": {
             "type": "object",
-            "required": [
-                "from",
-                "text",
-                "to"
-            ],
             "properties": {
+                "architecture": {
+                    "description": "Architecture 已下载的模型架构，未知时为空",
+                    "type": "string",
+                    "example": "base-memory"
+                },
+                "downloaded": {
+                    "type": "boolean"
+                },
+                "downloading": {
+                    "type": "boolean"
+                },
                 "from": {
                     "type": "string",
                     "example": "en"
                 },
-                "text": {
+                "latest_version": {
                     "type": "string",
-                    "example": "Hello, world!"
+                    "example": "1.0"
+                },
+                "loaded": {
+                    "description": "Loaded 是否有运行中的 Worker，由 services 填充",
+                    "type": "boolean"
+                },
+                "local": {
+                    "description": "Local 使用本地注册的模型",
+                    "type": "boolean"
+                },
+                "pinned_version": {
+                    "description": "PinnedVersion 配置中固定的版本",
+                    "type": "string"
+                },
+                "progress": {
+                    "description": "Progress 最近一次下载的进度",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.DownloadProgress"
+                        }
+                    ]
+                },
+                "retained_versions": {
+                    "description": "RetainedVersions 保留的旧版本，从新到旧",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "size": {
+                    "description": "Size 模型文件占用的磁盘空间（字节）",
+                    "type": "integer"
+                },
+                "target_architecture": {
+                    "description": "TargetArchitecture 按配置的架构偏好应使用的架构",
+                    "type": "string",
+                    "example": "tiny"
                 },
                 "to": {
                     "type": "string",
-                    "example": "zh-CN"
+                    "example": "ja"
+                },
+                "version": {
+                    "description": "Version 已下载的模型版本，未知时为空",
+                    "type": "string",
+                    "example": "1.0"
                 }
             }
         },
-        "handlers.KissTranslateResponse": {
+        "models.Pair": {
             "type": "object",
             "properties": {
-                "src": {
+                "from": {
                     "type": "string",
                     "example": "en"
                 },
-                "text": {
+                "to": {
                     "type": "string",
-                    "example": "你好，世界！"
+                    "example": "ja"
                 }
             }
         },
-        "handlers.TranslateBatchRequest": {
+        "models.PairInfo": {
             "type": "object",
-            "required": [
-                "from",
-                "texts",
-                "to"
-            ],
             "properties": {
+                "architecture": {
+                    "description": "Architecture 已下载的模型架构，未下载时为按配置应使用的架构",
+                    "type": "string",
+                    "example": "base-memory"
+                },
                 "from": {
                     "type": "string",
                     "example": "en"
                 },
-                "html": {
-                    "type": "boolean",
-                    "example": false
-                },
-                "texts": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    },
-                    "example": [
-                        "Hello",
-                        " world!",
-                        "Good morning!"
-                    ]
+                "local": {
+                    "description": "Local 使用本地注册的模型",
+                    "type": "boolean"
                 },
                 "to": {
                     "type": "string",
-                    "example": "zh-Hans"
+                    "example": "ja"
                 }
             }
         },
-        "handlers.TranslateBatchResponse": {
+        "services.DetectHints": {
             "type": "object",
             "properties": {
-                "results": {
+                "languages": {
+                    "description": "Languages 预期的语言",
                     "type": "array",
                     "items": {
                         "type": "string"
                     },
                     "example": [
-                        "你好，世界！",
-                        "早上好！"
+                        "en",
+                        "de"
                     ]
+                },
+                "locale": {
+                    "description": "Locale 用户界面语言",
+                    "type": "string",
+                    "example": "de-DE"
+                },
+                "previous": {
+                    "description": "Previous 上一次检测到的语言",
+                    "type": "string",
+                    "example": "en"
                 }
             }
         },
-        "handlers.TranslateRequest": {
+        "services.EngineUpgrade": {
             "type": "object",
-            "required": [
-                "from",
-                "text",
-                "to"
-            ],
             "properties": {
                 "from": {
                     "type": "string",
                     "example": "en"
                 },
-                "html": {
-                    "type": "boolean",
-                    "example": false
-                },
-                "text": {
+                "target": {
+                    "description": "Target 记录中的最新版本或配置中固定的版本",
                     "type": "string",
-                    "example": "Hello, world!"
+                    "example": "1.1"
                 },
                 "to": {
                     "type": "string",
-                    "example": "zh-Hans"
+                    "example": "ja"
+                },
+                "version": {
+                    "description": "Version Worker 当前使用的版本",
+                    "type": "string",
+                    "example": "1.0"
                 }
             }
         },
-        "handlers.TranslateResponse": {
+        "services.LanguageCandidate": {
             "type": "object",
             "properties": {
-                "result": {
+                "confidence": {
+                    "type": "number",
+                    "example": 0.92
+                },
+                "language": {
                     "type": "string",
-                    "example": "你好，世界！"
+                    "example": "en"
+                }
+            }
+        },
+        "services.RouteStats": {
+            "type": "object",
+            "properties": {
+                "characters": {
+                    "description": "Characters 使用该路径翻译的字符数",
+                    "type": "integer",
+                    "example": 3456
+                },
+                "count": {
+                    "description": "Count 使用该路径翻译的文本段数",
+                    "type": "integer",
+                    "example": 12
+                },
+                "hops": {
+                    "description": "Hops 经过的语言对数量",
+                    "type": "integer",
+                    "example": 2
+                },
+                "route": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "ja",
+                        "en",
+                        "ko"
+                    ]
                 }
             }
         }
@@ -3,25 +3,40 @@ package downloader
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/hashicorp/go-getter"
 	"github.com/xxnuo/MTranServer/internal/logger"
 	"github.com/xxnuo/MTranServer/internal/utils"
 )
 
+// partSuffix 未完成的下载文件后缀，下载失败时保留用于续传
+const partSuffix = ".part"
+
+// ProgressTracker 跟踪下载进度，返回的 ReadCloser 包装下载流
+type ProgressTracker interface {
+	TrackProgress(src string, currentSize, totalSize int64, stream io.ReadCloser) (body io.ReadCloser)
+}
+
 type Downloader struct {
 	DestDir string
 
-	ProgressFunc getter.ProgressTracker
+	ProgressFunc ProgressTracker
+
+	// Client 用于 HTTP 下载，为空时使用默认客户端
+	Client *http.Client
 }
 
 type DownloadOptions struct {
+	// SHA256 校验下载的文件，设置后失败的下载会保留已下载的部分并在下次续传
 	SHA256 string
 
 	Overwrite bool
@@ -35,15 +50,23 @@ func New(destDir string) *Downloader {
 	}
 }
 
-func (d *Downloader) SetProgressFunc(fn getter.ProgressTracker) {
+func (d *Downloader) SetProgressFunc(fn ProgressTracker) {
 	d.ProgressFunc = fn
 }
 
+// Download 下载单个地址
 func (d *Downloader) Download(urlStr, filename string, opts *DownloadOptions) error {
+	return d.DownloadMirrors([]string{urlStr}, filename, opts)
+}
+
+// DownloadMirrors 按顺序尝试各个地址，前一个失败时使用下一个
+// 支持 http(s):// 和 file:// 地址，已下载的部分在地址之间共享
+func (d *Downloader) DownloadMirrors(urls []string, filename string, opts *DownloadOptions) error {
+	if len(urls) == 0 {
+		return fmt.Errorf("No download URL for %s", filename)
+	}
 	if opts == nil {
-		opts = &DownloadOptions{
-			Context: context.Background(),
-		}
+		opts = &DownloadOptions{}
 	}
 	if opts.Context == nil {
 		opts.Context = context.Background()
@@ -68,95 +91,246 @@ func (d *Downloader) Download(urlStr, filename string, opts *DownloadOptions) er
 		}
 	}
 
-	logger.Info("Downloading %s from %s", filename, urlStr)
-
-	tmpFile := dst + ".tmp"
-	defer os.Remove(tmpFile)
+	part := dst + partSuffix
+	// 没有校验值时无法确认已下载的部分属于同一文件，总是重新下载
+	if opts.SHA256 == "" {
+		os.Remove(part)
+	}
 
-	httpClient := &http.Client{
-		Timeout: 30 * time.Minute,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+	var errs []error
+	for _, u := range urls {
+		if err := opts.Context.Err(); err != nil {
+			return fmt.Errorf("Failed to download: %w", err)
+		}
 
-			if len(via) >= 10 {
-				return fmt.Errorf("stopped after 10 redirects")
+		logger.Info("Downloading %s from %s", filename, u)
+		err := d.fetch(opts.Context, u, part)
+		if err == nil && opts.SHA256 != "" {
+			logger.Debug("Verifying SHA256 for %s", filename)
+			if err = utils.VerifySHA256(part, opts.SHA256); err != nil {
+				// 已下载的内容已损坏，不能用于续传
+				os.Remove(part)
+				err = fmt.Errorf("Failed to verify SHA256: %w", err)
 			}
-			return nil
-		},
+		}
+		if err != nil {
+			logger.Warn("Failed to download %s from %s: %v", filename, u, err)
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := os.Rename(part, dst); err != nil {
+			return fmt.Errorf("Failed to move file: %w", err)
+		}
+		logger.Info("Successfully downloaded: %s", filename)
+		return nil
 	}
 
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: false},
+	if opts.SHA256 == "" {
+		os.Remove(part)
 	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return fmt.Errorf("Failed to download from all %d mirrors: %w", len(urls), errors.Join(errs...))
+}
 
-	if proxyURL := os.Getenv("HTTP_PROXY"); proxyURL != "" {
-		if parsedURL, err := url.Parse(proxyURL); err == nil {
-			transport.Proxy = http.ProxyURL(parsedURL)
-		}
-	} else if proxyURL := os.Getenv("http_proxy"); proxyURL != "" {
-		if parsedURL, err := url.Parse(proxyURL); err == nil {
-			transport.Proxy = http.ProxyURL(parsedURL)
-		}
+// fetch 将 src 的内容写入 part，part 已存在时从已有的长度继续
+func (d *Downloader) fetch(ctx context.Context, src, part string) error {
+	u, err := url.Parse(src)
+	if err != nil {
+		return fmt.Errorf("Invalid URL %q: %w", src, err)
 	}
 
-	if proxyURL := os.Getenv("HTTPS_PROXY"); proxyURL != "" {
-		if parsedURL, err := url.Parse(proxyURL); err == nil {
-			transport.Proxy = http.ProxyURL(parsedURL)
-		}
-	} else if proxyURL := os.Getenv("https_proxy"); proxyURL != "" {
-		if parsedURL, err := url.Parse(proxyURL); err == nil {
-			transport.Proxy = http.ProxyURL(parsedURL)
-		}
+	var offset int64
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
 	}
 
-	httpClient.Transport = transport
+	var body io.ReadCloser
+	var total int64
+	switch u.Scheme {
+	case "file":
+		body, offset, total, err = openFile(u, offset)
+	case "http", "https":
+		body, offset, total, err = d.openHTTP(ctx, src, offset)
+	default:
+		return fmt.Errorf("Unsupported URL scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return err
+	}
+	defer body.Close()
 
-	httpGetter := &getter.HttpGetter{
-		Client: httpClient,
+	if offset > 0 {
+		logger.Debug("Resuming %s at %d bytes", filepath.Base(part), offset)
 	}
 
-	clientOpts := []getter.ClientOption{
-		getter.WithContext(opts.Context),
-		getter.WithGetters(map[string]getter.Getter{
-			"http":  httpGetter,
-			"https": httpGetter,
-		}),
-		getter.WithDecompressors(map[string]getter.Decompressor{}),
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to create file: %w", err)
 	}
+	defer f.Close()
 
 	if d.ProgressFunc != nil {
-		clientOpts = append(clientOpts, getter.WithProgress(d.ProgressFunc))
+		body = d.ProgressFunc.TrackProgress(filepath.Base(u.Path), offset, total, body)
 	}
 
-	client := &getter.Client{
-		Src:  urlStr,
-		Dst:  tmpFile,
-		Mode: getter.ClientModeFile,
+	if _, err := io.Copy(f, &contextReader{ctx: ctx, r: body}); err != nil {
+		return fmt.Errorf("Failed to download: %w", err)
 	}
+	return f.Sync()
+}
 
-	if err := client.Configure(clientOpts...); err != nil {
-		return fmt.Errorf("Failed to configure downloader: %w", err)
+// localPath 返回 file:// 地址对应的本地路径
+func localPath(u *url.URL) string {
+	path := u.Path
+	if u.Host != "" && u.Host != "localhost" {
+		path = "//" + u.Host + u.Path
+	}
+	// file:///C:/models 形式的 Windows 路径
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
 	}
+	return filepath.FromSlash(path)
+}
 
-	if err := client.Get(); err != nil {
-		return fmt.Errorf("Failed to download: %w", err)
+// openFile 打开本地镜像中的文件，从 offset 处开始读取
+func openFile(u *url.URL, offset int64) (io.ReadCloser, int64, int64, error) {
+	path := localPath(u)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("Failed to open %s: %w", path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, 0, err
+	}
+	if offset > info.Size() {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, 0, 0, err
+	}
+	return f, offset, info.Size(), nil
+}
+
+// openHTTP 发起请求，offset 大于 0 时使用 Range 续传，服务器不支持时从头下载
+// 返回实际的起始位置和文件总大小（未知时为 0）
+func (d *Downloader) openHTTP(ctx context.Context, src string, offset int64) (io.ReadCloser, int64, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
 	}
 
-	logger.Debug("Download completed: %s", filename)
+	resp, err := d.client().Do(req)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("Failed to download: %w", err)
+	}
 
-	if opts.SHA256 != "" {
-		logger.Debug("Verifying SHA256 for %s", filename)
-		if err := utils.VerifySHA256(tmpFile, opts.SHA256); err != nil {
-			return fmt.Errorf("Failed to verify SHA256: %w", err)
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		start, total, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			resp.Body.Close()
+			return nil, 0, 0, fmt.Errorf("Unexpected Content-Range %q", resp.Header.Get("Content-Range"))
+		}
+		return resp.Body, offset, total, nil
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// 已下载的部分可能就是完整的文件，交给校验判断
+		resp.Body.Close()
+		return io.NopCloser(strings.NewReader("")), offset, offset, nil
+	case resp.StatusCode == http.StatusOK:
+		total := resp.ContentLength
+		if total < 0 {
+			total = 0
 		}
-		logger.Debug("SHA256 verification passed for %s", filename)
+		return resp.Body, 0, total, nil
+	default:
+		resp.Body.Close()
+		return nil, 0, 0, fmt.Errorf("Failed to download: bad response code: %d", resp.StatusCode)
 	}
+}
 
-	if err := os.Rename(tmpFile, dst); err != nil {
-		return fmt.Errorf("Failed to move file: %w", err)
+// parseContentRange 解析 "bytes 100-199/200"，总大小未知时返回 0
+func parseContentRange(v string) (start, total int64, ok bool) {
+	v, found := strings.CutPrefix(v, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	rng, size, found := strings.Cut(v, "/")
+	if !found {
+		return 0, 0, false
+	}
+	first, _, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, false
 	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if size != "*" {
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	return start, total, true
+}
+
+func (d *Downloader) client() *http.Client {
+	if d.Client != nil {
+		return d.Client
+	}
+	return defaultClient
+}
+
+var defaultClient = newHTTPClient()
+
+func newHTTPClient() *http.Client {
+	transport := &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: false},
+	}
+
+	return &http.Client{
+		Timeout:   30 * time.Minute,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			return nil
+		},
+	}
+}
+
+// contextReader 在 context 取消后停止读取
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
 
-	logger.Info("Successfully downloaded: %s", filename)
-	return nil
+// JoinURL 拼接镜像地址与相对路径，file:// 地址同样适用
+func JoinURL(base, path string) string {
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
 }
 
 func DownloadFile(url, destPath, sha256sum string) error {
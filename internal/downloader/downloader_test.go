@@ -1,11 +1,15 @@
 package downloader
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 )
@@ -229,3 +233,272 @@ func TestDownloadFile(t *testing.T) {
 		t.Fatalf("文件内容不匹配: 期望 %s, 实际 %s", testContent, content)
 	}
 }
+
+func TestDownloadResume(t *testing.T) {
+
+	testContent := []byte("Hello, World!")
+	expectedSHA256 := "dffd6021bb2bd5b0af676290809ec3a53191dd81c7f70a4b28688a362182986f"
+
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "test.txt", time.Time{}, bytes.NewReader(testContent))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	part := filepath.Join(tempDir, "test.txt"+partSuffix)
+	if err := os.WriteFile(part, testContent[:6], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := New(tempDir)
+	err := d.Download(server.URL, "test.txt", &DownloadOptions{
+		SHA256:  expectedSHA256,
+		Context: context.Background(),
+	})
+	if err != nil {
+		t.Fatalf("下载失败: %v", err)
+	}
+
+	if len(ranges) != 1 || ranges[0] != "bytes=6-" {
+		t.Fatalf("期望使用 Range 续传, 实际请求头 %v", ranges)
+	}
+	content, err := os.ReadFile(filepath.Join(tempDir, "test.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != string(testContent) {
+		t.Fatalf("文件内容不匹配: 期望 %s, 实际 %s", testContent, content)
+	}
+	if _, err := os.Stat(part); !os.IsNotExist(err) {
+		t.Fatal("下载完成后不应保留 .part 文件")
+	}
+}
+
+func TestDownloadResumeUnsupported(t *testing.T) {
+
+	testContent := []byte("Hello, World!")
+	expectedSHA256 := "dffd6021bb2bd5b0af676290809ec3a53191dd81c7f70a4b28688a362182986f"
+
+	// 忽略 Range 请求头，总是返回完整内容
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(testContent)
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "test.txt"+partSuffix), testContent[:6], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := New(tempDir)
+	err := d.Download(server.URL, "test.txt", &DownloadOptions{
+		SHA256:  expectedSHA256,
+		Context: context.Background(),
+	})
+	if err != nil {
+		t.Fatalf("下载失败: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tempDir, "test.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != string(testContent) {
+		t.Fatalf("文件内容不匹配: 期望 %s, 实际 %s", testContent, content)
+	}
+}
+
+func TestDownloadMirrorFailover(t *testing.T) {
+
+	testContent := []byte("Hello, World!")
+	expectedSHA256 := "dffd6021bb2bd5b0af676290809ec3a53191dd81c7f70a4b28688a362182986f"
+
+	// 第一个镜像只发送一半内容后断开连接
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(testContent)))
+		w.Write(testContent[:6])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}))
+	defer broken.Close()
+
+	var mu sync.Mutex
+	var ranges []string
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mu.Unlock()
+		http.ServeContent(w, r, "test.txt", time.Time{}, bytes.NewReader(testContent))
+	}))
+	defer good.Close()
+
+	tempDir := t.TempDir()
+	d := New(tempDir)
+	err := d.DownloadMirrors([]string{broken.URL, good.URL}, "test.txt", &DownloadOptions{
+		SHA256:  expectedSHA256,
+		Context: context.Background(),
+	})
+	if err != nil {
+		t.Fatalf("下载失败: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ranges) != 1 || ranges[0] != "bytes=6-" {
+		t.Fatalf("期望从第二个镜像续传, 实际请求头 %v", ranges)
+	}
+	content, err := os.ReadFile(filepath.Join(tempDir, "test.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != string(testContent) {
+		t.Fatalf("文件内容不匹配: 期望 %s, 实际 %s", testContent, content)
+	}
+}
+
+func TestDownloadFileMirror(t *testing.T) {
+
+	testContent := []byte("Hello, World!")
+	expectedSHA256 := "dffd6021bb2bd5b0af676290809ec3a53191dd81c7f70a4b28688a362182986f"
+
+	mirrorDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(mirrorDir, "models"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(mirrorDir, "models", "test.txt"), testContent, 0644); err != nil {
+		t.Fatal(err)
+	}
+	base := "file://" + filepath.ToSlash(mirrorDir)
+
+	tempDir := t.TempDir()
+	d := New(tempDir)
+	err := d.DownloadMirrors([]string{
+		JoinURL(base, "missing/test.txt"),
+		JoinURL(base, "models/test.txt"),
+	}, "test.txt", &DownloadOptions{
+		SHA256:  expectedSHA256,
+		Context: context.Background(),
+	})
+	if err != nil {
+		t.Fatalf("下载失败: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tempDir, "test.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != string(testContent) {
+		t.Fatalf("文件内容不匹配: 期望 %s, 实际 %s", testContent, content)
+	}
+}
+
+func TestDownloadAllMirrorsFail(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Hello, World!"))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	d := New(tempDir)
+	err := d.DownloadMirrors([]string{"file:///nonexistent/test.txt", server.URL}, "test.txt", &DownloadOptions{
+		SHA256:  "0000000000000000000000000000000000000000000000000000000000000000",
+		Context: context.Background(),
+	})
+	if err == nil {
+		t.Fatal("应该返回下载失败错误")
+	}
+
+	// 校验失败的内容不能用于续传
+	if _, err := os.Stat(filepath.Join(tempDir, "test.txt"+partSuffix)); !os.IsNotExist(err) {
+		t.Fatal("校验失败后应删除 .part 文件")
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "test.txt")); !os.IsNotExist(err) {
+		t.Fatal("校验失败后不应生成文件")
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		value string
+		start int64
+		total int64
+		ok    bool
+	}{
+		{"bytes 100-199/200", 100, 200, true},
+		{"bytes 0-99/*", 0, 0, true},
+		{"bytes */200", 0, 0, false},
+		{"items 0-1/2", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		start, total, ok := parseContentRange(tt.value)
+		if start != tt.start || total != tt.total || ok != tt.ok {
+			t.Errorf("parseContentRange(%q) = %d, %d, %v, 期望 %d, %d, %v", tt.value, start, total, ok, tt.start, tt.total, tt.ok)
+		}
+	}
+}
+
+func TestFetchConditional(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Write([]byte("records"))
+	}))
+	defer server.Close()
+
+	d := New(t.TempDir())
+	data, v, err := d.Fetch(context.Background(), []string{server.URL}, Validator{})
+	if err != nil {
+		t.Fatalf("获取失败: %v", err)
+	}
+	if string(data) != "records" || v.ETag != `"v1"` || v.LastModified == "" {
+		t.Fatalf("获取结果不正确: %q, %+v", data, v)
+	}
+
+	_, next, err := d.Fetch(context.Background(), []string{server.URL}, v)
+	if !errors.Is(err, ErrNotModified) {
+		t.Fatalf("期望 ErrNotModified, 实际 %v", err)
+	}
+	if next != v {
+		t.Fatalf("未变化时应返回原有的校验信息: %+v", next)
+	}
+	if requests != 2 {
+		t.Fatalf("期望 2 次请求, 实际 %d", requests)
+	}
+}
+
+func TestFetchFileMirror(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "records.json")
+	if err := os.WriteFile(path, []byte("records"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	urls := []string{"file://" + filepath.ToSlash(filepath.Join(dir, "missing.json")), "file://" + filepath.ToSlash(path)}
+
+	d := New(t.TempDir())
+	data, v, err := d.Fetch(context.Background(), urls, Validator{})
+	if err != nil || string(data) != "records" {
+		t.Fatalf("获取失败: %q, %v", data, err)
+	}
+	if _, _, err := d.Fetch(context.Background(), urls, v); !errors.Is(err, ErrNotModified) {
+		t.Fatalf("期望 ErrNotModified, 实际 %v", err)
+	}
+
+	// 文件修改后重新读取
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := d.Fetch(context.Background(), urls, v); err != nil {
+		t.Fatalf("文件修改后应重新读取: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/xxnuo/MTranServer/internal/logger"
+)
+
+// maxFetchSize Fetch 读取的内容上限
+const maxFetchSize = 64 << 20
+
+// ErrNotModified 内容与上次获取时相同
+var ErrNotModified = errors.New("not modified")
+
+// Validator 条件请求使用的缓存校验信息
+type Validator struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// Fetch 按顺序尝试各个地址读取完整内容，使用 v 发起条件请求，内容未变化时返回 ErrNotModified
+// file:// 地址以文件修改时间作为 LastModified
+func (d *Downloader) Fetch(ctx context.Context, urls []string, v Validator) ([]byte, Validator, error) {
+	if len(urls) == 0 {
+		return nil, Validator{}, fmt.Errorf("No URL to fetch")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var errs []error
+	for _, src := range urls {
+		if err := ctx.Err(); err != nil {
+			return nil, Validator{}, err
+		}
+		data, next, err := d.fetchOne(ctx, src, v)
+		if err == nil || errors.Is(err, ErrNotModified) {
+			return data, next, err
+		}
+		logger.Warn("Failed to fetch %s: %v", src, err)
+		errs = append(errs, err)
+	}
+	if len(errs) == 1 {
+		return nil, Validator{}, errs[0]
+	}
+	return nil, Validator{}, fmt.Errorf("Failed to fetch from all %d mirrors: %w", len(urls), errors.Join(errs...))
+}
+
+func (d *Downloader) fetchOne(ctx context.Context, src string, v Validator) ([]byte, Validator, error) {
+	u, err := url.Parse(src)
+	if err != nil {
+		return nil, Validator{}, fmt.Errorf("Invalid URL %q: %w", src, err)
+	}
+
+	switch u.Scheme {
+	case "file":
+		path := localPath(u)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, Validator{}, fmt.Errorf("Failed to open %s: %w", path, err)
+		}
+		next := Validator{LastModified: info.ModTime().UTC().Format(http.TimeFormat)}
+		if v.LastModified != "" && v.LastModified == next.LastModified {
+			return nil, v, ErrNotModified
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, Validator{}, err
+		}
+		return data, next, nil
+	case "http", "https":
+	default:
+		return nil, Validator{}, fmt.Errorf("Unsupported URL scheme %q", u.Scheme)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, Validator{}, err
+	}
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+
+	resp, err := d.client().Do(req)
+	if err != nil {
+		return nil, Validator{}, fmt.Errorf("Failed to fetch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, v, ErrNotModified
+	case http.StatusOK:
+	default:
+		return nil, Validator{}, fmt.Errorf("Failed to fetch: bad response code: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchSize+1))
+	if err != nil {
+		return nil, Validator{}, fmt.Errorf("Failed to fetch: %w", err)
+	}
+	if len(data) > maxFetchSize {
+		return nil, Validator{}, fmt.Errorf("Failed to fetch: response exceeds %d bytes", maxFetchSize)
+	}
+	return data, Validator{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxnuo/MTranServer/internal/auth"
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/logger"
+	"github.com/xxnuo/MTranServer/internal/middleware"
+	"github.com/xxnuo/MTranServer/internal/services"
+)
+
+// quotaChargedKey authorizeTranslation 扣减且尚未退还的字符数在 gin.Context 中的键
+const quotaChargedKey = "quota_charged"
+
+// authorizeTranslation 检查文本长度限制与当前令牌的语言对权限，按字符数限流并扣减字符额度，失败时写入错误响应
+// 没有返回译文时需调用 refundQuota 退还额度
+// 源语言为 auto 时只检查目标语言，检测到的源语言由 translateContext 中的检查在翻译时校验
+func authorizeTranslation(c *gin.Context, fromLang, toLang string, texts ...string) bool {
+	if !middleware.CheckTexts(c, texts...) {
+		return false
+	}
+
+	body := middleware.RouteErrorBody(c)
+	t := middleware.GetToken(c)
+	if t != nil && !t.AllowsPair(fromLang, toLang) {
+		c.JSON(http.StatusForbidden, body(c, http.StatusForbidden, auth.ErrPairDenied.Error()))
+		return false
+	}
+
+	var chars int64
+	for _, text := range texts {
+		chars += int64(utf8.RuneCountInString(text))
+	}
+
+	if !middleware.ChargeRateLimit(c, int(chars)) {
+		return false
+	}
+
+	if t == nil {
+		return true
+	}
+
+	if err := auth.GetStore().Charge(t, chars); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, auth.ErrQuotaExceeded) {
+			status = http.StatusTooManyRequests
+		}
+		logger.Ctx(c.Request.Context()).Warn("Token %s (%s) rejected: %v", t.ID, t.Label, err)
+		c.JSON(status, body(c, status, err.Error()))
+		return false
+	}
+	c.Set(quotaChargedKey, chars)
+	return true
+}
+
+// refundQuota 退还 authorizeTranslation 扣减的字符额度，texts 为空时退还本次请求尚未退还的全部额度
+func refundQuota(c *gin.Context, texts ...string) {
+	t := middleware.GetToken(c)
+	charged := c.GetInt64(quotaChargedKey)
+	if t == nil || charged <= 0 {
+		return
+	}
+
+	chars := charged
+	if len(texts) > 0 {
+		chars = 0
+		for _, text := range texts {
+			chars += int64(utf8.RuneCountInString(text))
+		}
+		chars = min(chars, charged)
+	}
+	auth.GetStore().Refund(t, chars)
+	c.Set(quotaChargedKey, charged-chars)
+}
+
+// authorizeDetection 检查语言检测请求的文本长度限制并按字符数限流，检测不扣减令牌的字符额度
+func authorizeDetection(c *gin.Context, texts ...string) bool {
+	if !middleware.CheckTexts(c, texts...) {
+		return false
+	}
+	chars := 0
+	for _, text := range texts {
+		chars += utf8.RuneCountInString(text)
+	}
+	return middleware.ChargeRateLimit(c, chars)
+}
+
+// translateContext 返回翻译使用的请求 context，带有请求中的检测提示和当前令牌的语言对检查
+// 客户端发送的 Accept-Language 通常是目标语言，翻译时不作为检测提示
+func translateContext(c *gin.Context, hints *services.DetectHints) context.Context {
+	ctx := c.Request.Context()
+	if hints != nil {
+		ctx = services.ContextWithDetectHints(ctx, *hints)
+	}
+	if t := middleware.GetToken(c); t != nil && len(t.Pairs) > 0 {
+		ctx = services.ContextWithPairCheck(ctx, func(fromLang, toLang string) error {
+			if !t.AllowsPair(fromLang, toLang) {
+				return auth.ErrPairDenied
+			}
+			return nil
+		})
+	}
+	return ctx
+}
+
+// pairDenied 检测到的源语言不在令牌允许的语言对中时按路由的错误格式返回 403 并退还字符额度，返回 true 表示已写入响应
+func pairDenied(c *gin.Context, err error) bool {
+	if !errors.Is(err, auth.ErrPairDenied) {
+		return false
+	}
+	refundQuota(c)
+	c.JSON(http.StatusForbidden, middleware.RouteErrorBody(c)(c, http.StatusForbidden, err.Error()))
+	return true
+}
+
+// modelLoading 模型正在下载时按配置返回 202 或 503 并带上 Retry-After，同时退还字符额度，返回 true 表示已写入响应
+func modelLoading(c *gin.Context, err error) bool {
+	var loading *services.ModelLoadingError
+	if !errors.As(err, &loading) {
+		return false
+	}
+	refundQuota(c)
+	body := middleware.RouteErrorBody(c)
+
+	status := http.StatusServiceUnavailable
+	if config.GetConfig().ModelLoading == "accept" {
+		status = http.StatusAccepted
+	}
+
+	logger.Ctx(c.Request.Context()).Info("Request deferred: %v", loading)
+	c.Header("Retry-After", strconv.Itoa(int(loading.RetryAfter().Seconds())))
+	c.JSON(status, body(c, status, loading.Error()))
+	return true
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xxnuo/MTranServer/internal/auth"
+	"github.com/xxnuo/MTranServer/internal/middleware"
+)
+
+func TestRefundQuota(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := auth.GetStore()
+	info, raw, err := store.Create("refund", nil, nil, 100)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Delete(info.ID) })
+	tok, err := store.Authenticate(raw)
+	require.NoError(t, err)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set(middleware.TokenKey, tok)
+
+	require.True(t, authorizeTranslation(c, "en", "de", "Hello", "world!"))
+	assert.Equal(t, int64(11), store.UsedToday(tok.ID))
+
+	// 单条翻译失败时只退还该条
+	refundQuota(c, "world!")
+	assert.Equal(t, int64(5), store.UsedToday(tok.ID))
+
+	// 请求失败时退还剩余的全部额度，重复调用不会多退
+	refundQuota(c)
+	refundQuota(c)
+	assert.Equal(t, int64(0), store.UsedToday(tok.ID))
+}
+
+func TestDenyUsesRouteErrorBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := auth.GetStore()
+	pairs, rawPairs, err := store.Create("pairs", nil, []string{"en-de"}, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Delete(pairs.ID) })
+	quota, rawQuota, err := store.Create("quota", nil, nil, 3)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Delete(quota.ID) })
+
+	r := gin.New()
+	r.POST("/plugin", middleware.Authenticate(middleware.AuthOptions{
+		Store:   store,
+		Scope:   auth.ScopePlugin,
+		Sources: []middleware.CredentialSource{middleware.CredBearer},
+		Body: func(c *gin.Context, status int, message string) interface{} {
+			return gin.H{"plugin_error": message, "code": status}
+		},
+	}), func(c *gin.Context) {
+		if !authorizeTranslation(c, "en", c.Query("to"), "Hello") {
+			return
+		}
+		if pairDenied(c, auth.ErrPairDenied) {
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	cases := []struct {
+		raw    string
+		to     string
+		status int
+	}{
+		{rawPairs, "fr", http.StatusForbidden},
+		{rawQuota, "de", http.StatusTooManyRequests},
+		// 翻译时检测到不允许的语言对
+		{rawPairs, "de", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/plugin?to="+tc.to, nil)
+		req.Header.Set("Authorization", "Bearer "+tc.raw)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, tc.status, w.Code, tc.to)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Contains(t, resp, "plugin_error", tc.to)
+		assert.EqualValues(t, tc.status, resp["code"], tc.to)
+	}
+}
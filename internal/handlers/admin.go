@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxnuo/MTranServer/internal/auth"
+	"github.com/xxnuo/MTranServer/internal/logger"
+	"github.com/xxnuo/MTranServer/internal/middleware"
+)
+
+// CreateTokenRequest 创建令牌请求
+type CreateTokenRequest struct {
+	Label      string       `json:"label" binding:"required" example:"immersive-translate"`
+	Scopes     []auth.Scope `json:"scopes" example:"api,plugin"`
+	Pairs      []string     `json:"pairs" example:"en-zh-Hans,*-ja"`
+	DailyQuota int64        `json:"daily_quota" example:"100000"`
+}
+
+// CreateTokenResponse 创建令牌响应，明文令牌仅返回一次
+type CreateTokenResponse struct {
+	Token string         `json:"token" example:"mt_0123456789abcdef"`
+	Info  auth.TokenInfo `json:"info"`
+}
+
+// HandleListTokens 列出令牌
+// @Summary      列出令牌
+// @Description  列出令牌文件中的所有令牌及当日字符用量，不返回令牌明文
+// @Tags         管理
+// @Produce      json
+// @Success      200  {object}  map[string][]auth.TokenInfo
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Security     ApiKeyQuery
+// @Router       /admin/tokens [get]
+func HandleListTokens(store *auth.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"tokens": store.List(),
+		})
+	}
+}
+
+// HandleCreateToken 创建令牌
+// @Summary      创建令牌
+// @Description  创建新的访问令牌，明文令牌仅在响应中返回一次
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      CreateTokenRequest  true  "创建令牌请求"
+// @Success      201      {object}  CreateTokenResponse
+// @Failure      400      {object}  map[string]string
+// @Failure      401      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Security     ApiKeyQuery
+// @Router       /admin/tokens [post]
+func HandleCreateToken(store *auth.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req CreateTokenRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, err.Error()))
+			return
+		}
+		if req.DailyQuota < 0 {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "daily_quota must not be negative"))
+			return
+		}
+
+		info, raw, err := store.Create(req.Label, req.Scopes, req.Pairs, req.DailyQuota)
+		if err != nil {
+			logger.Ctx(c.Request.Context()).Error("Failed to create token: %v", err)
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusCreated, CreateTokenResponse{
+			Token: raw,
+			Info:  info,
+		})
+	}
+}
+
+// HandleDeleteToken 吊销令牌
+// @Summary      吊销令牌
+// @Description  删除指定 ID 的令牌
+// @Tags         管理
+// @Produce      json
+// @Param        id   path      string  true  "令牌 ID"
+// @Success      204
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Security     ApiKeyQuery
+// @Router       /admin/tokens/{id} [delete]
+func HandleDeleteToken(store *auth.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := store.Delete(c.Param("id"))
+		if errors.Is(err, auth.ErrTokenNotFound) {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, err.Error()))
+			return
+		}
+		if err != nil {
+			logger.Ctx(c.Request.Context()).Error("Failed to delete token: %v", err)
+			c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, err.Error()))
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxnuo/MTranServer/internal/middleware"
 	"github.com/xxnuo/MTranServer/internal/services"
 	"github.com/xxnuo/MTranServer/internal/utils"
 )
@@ -55,6 +56,11 @@ type DeeplTranslateResponse struct {
 	Translations []DeeplTranslation `json:"translations"`
 }
 
+// DeeplErrorBody DeepL API 格式的错误响应体
+func DeeplErrorBody(c *gin.Context, status int, message string) interface{} {
+	return gin.H{"message": message}
+}
+
 // HandleDeeplTranslate DeepL 翻译兼容接口
 // @Summary      DeepL 翻译兼容接口
 // @Description  兼容 DeepL API v2 的翻译接口
@@ -66,76 +72,54 @@ type DeeplTranslateResponse struct {
 // @Success      200      {object}  DeeplTranslateResponse
 // @Failure      400      {object}  map[string]string
 // @Failure      401      {object}  map[string]string
+// @Failure      429      {object}  map[string]interface{}
 // @Failure      500      {object}  map[string]string
 // @Router       /deepl [post]
-func HandleDeeplTranslate(apiToken string) gin.HandlerFunc {
-	return func(c *gin.Context) {
+func HandleDeeplTranslate(c *gin.Context) {
+	var req DeeplTranslateRequest
 
-		if apiToken != "" {
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, err.Error()))
+		return
+	}
 
-			authHeader := c.GetHeader("Authorization")
-			token := ""
+	sourceLang := "auto"
+	if req.SourceLang != "" {
+		sourceLang = utils.NormalizeLanguageCode(req.SourceLang)
+	}
+	targetLang := utils.NormalizeLanguageCode(req.TargetLang)
 
-			if strings.HasPrefix(authHeader, "DeepL-Auth-Key ") {
-				token = strings.TrimPrefix(authHeader, "DeepL-Auth-Key ")
-			} else if authHeader != "" {
+	if !authorizeTranslation(c, sourceLang, targetLang, req.Text...) {
+		return
+	}
 
-				token = strings.TrimPrefix(authHeader, "Bearer ")
-			} else {
+	translations := make([]DeeplTranslation, len(req.Text))
+	ctx, cancel := context.WithTimeout(translateContext(c, nil), 120*time.Second)
+	defer cancel()
 
-				token = c.Query("token")
-			}
+	isHTML := req.TagHandling == "html" || req.TagHandling == "xml"
 
-			if token != apiToken {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error": "Unauthorized",
-				})
+	for i, text := range req.Text {
+		result, err := services.TranslateWithPivot(ctx, sourceLang, targetLang, text, isHTML)
+		if err != nil {
+			if modelLoading(c, err) {
 				return
 			}
-		}
-		var req DeeplTranslateRequest
-
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
-
-		sourceLang := "auto"
-		if req.SourceLang != "" {
-			sourceLang = utils.NormalizeLanguageCode(req.SourceLang)
-		}
-		targetLang := utils.NormalizeLanguageCode(req.TargetLang)
-
-		translations := make([]DeeplTranslation, len(req.Text))
-		ctx, cancel := context.WithTimeout(c.Request.Context(), 120*time.Second)
-		defer cancel()
-
-		isHTML := req.TagHandling == "html" || req.TagHandling == "xml"
-
-		for i, text := range req.Text {
-			result, err := services.TranslateWithPivot(ctx, sourceLang, targetLang, text, isHTML)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": fmt.Sprintf("Translation failed at index %d: %v", i, err),
-				})
+			if pairDenied(c, err) {
 				return
 			}
-
-			detectedLang := req.SourceLang
-			if detectedLang == "" {
-				detectedLang = convertBCP47ToDeeplLang(sourceLang)
-			}
-
-			translations[i] = DeeplTranslation{
-				DetectedSourceLanguage: detectedLang,
-				Text:                   result,
-			}
+			refundQuota(c)
+			c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, fmt.Sprintf("Translation failed at index %d: %v", i, err)))
+			return
 		}
 
-		c.JSON(http.StatusOK, DeeplTranslateResponse{
-			Translations: translations,
-		})
+		translations[i] = DeeplTranslation{
+			DetectedSourceLanguage: convertBCP47ToDeeplLang(result.DetectedLanguage),
+			Text:                   result.Text,
+		}
 	}
+
+	c.JSON(http.StatusOK, DeeplTranslateResponse{
+		Translations: translations,
+	})
 }
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxnuo/MTranServer/internal/middleware"
+	"github.com/xxnuo/MTranServer/internal/services"
+	"github.com/xxnuo/MTranServer/internal/utils"
+)
+
+// defaultDetectLimit 未指定数量时返回的候选语言数
+const defaultDetectLimit = 5
+
+// DetectRequest 语言检测请求
+type DetectRequest struct {
+	Text string `json:"text" binding:"required" example:"Hello, world!"`
+	// Candidates 只在这些语言中检测，为空时不限制
+	Candidates []string `json:"candidates" example:"en,de,fr"`
+	// Segments 同时返回混合语言文本的分段
+	Segments bool `json:"segments" example:"false"`
+	// Limit 最多返回的候选语言数，默认 5
+	Limit int `json:"limit" example:"5"`
+	// Hints 检测提示，文本较短或置信度较低时优先选择提示中的语言
+	Hints *services.DetectHints `json:"hints,omitempty"`
+}
+
+// DetectSegment 混合语言文本中的一段，Start 和 End 为原文中的字节偏移
+type DetectSegment struct {
+	Language   string  `json:"language" example:"en"`
+	Confidence float64 `json:"confidence" example:"0.97"`
+	Start      int     `json:"start" example:"0"`
+	End        int     `json:"end" example:"13"`
+}
+
+// DetectResponse 语言检测响应
+type DetectResponse struct {
+	// Language 置信度最高的语言，无法检测时为空
+	Language   string                       `json:"language" example:"en"`
+	Confidence float64                      `json:"confidence" example:"0.92"`
+	Candidates []services.LanguageCandidate `json:"candidates"`
+	Segments   []DetectSegment              `json:"segments,omitempty"`
+}
+
+// HandleDetect 语言检测
+// @Summary      语言检测
+// @Description  返回按置信度排序的候选语言，可限定候选语言并返回混合语言文本的分段
+// @Tags         翻译
+// @Accept       json
+// @Produce      json
+// @Param        request  body      DetectRequest  true  "语言检测请求"
+// @Success      200      {object}  DetectResponse
+// @Failure      400      {object}  map[string]string
+// @Failure      413      {object}  map[string]string
+// @Failure      429      {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Security     ApiKeyQuery
+// @Router       /detect [post]
+func HandleDetect(c *gin.Context) {
+	var req DetectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, err.Error()))
+		return
+	}
+	if !authorizeDetection(c, req.Text) {
+		return
+	}
+
+	candidates := normalizeLanguages(req.Candidates)
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultDetectLimit
+	}
+
+	resp := DetectResponse{Candidates: detectCandidates(req.Text, candidates, limit, requestHints(c, req.Hints))}
+	if len(resp.Candidates) > 0 {
+		resp.Language, resp.Confidence = resp.Candidates[0].Language, resp.Candidates[0].Confidence
+	}
+	if req.Segments {
+		for _, seg := range services.DetectSegments(req.Text, candidates) {
+			resp.Segments = append(resp.Segments, DetectSegment{
+				Language:   seg.Language,
+				Confidence: seg.Confidence,
+				Start:      seg.Start,
+				End:        seg.End,
+			})
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// LibreDetectRequest LibreTranslate 语言检测请求，支持 JSON 和表单
+type LibreDetectRequest struct {
+	Q      string `json:"q" form:"q" binding:"required" example:"Hello, world!"`
+	APIKey string `json:"api_key" form:"api_key"`
+}
+
+// LibreDetection LibreTranslate 格式的候选语言
+type LibreDetection struct {
+	// Confidence 置信度，范围 0 到 100
+	Confidence float64 `json:"confidence" example:"92"`
+	Language   string  `json:"language" example:"en"`
+}
+
+var bcp47ToLibreLang = map[string]string{
+	"zh-Hans": "zh",
+}
+
+// LibreErrorBody LibreTranslate API 格式的错误响应体
+func LibreErrorBody(c *gin.Context, status int, message string) interface{} {
+	return gin.H{"error": message}
+}
+
+// HandleLibreDetect LibreTranslate 语言检测兼容接口
+// @Summary      LibreTranslate 语言检测兼容接口
+// @Description  兼容 LibreTranslate /detect 的语言检测接口
+// @Tags         插件
+// @Accept       json
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        api_key  query     string              false  "API Key"
+// @Param        request  body      LibreDetectRequest  true   "LibreTranslate 语言检测请求"
+// @Success      200      {array}   LibreDetection
+// @Failure      400      {object}  map[string]string
+// @Failure      401      {object}  map[string]string
+// @Failure      429      {object}  map[string]string
+// @Router       /libre/detect [post]
+func HandleLibreDetect(c *gin.Context) {
+	var req LibreDetectRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, LibreErrorBody(c, http.StatusBadRequest, err.Error()))
+		return
+	}
+	if !authorizeDetection(c, req.Q) {
+		return
+	}
+
+	candidates := detectCandidates(req.Q, nil, defaultDetectLimit, requestHints(c, nil))
+	detections := make([]LibreDetection, 0, len(candidates))
+	for _, candidate := range candidates {
+		lang := candidate.Language
+		if libreLang, ok := bcp47ToLibreLang[lang]; ok {
+			lang = libreLang
+		}
+		detections = append(detections, LibreDetection{
+			Confidence: math.Round(candidate.Confidence*1000) / 10,
+			Language:   lang,
+		})
+	}
+	c.JSON(http.StatusOK, detections)
+}
+
+// GoogleDetectRequest Google 语言检测请求，q 可以是字符串或字符串数组
+type GoogleDetectRequest struct {
+	Q interface{} `json:"q" binding:"required" swaggertype:"array,string" example:"Hello, world!"`
+}
+
+// GoogleDetection Google 格式的检测结果
+type GoogleDetection struct {
+	Language string `json:"language" example:"en"`
+	// IsReliable Google 已弃用该字段，始终为 false
+	IsReliable bool    `json:"isReliable" example:"false"`
+	Confidence float64 `json:"confidence" example:"0.92"`
+}
+
+type GoogleDetectResponse struct {
+	Data struct {
+		Detections [][]GoogleDetection `json:"detections"`
+	} `json:"data"`
+}
+
+// HandleGoogleDetect Google 语言检测兼容接口
+// @Summary      Google 语言检测兼容接口
+// @Description  兼容 Google Translate API v2 的语言检测接口
+// @Tags         插件
+// @Accept       json
+// @Produce      json
+// @Param        key      query     string               false  "API Key"
+// @Param        request  body      GoogleDetectRequest  true   "Google 语言检测请求"
+// @Success      200      {object}  GoogleDetectResponse
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      429      {object}  map[string]interface{}
+// @Router       /google/language/translate/v2/detect [post]
+func HandleGoogleDetect(c *gin.Context) {
+	var req GoogleDetectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, GoogleErrorBody(c, http.StatusBadRequest, err.Error()))
+		return
+	}
+	texts, ok := googleQueries(req.Q)
+	if !ok {
+		c.JSON(http.StatusBadRequest, GoogleErrorBody(c, http.StatusBadRequest, "q must be a string or an array of strings"))
+		return
+	}
+	if !authorizeDetection(c, texts...) {
+		return
+	}
+
+	var resp GoogleDetectResponse
+	resp.Data.Detections = make([][]GoogleDetection, 0, len(texts))
+	for _, text := range texts {
+		// Google 无法检测时返回 und
+		detection := GoogleDetection{Language: "und"}
+		if candidates := detectCandidates(text, nil, 1, requestHints(c, nil)); len(candidates) > 0 {
+			detection.Language = convertBCP47ToGoogleLang(candidates[0].Language)
+			detection.Confidence = candidates[0].Confidence
+		}
+		resp.Data.Detections = append(resp.Data.Detections, []GoogleDetection{detection})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// googleQueries 读取字符串或字符串数组形式的 q
+func googleQueries(q interface{}) ([]string, bool) {
+	switch v := q.(type) {
+	case string:
+		return []string{v}, true
+	case []interface{}:
+		texts := make([]string, 0, len(v))
+		for _, item := range v {
+			text, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			texts = append(texts, text)
+		}
+		return texts, len(texts) > 0
+	}
+	return nil, false
+}
+
+// detectCandidates 返回最多 limit 个候选语言，没有候选语言时返回空列表
+func detectCandidates(text string, candidates []string, limit int, hints services.DetectHints) []services.LanguageCandidate {
+	list := services.DetectCandidatesWithHints(text, candidates, hints)
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	if list == nil {
+		list = []services.LanguageCandidate{}
+	}
+	return list
+}
+
+// normalizeLanguages 规范化语言代码并去掉空值
+func normalizeLanguages(langs []string) []string {
+	var normalized []string
+	for _, lang := range langs {
+		if lang = utils.NormalizeLanguageCode(lang); lang != "" {
+			normalized = append(normalized, lang)
+		}
+	}
+	return normalized
+}
+
+// requestHints 返回检测接口的检测提示，未指定界面语言时使用 Accept-Language 中的首选语言
+func requestHints(c *gin.Context, hints *services.DetectHints) services.DetectHints {
+	var h services.DetectHints
+	if hints != nil {
+		h = *hints
+	}
+	if h.Locale == "" {
+		h.Locale = acceptLanguage(c.GetHeader("Accept-Language"))
+	}
+	return h
+}
+
+// acceptLanguage 返回 Accept-Language 中的第一个语言，忽略 *
+func acceptLanguage(header string) string {
+	first, _, _ := strings.Cut(header, ",")
+	tag, _, _ := strings.Cut(first, ";")
+	tag = strings.TrimSpace(tag)
+	if tag == "*" {
+		return ""
+	}
+	return tag
+}
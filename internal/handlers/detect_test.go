@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoogleQueries(t *testing.T) {
+	texts, ok := googleQueries("Hello")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"Hello"}, texts)
+
+	texts, ok = googleQueries([]interface{}{"Hello", "Bonjour"})
+	assert.True(t, ok)
+	assert.Equal(t, []string{"Hello", "Bonjour"}, texts)
+
+	_, ok = googleQueries([]interface{}{"Hello", 1})
+	assert.False(t, ok)
+	_, ok = googleQueries([]interface{}{})
+	assert.False(t, ok)
+	_, ok = googleQueries(42.0)
+	assert.False(t, ok)
+}
+
+func TestAcceptLanguage(t *testing.T) {
+	assert.Equal(t, "de-DE", acceptLanguage("de-DE,de;q=0.9,en;q=0.8"))
+	assert.Equal(t, "fr", acceptLanguage(" fr;q=0.7 "))
+	assert.Equal(t, "", acceptLanguage("*"))
+	assert.Equal(t, "", acceptLanguage(""))
+}
@@ -4,10 +4,10 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxnuo/MTranServer/internal/middleware"
 	"github.com/xxnuo/MTranServer/internal/services"
 	"github.com/xxnuo/MTranServer/internal/utils"
 )
@@ -36,10 +36,36 @@ type GoogleTranslateResponse struct {
 	Data struct {
 		Translations []struct {
 			TranslatedText string `json:"translatedText" example:"吉萨大金字塔"`
+			// DetectedSourceLanguage 源语言为 auto 时检测到的语言
+			DetectedSourceLanguage string `json:"detectedSourceLanguage,omitempty" example:"en"`
 		} `json:"translations"`
 	} `json:"data"`
 }
 
+// GoogleErrorBody Google API 格式的错误响应体
+func GoogleErrorBody(c *gin.Context, status int, message string) interface{} {
+	reason := "UNKNOWN"
+	switch status {
+	case http.StatusTooManyRequests:
+		reason = "RESOURCE_EXHAUSTED"
+	case http.StatusUnauthorized:
+		reason = "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		reason = "PERMISSION_DENIED"
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		reason = "INVALID_ARGUMENT"
+	case http.StatusServiceUnavailable:
+		reason = "UNAVAILABLE"
+	}
+	return gin.H{
+		"error": gin.H{
+			"code":    status,
+			"message": message,
+			"status":  reason,
+		},
+	}
+}
+
 // HandleGoogleCompatTranslate Google 翻译兼容接口
 // @Summary      Google 翻译兼容接口
 // @Description  兼容 Google Translate API v2 的翻译接口
@@ -51,70 +77,50 @@ type GoogleTranslateResponse struct {
 // @Success      200      {object}  GoogleTranslateResponse
 // @Failure      400      {object}  map[string]string
 // @Failure      401      {object}  map[string]string
+// @Failure      429      {object}  map[string]interface{}
 // @Failure      500      {object}  map[string]string
 // @Router       /google/language/translate/v2 [post]
-func HandleGoogleCompatTranslate(apiToken string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-
-		if apiToken != "" {
-
-			token := c.Query("key")
-
-			if token == "" {
-				authHeader := c.GetHeader("Authorization")
-				if strings.HasPrefix(authHeader, "Bearer ") {
-					token = strings.TrimPrefix(authHeader, "Bearer ")
-				} else if authHeader != "" {
-					token = authHeader
-				}
-			}
-
-			if token == "" {
-				token = c.Query("token")
-			}
-
-			if token != apiToken {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error": "Unauthorized",
-				})
-				return
-			}
-		}
+func HandleGoogleCompatTranslate(c *gin.Context) {
+	var req GoogleTranslateRequest
 
-		var req GoogleTranslateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, err.Error()))
+		return
+	}
 
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
+	sourceBCP47 := utils.NormalizeLanguageCode(req.Source)
+	targetBCP47 := utils.NormalizeLanguageCode(req.Target)
 
-		sourceBCP47 := utils.NormalizeLanguageCode(req.Source)
-		targetBCP47 := utils.NormalizeLanguageCode(req.Target)
+	if !authorizeTranslation(c, sourceBCP47, targetBCP47, req.Q) {
+		return
+	}
 
-		ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
-		defer cancel()
+	ctx, cancel := context.WithTimeout(translateContext(c, nil), 60*time.Second)
+	defer cancel()
 
-		isHTML := req.Format == "html"
-		result, err := services.TranslateWithPivot(ctx, sourceBCP47, targetBCP47, req.Q, isHTML)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": fmt.Sprintf("Translation failed: %v", err),
-			})
+	isHTML := req.Format == "html"
+	result, err := services.TranslateWithPivot(ctx, sourceBCP47, targetBCP47, req.Q, isHTML)
+	if err != nil {
+		if modelLoading(c, err) {
+			return
+		}
+		if pairDenied(c, err) {
 			return
 		}
+		refundQuota(c)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, fmt.Sprintf("Translation failed: %v", err)))
+		return
+	}
 
-		c.JSON(http.StatusOK, gin.H{
-			"data": gin.H{
-				"translations": []gin.H{
-					{
-						"translatedText": result,
-					},
-				},
-			},
-		})
+	translation := gin.H{"translatedText": result.Text}
+	if sourceBCP47 == "auto" {
+		translation["detectedSourceLanguage"] = convertBCP47ToGoogleLang(result.DetectedLanguage)
 	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"translations": []gin.H{translation},
+		},
+	})
 }
 
 // HandleGoogleTranslateSingle Google translate_a/single 兼容接口
@@ -132,80 +138,66 @@ func HandleGoogleCompatTranslate(apiToken string) gin.HandlerFunc {
 // @Success      200     {array}   interface{}
 // @Failure      400     {object}  map[string]string
 // @Failure      401     {object}  map[string]string
+// @Failure      429     {object}  map[string]interface{}
 // @Failure      500     {object}  map[string]string
 // @Router       /google/translate_a/single [get]
-func HandleGoogleTranslateSingle(apiToken string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-
-		if apiToken != "" {
-			token := c.Query("key")
-
-			if token == "" {
-				authHeader := c.GetHeader("Authorization")
-				if strings.HasPrefix(authHeader, "Bearer ") {
-					token = strings.TrimPrefix(authHeader, "Bearer ")
-				} else if authHeader != "" {
-					token = authHeader
-				}
-			}
-
-			if token == "" {
-				token = c.Query("token")
-			}
-
-			if token != apiToken {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error": "Unauthorized",
-				})
-				return
-			}
-		}
-
-		sl := c.Query("sl")
-		tl := c.Query("tl")
-		q := c.Query("q")
+func HandleGoogleTranslateSingle(c *gin.Context) {
+	sl := c.Query("sl")
+	tl := c.Query("tl")
+	q := c.Query("q")
+
+	if tl == "" || q == "" {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "Missing required parameters: tl, q"))
+		return
+	}
 
-		if tl == "" || q == "" {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Missing required parameters: tl, q",
-			})
-			return
-		}
+	if sl == "" {
+		sl = "auto"
+	}
 
-		if sl == "" {
-			sl = "auto"
-		}
+	text := q
 
-		text := q
+	sourceBCP47 := utils.NormalizeLanguageCode(sl)
+	targetBCP47 := utils.NormalizeLanguageCode(tl)
 
-		sourceBCP47 := utils.NormalizeLanguageCode(sl)
-		targetBCP47 := utils.NormalizeLanguageCode(tl)
+	if !authorizeTranslation(c, sourceBCP47, targetBCP47, text) {
+		return
+	}
 
-		ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
-		defer cancel()
+	ctx, cancel := context.WithTimeout(translateContext(c, nil), 60*time.Second)
+	defer cancel()
 
-		result, err := services.TranslateWithPivot(ctx, sourceBCP47, targetBCP47, text, false)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": fmt.Sprintf("Translation failed: %v", err),
-			})
+	result, err := services.TranslateWithPivot(ctx, sourceBCP47, targetBCP47, text, false)
+	if err != nil {
+		if modelLoading(c, err) {
 			return
 		}
-
-		detectedLang := convertBCP47ToGoogleLang(sourceBCP47)
-		response := []interface{}{
-			[]interface{}{
-				[]interface{}{result, text, nil, nil, 1},
-			},
-			nil,
-			detectedLang,
-			nil,
-			nil,
-			nil,
-			nil,
-			[]interface{}{},
+		if pairDenied(c, err) {
+			return
 		}
+		refundQuota(c)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, fmt.Sprintf("Translation failed: %v", err)))
+		return
+	}
 
-		c.JSON(http.StatusOK, response)
+	// 第 7 项为源语言检测的置信度
+	var confidence interface{}
+	if result.Detected {
+		confidence = result.Confidence
+	}
+	detectedLang := convertBCP47ToGoogleLang(result.DetectedLanguage)
+	response := []interface{}{
+		[]interface{}{
+			[]interface{}{result.Text, text, nil, nil, 1},
+		},
+		nil,
+		detectedLang,
+		nil,
+		nil,
+		nil,
+		confidence,
+		[]interface{}{},
 	}
+
+	c.JSON(http.StatusOK, response)
 }
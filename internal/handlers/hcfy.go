@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxnuo/MTranServer/internal/middleware"
 	"github.com/xxnuo/MTranServer/internal/services"
 )
 
@@ -282,105 +283,101 @@ type HcfyTranslateResponse struct {
 // @Param        request  body      HcfyTranslateRequest  true   "划词翻译请求"
 // @Success      200      {object}  HcfyTranslateResponse
 // @Failure      400      {object}  map[string]string
+// @Failure      429      {object}  map[string]string
 // @Failure      401      {object}  map[string]string
 // @Failure      500      {object}  map[string]string
 // @Router       /hcfy [post]
-func HandleHcfyTranslate(apiToken string) gin.HandlerFunc {
-	return func(c *gin.Context) {
+func HandleHcfyTranslate(c *gin.Context) {
+	var req HcfyTranslateRequest
 
-		if apiToken != "" {
-			token := c.Query("token")
-			if token == "" {
-				authHeader := c.GetHeader("Authorization")
-				if strings.HasPrefix(authHeader, "Bearer ") {
-					token = strings.TrimPrefix(authHeader, "Bearer ")
-				} else if authHeader != "" {
-					token = authHeader
-				}
-			}
-
-			if token != apiToken {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error": "Unauthorized",
-				})
-				return
-			}
-		}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, err.Error()))
+		return
+	}
 
-		var req HcfyTranslateRequest
+	sourceLang := "auto"
+	if req.Source != "" {
+		sourceLang = convertHcfyLangToBCP47(req.Source)
+	}
 
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
+	if len(req.Destination) == 0 {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "destination is required"))
+		return
+	}
 
-		sourceLang := "auto"
-		if req.Source != "" {
-			sourceLang = convertHcfyLangToBCP47(req.Source)
-		}
+	targetLangName := req.Destination[0]
+	targetLang := convertHcfyLangToBCP47(targetLangName)
 
-		if len(req.Destination) == 0 {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "destination is required",
-			})
-			return
-		}
+	if !middleware.CheckTexts(c, req.Text) {
+		return
+	}
 
-		targetLangName := req.Destination[0]
-		targetLang := convertHcfyLangToBCP47(targetLangName)
+	// 需要先确定源语言，才能在源语言与首选目标语言相同时改用第二个目标语言
+	detectedSourceLang := sourceLang
+	if sourceLang == "auto" {
+		detectedSourceLang = detectHcfyLanguage(req.Text)
+	}
 
-		detectedSourceLang := sourceLang
-		if sourceLang == "auto" {
+	if detectedSourceLang == targetLang && len(req.Destination) > 1 {
+		targetLangName = req.Destination[1]
+		targetLang = convertHcfyLangToBCP47(targetLangName)
+	}
 
-			if containsChinese(req.Text) {
-				detectedSourceLang = "zh-Hans"
-			} else if containsJapanese(req.Text) {
-				detectedSourceLang = "ja"
-			} else if containsKorean(req.Text) {
-				detectedSourceLang = "ko"
-			} else {
-				detectedSourceLang = "en"
-			}
-		}
+	// 按最终的源语言和目标语言检查令牌权限
+	if !authorizeTranslation(c, detectedSourceLang, targetLang, req.Text) {
+		return
+	}
 
-		if detectedSourceLang == targetLang && len(req.Destination) > 1 {
-			targetLangName = req.Destination[1]
-			targetLang = convertHcfyLangToBCP47(targetLangName)
-		}
+	ctx, cancel := context.WithTimeout(translateContext(c, nil), 60*time.Second)
+	defer cancel()
 
-		ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
-		defer cancel()
+	paragraphs := strings.Split(req.Text, "\n")
+	results := make([]string, len(paragraphs))
 
-		paragraphs := strings.Split(req.Text, "\n")
-		results := make([]string, len(paragraphs))
+	for i, paragraph := range paragraphs {
+		if paragraph == "" {
+			results[i] = ""
+			continue
+		}
 
-		for i, paragraph := range paragraphs {
-			if paragraph == "" {
-				results[i] = ""
-				continue
+		result, err := services.TranslateWithPivot(ctx, detectedSourceLang, targetLang, paragraph, false)
+		if err != nil {
+			if modelLoading(c, err) {
+				return
 			}
-
-			result, err := services.TranslateWithPivot(ctx, detectedSourceLang, targetLang, paragraph, false)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": fmt.Sprintf("Translation failed at paragraph %d: %v", i, err),
-				})
+			if pairDenied(c, err) {
 				return
 			}
-			results[i] = result
+			refundQuota(c)
+			c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, fmt.Sprintf("Translation failed at paragraph %d: %v", i, err)))
+			return
 		}
+		results[i] = result.Text
+	}
 
-		response := HcfyTranslateResponse{
-			Text:   req.Text,
-			From:   convertBCP47ToHcfyLang(detectedSourceLang),
-			To:     targetLangName,
-			Result: results,
-		}
+	response := HcfyTranslateResponse{
+		Text:   req.Text,
+		From:   convertBCP47ToHcfyLang(detectedSourceLang),
+		To:     targetLangName,
+		Result: results,
+	}
 
-		c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, response)
+}
+
+// detectHcfyLanguage 使用语言检测器判断源语言，无法判断时按文字种类推测
+func detectHcfyLanguage(text string) string {
+	if lang := services.DetectLanguage(text); lang != "" {
+		return lang
+	}
+	if containsChinese(text) {
+		return "zh-Hans"
+	} else if containsJapanese(text) {
+		return "ja"
+	} else if containsKorean(text) {
+		return "ko"
 	}
+	return "en"
 }
 
 func containsChinese(text string) bool {
@@ -7,10 +7,24 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/xxnuo/MTranServer/internal/logger"
+	"github.com/xxnuo/MTranServer/internal/middleware"
 	"github.com/xxnuo/MTranServer/internal/services"
 	"github.com/xxnuo/MTranServer/internal/utils"
 )
 
+var bcp47ToImmeLang = map[string]string{
+	"zh-Hans": "zh-CN",
+	"zh-Hant": "zh-TW",
+}
+
+func convertBCP47ToImmeLang(bcp47Lang string) string {
+	if immeLang, ok := bcp47ToImmeLang[bcp47Lang]; ok {
+		return immeLang
+	}
+
+	return bcp47Lang
+}
+
 type ImmeTranslateRequest struct {
 	SourceLang string   `json:"source_lang" binding:"required" example:"en"`
 	TargetLang string   `json:"target_lang" binding:"required" example:"zh-CN"`
@@ -36,57 +50,57 @@ type ImmeTranslateResponse struct {
 // @Param        request  body      ImmeTranslateRequest    true   "沉浸式翻译请求"
 // @Success      200      {object}  ImmeTranslateResponse
 // @Failure      400      {object}  map[string]string
+// @Failure      429      {object}  map[string]string
 // @Failure      401      {object}  map[string]string
 // @Failure      500      {object}  map[string]string
 // @Router       /imme [post]
-func HandleImmeTranslate(apiToken string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-
-		if apiToken != "" {
-			token := c.Query("token")
-			if token != apiToken {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error": "Unauthorized",
-				})
-				return
-			}
-		}
+func HandleImmeTranslate(c *gin.Context) {
+	var req ImmeTranslateRequest
 
-		var req ImmeTranslateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, err.Error()))
+		return
+	}
 
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
+	sourceLang := utils.NormalizeLanguageCode(req.SourceLang)
+	targetLang := utils.NormalizeLanguageCode(req.TargetLang)
 
-		sourceLang := utils.NormalizeLanguageCode(req.SourceLang)
-		targetLang := utils.NormalizeLanguageCode(req.TargetLang)
+	if !authorizeTranslation(c, sourceLang, targetLang, req.TextList...) {
+		return
+	}
 
-		translations := make([]ImmeTranslation, len(req.TextList))
-		ctx, cancel := context.WithTimeout(c.Request.Context(), 120*time.Second)
-		defer cancel()
+	translations := make([]ImmeTranslation, len(req.TextList))
+	ctx, cancel := context.WithTimeout(translateContext(c, nil), 120*time.Second)
+	defer cancel()
 
-		logger.Debug("Imme request: %s -> %s, count: %d", sourceLang, targetLang, len(req.TextList))
-		for i, text := range req.TextList {
-			logger.Debug("Imme translating [%d/%d]: %s -> %s, text length: %d, text: %q", i+1, len(req.TextList), sourceLang, targetLang, len(text), text)
-			result, err := services.TranslateWithPivot(ctx, sourceLang, targetLang, text, true)
-			if err != nil {
-				logger.Error("Imme translation failed at index %d (%s -> %s): %v", i, sourceLang, targetLang, err)
-				result = text // Fallback to original text
-			} else {
-				logger.Debug("Imme translated [%d/%d] success", i+1, len(req.TextList))
+	logger.Ctx(c.Request.Context()).Debug("Imme request: %s -> %s, count: %d", sourceLang, targetLang, len(req.TextList))
+	for i, text := range req.TextList {
+		logger.Ctx(c.Request.Context()).Debug("Imme translating [%d/%d]: %s -> %s, text length: %d, text: %q", i+1, len(req.TextList), sourceLang, targetLang, len(text), text)
+		// 翻译失败时返回原文，自动检测的源语言未知
+		translations[i] = ImmeTranslation{Text: text}
+		if sourceLang != "auto" {
+			translations[i].DetectedSourceLang = convertBCP47ToImmeLang(sourceLang)
+		}
+		result, err := services.TranslateWithPivot(ctx, sourceLang, targetLang, text, true)
+		if err != nil {
+			if modelLoading(c, err) {
+				return
 			}
-
-			translations[i] = ImmeTranslation{
-				DetectedSourceLang: req.SourceLang,
-				Text:               result,
+			if pairDenied(c, err) {
+				return
 			}
+			refundQuota(c, text)
+			logger.Ctx(c.Request.Context()).Error("Imme translation failed at index %d (%s -> %s): %v", i, sourceLang, targetLang, err)
+			continue
+		}
+		logger.Ctx(c.Request.Context()).Debug("Imme translated [%d/%d] success", i+1, len(req.TextList))
+		translations[i] = ImmeTranslation{
+			DetectedSourceLang: convertBCP47ToImmeLang(result.DetectedLanguage),
+			Text:               result.Text,
 		}
-
-		c.JSON(http.StatusOK, ImmeTranslateResponse{
-			Translations: translations,
-		})
 	}
+
+	c.JSON(http.StatusOK, ImmeTranslateResponse{
+		Translations: translations,
+	})
 }
@@ -7,10 +7,24 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxnuo/MTranServer/internal/middleware"
 	"github.com/xxnuo/MTranServer/internal/services"
 	"github.com/xxnuo/MTranServer/internal/utils"
 )
 
+var bcp47ToKissLang = map[string]string{
+	"zh-Hans": "zh-CN",
+	"zh-Hant": "zh-TW",
+}
+
+func convertBCP47ToKissLang(bcp47Lang string) string {
+	if kissLang, ok := bcp47ToKissLang[bcp47Lang]; ok {
+		return kissLang
+	}
+
+	return bcp47Lang
+}
+
 type KissTranslateRequest struct {
 	From string `json:"from" binding:"required" example:"en"`
 	To   string `json:"to" binding:"required" example:"zh-CN"`
@@ -47,81 +61,72 @@ type KissBatchTranslateResponse struct {
 // @Param        request  body      KissTranslateRequest  true   "简约翻译请求"
 // @Success      200      {object}  KissTranslateResponse
 // @Failure      400      {object}  map[string]string
+// @Failure      429      {object}  map[string]string
 // @Failure      401      {object}  map[string]string
 // @Failure      500      {object}  map[string]string
 // @Router       /kiss [post]
-func HandleKissTranslate(apiToken string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-
-		if apiToken != "" {
-			token := c.GetHeader("KEY")
-			if token != apiToken {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error": "Unauthorized",
-				})
-				return
-			}
-		}
-
-		var rawReq map[string]interface{}
-		if err := c.ShouldBindJSON(&rawReq); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
+func HandleKissTranslate(c *gin.Context) {
+	var rawReq map[string]interface{}
+	if err := c.ShouldBindJSON(&rawReq); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, err.Error()))
+		return
+	}
 
-		if texts, ok := rawReq["texts"].([]interface{}); ok && len(texts) > 0 {
+	if texts, ok := rawReq["texts"].([]interface{}); ok && len(texts) > 0 {
 
-			var batchReq KissBatchTranslateRequest
-			batchReq.From, _ = rawReq["from"].(string)
-			batchReq.To, _ = rawReq["to"].(string)
-			for _, t := range texts {
-				if str, ok := t.(string); ok {
-					batchReq.Texts = append(batchReq.Texts, str)
-				}
-			}
-			if batchReq.From == "" || batchReq.To == "" || len(batchReq.Texts) == 0 {
-				c.JSON(http.StatusBadRequest, gin.H{
-					"error": "Invalid batch request",
-				})
-				return
+		var batchReq KissBatchTranslateRequest
+		batchReq.From, _ = rawReq["from"].(string)
+		batchReq.To, _ = rawReq["to"].(string)
+		for _, t := range texts {
+			if str, ok := t.(string); ok {
+				batchReq.Texts = append(batchReq.Texts, str)
 			}
-			handleBatchTranslate(c, batchReq)
+		}
+		if batchReq.From == "" || batchReq.To == "" || len(batchReq.Texts) == 0 {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "Invalid batch request"))
 			return
 		}
+		handleBatchTranslate(c, batchReq)
+		return
+	}
 
-		var req KissTranslateRequest
-		req.From, _ = rawReq["from"].(string)
-		req.To, _ = rawReq["to"].(string)
-		req.Text, _ = rawReq["text"].(string)
+	var req KissTranslateRequest
+	req.From, _ = rawReq["from"].(string)
+	req.To, _ = rawReq["to"].(string)
+	req.Text, _ = rawReq["text"].(string)
 
-		if req.From == "" || req.To == "" || req.Text == "" {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Missing required fields: from, to, text",
-			})
-			return
-		}
+	if req.From == "" || req.To == "" || req.Text == "" {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "Missing required fields: from, to, text"))
+		return
+	}
 
-		fromLang := utils.NormalizeLanguageCode(req.From)
-		toLang := utils.NormalizeLanguageCode(req.To)
+	fromLang := utils.NormalizeLanguageCode(req.From)
+	toLang := utils.NormalizeLanguageCode(req.To)
 
-		ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
-		defer cancel()
+	if !authorizeTranslation(c, fromLang, toLang, req.Text) {
+		return
+	}
 
-		result, err := services.TranslateWithPivot(ctx, fromLang, toLang, req.Text, false)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": fmt.Sprintf("Translation failed: %v", err),
-			})
+	ctx, cancel := context.WithTimeout(translateContext(c, nil), 60*time.Second)
+	defer cancel()
+
+	result, err := services.TranslateWithPivot(ctx, fromLang, toLang, req.Text, false)
+	if err != nil {
+		if modelLoading(c, err) {
 			return
 		}
-
-		c.JSON(http.StatusOK, gin.H{
-			"text": result,
-			"src":  req.From,
-		})
+		if pairDenied(c, err) {
+			return
+		}
+		refundQuota(c)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, fmt.Sprintf("Translation failed: %v", err)))
+		return
 	}
+
+	c.JSON(http.StatusOK, KissTranslateResponse{
+		Text: result.Text,
+		Src:  convertBCP47ToKissLang(result.DetectedLanguage),
+	})
 }
 
 func handleBatchTranslate(c *gin.Context, req KissBatchTranslateRequest) {
@@ -129,21 +134,30 @@ func handleBatchTranslate(c *gin.Context, req KissBatchTranslateRequest) {
 	fromLang := utils.NormalizeLanguageCode(req.From)
 	toLang := utils.NormalizeLanguageCode(req.To)
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 120*time.Second)
+	if !authorizeTranslation(c, fromLang, toLang, req.Texts...) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(translateContext(c, nil), 120*time.Second)
 	defer cancel()
 
 	translations := make([]KissBatchTranslateItem, 0, len(req.Texts))
 	for _, text := range req.Texts {
 		result, err := services.TranslateWithPivot(ctx, fromLang, toLang, text, false)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": fmt.Sprintf("Translation failed: %v", err),
-			})
+			if modelLoading(c, err) {
+				return
+			}
+			if pairDenied(c, err) {
+				return
+			}
+			refundQuota(c)
+			c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, fmt.Sprintf("Translation failed: %v", err)))
 			return
 		}
 		translations = append(translations, KissBatchTranslateItem{
-			Text: result,
-			Src:  req.From,
+			Text: result.Text,
+			Src:  convertBCP47ToKissLang(result.DetectedLanguage),
 		})
 	}
 
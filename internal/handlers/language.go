@@ -4,39 +4,42 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/middleware"
 	"github.com/xxnuo/MTranServer/internal/models"
 )
 
+// LanguagesResponse 支持的语言和语言对
+type LanguagesResponse struct {
+	Languages []string `json:"languages"`
+	// Pairs 支持的语言对及其使用的模型架构
+	Pairs []models.PairInfo `json:"pairs"`
+}
+
 // handleLanguages 获取支持的语言列表
 // @Summary      获取支持的语言列表
-// @Description  返回所有支持的翻译语言代码
+// @Description  返回所有支持的翻译语言代码和语言对，包括本地注册的模型，语言对中包含使用的模型架构
 // @Tags         翻译
 // @Produce      json
-// @Success      200  {object}  map[string][]string
+// @Success      200  {object}  LanguagesResponse
 // @Failure      500  {object}  map[string]string
 // @Security     ApiKeyAuth
 // @Security     ApiKeyQuery
 // @Router       /languages [get]
 func HandleLanguages(c *gin.Context) {
-	if models.GlobalRecords == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Records not initialized",
-		})
+	if models.GetRecords() == nil {
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, "Records not initialized"))
 		return
 	}
 
-	langMap := make(map[string]bool)
-	for _, record := range models.GlobalRecords.Data {
-		langMap[record.SourceLanguage] = true
-		langMap[record.TargetLanguage] = true
-	}
-
-	languages := make([]string, 0, len(langMap))
-	for lang := range langMap {
-		languages = append(languages, lang)
+	languages, err := models.GetSupportedLanguages()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, err.Error()))
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"languages": languages,
+	c.JSON(http.StatusOK, LanguagesResponse{
+		Languages: languages,
+		Pairs:     models.SupportedPairs(config.GetConfig().ModelDir),
 	})
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/xxnuo/MTranServer/internal/models"
+	"github.com/xxnuo/MTranServer/internal/utils"
 )
 
 func TestHandleLanguages(t *testing.T) {
@@ -51,3 +52,12 @@ func TestHandleLanguagesNotInitialized(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "error")
 	assert.Contains(t, w.Body.String(), "Records not initialized")
 }
+
+func TestPluginLanguageCodes(t *testing.T) {
+	// 检测结果与请求中的语言使用插件的语言代码
+	assert.Equal(t, "zh-CN", convertBCP47ToImmeLang(utils.NormalizeLanguageCode("zh-CN")))
+	assert.Equal(t, "zh-TW", convertBCP47ToImmeLang("zh-Hant"))
+	assert.Equal(t, "ja", convertBCP47ToImmeLang("ja"))
+	assert.Equal(t, "zh-CN", convertBCP47ToKissLang("zh-Hans"))
+	assert.Equal(t, "en", convertBCP47ToKissLang("en"))
+}
@@ -0,0 +1,463 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/logger"
+	"github.com/xxnuo/MTranServer/internal/middleware"
+	"github.com/xxnuo/MTranServer/internal/models"
+	"github.com/xxnuo/MTranServer/internal/services"
+	"github.com/xxnuo/MTranServer/internal/utils"
+)
+
+// DownloadModelRequest 下载模型请求，省略 from 时下载目标语言的所有语言对
+type DownloadModelRequest struct {
+	From string `json:"from" example:"en"`
+	To   string `json:"to" binding:"required" example:"ja"`
+}
+
+// ModelsResponse 模型状态列表
+type ModelsResponse struct {
+	Models []models.ModelStatus `json:"models"`
+}
+
+// HandleListModels 列出模型
+// @Summary      列出模型
+// @Description  列出所有语言对的模型状态，包括是否已下载、版本、磁盘占用和是否已加载
+// @Tags         管理
+// @Produce      json
+// @Success      200  {object}  ModelsResponse
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Security     ApiKeyQuery
+// @Router       /admin/models [get]
+func HandleListModels(c *gin.Context) {
+	statuses, err := services.ListModels()
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Error("Failed to list models: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, ModelsResponse{Models: statuses})
+}
+
+// DownloadsResponse 下载进度列表
+type DownloadsResponse struct {
+	Downloads []models.DownloadProgress `json:"downloads"`
+}
+
+// HandleModelDownloads 查询下载进度
+// @Summary      查询下载进度
+// @Description  返回各语言对最近一次模型下载的进度，包括每个文件的字节数、总大小和下载速度
+// @Tags         管理
+// @Produce      json
+// @Success      200  {object}  DownloadsResponse
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Security     ApiKeyQuery
+// @Router       /admin/models/downloads [get]
+func HandleModelDownloads(c *gin.Context) {
+	c.JSON(http.StatusOK, DownloadsResponse{Downloads: models.ListProgress()})
+}
+
+// HandleModelEvents 下载进度事件流
+// @Summary      下载进度事件流
+// @Description  以 Server-Sent Events 推送模型下载进度，连接后先推送当前所有下载的进度，事件名为 progress；records.json 刷新后语言对或版本变化时推送 records 事件
+// @Tags         管理
+// @Produce      text/event-stream
+// @Success      200  {object}  models.DownloadProgress
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Security     ApiKeyQuery
+// @Router       /admin/models/events [get]
+func HandleModelEvents(c *gin.Context) {
+	events, cancel := models.SubscribeProgress()
+	defer cancel()
+	diffs, cancelRecords := models.SubscribeRecords()
+	defer cancelRecords()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	for _, p := range models.ListProgress() {
+		c.SSEvent("progress", p)
+	}
+	c.Writer.Flush()
+
+	keepalive := time.NewTicker(15 * time.Second)
+	defer keepalive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case p := <-events:
+			c.SSEvent("progress", p)
+		case d := <-diffs:
+			c.SSEvent("records", d)
+		case <-keepalive.C:
+			// 注释行，防止代理因空闲断开连接
+			io.WriteString(w, ": keepalive\n\n")
+		}
+		return true
+	})
+}
+
+// HandleGetModel 获取模型状态
+// @Summary      获取模型状态
+// @Description  获取指定语言对的模型状态
+// @Tags         管理
+// @Produce      json
+// @Param        from  path      string  true  "源语言"
+// @Param        to    path      string  true  "目标语言"
+// @Success      200   {object}  models.ModelStatus
+// @Failure      401   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Security     ApiKeyQuery
+// @Router       /admin/models/{from}/{to} [get]
+func HandleGetModel(c *gin.Context) {
+	from, to, ok := modelPair(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, services.GetModelStatus(from, to))
+}
+
+// HandleDownloadModel 下载模型
+// @Summary      下载模型
+// @Description  在后台下载语言对的模型，省略 from 时下载目标语言的所有语言对，可通过模型状态查询进度
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      DownloadModelRequest  true  "下载模型请求"
+// @Success      202      {object}  ModelsResponse
+// @Failure      400      {object}  map[string]string
+// @Failure      401      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Security     ApiKeyQuery
+// @Router       /admin/models/download [post]
+func HandleDownloadModel(c *gin.Context) {
+	var req DownloadModelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, err.Error()))
+		return
+	}
+
+	to := utils.NormalizeLanguageCode(req.To)
+	sources := []string{utils.NormalizeLanguageCode(req.From)}
+	if req.From == "" {
+		sources = models.PairsForTarget(to)
+	}
+	if len(sources) == 0 {
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, fmt.Sprintf("no models found for target language %s", to)))
+		return
+	}
+
+	statuses := make([]models.ModelStatus, 0, len(sources))
+	for _, from := range sources {
+		err := models.StartDownload(from, to)
+		if errors.Is(err, models.ErrPairNotSupported) {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, fmt.Sprintf("%s: %s -> %s", err, from, to)))
+			return
+		}
+		// 已在下载中的语言对直接返回当前状态
+		if err != nil && !errors.Is(err, models.ErrDownloadInProcess) {
+			logger.Ctx(c.Request.Context()).Error("Failed to start model download %s -> %s: %v", from, to, err)
+			c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, err.Error()))
+			return
+		}
+		statuses = append(statuses, services.GetModelStatus(from, to))
+	}
+
+	logger.Ctx(c.Request.Context()).Info("Started model download for %d pair(s) into %s", len(statuses), to)
+	c.JSON(http.StatusAccepted, ModelsResponse{Models: statuses})
+}
+
+// HandleDeleteModel 删除模型
+// @Summary      删除模型
+// @Description  删除语言对的模型文件，语言对的 Worker 正在运行或模型正在下载时拒绝删除
+// @Tags         管理
+// @Produce      json
+// @Param        from  path  string  true  "源语言"
+// @Param        to    path  string  true  "目标语言"
+// @Success      204
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Security     ApiKeyQuery
+// @Router       /admin/models/{from}/{to} [delete]
+func HandleDeleteModel(c *gin.Context) {
+	from, to, ok := modelPair(c)
+	if !ok {
+		return
+	}
+
+	err := services.DeleteModel(from, to)
+	switch {
+	case errors.Is(err, services.ErrEngineRunning), errors.Is(err, models.ErrDownloadInProcess), errors.Is(err, models.ErrModelBusy):
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, err.Error()))
+	case errors.Is(err, models.ErrModelNotFound):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, err.Error()))
+	case err != nil:
+		logger.Ctx(c.Request.Context()).Error("Failed to delete model %s -> %s: %v", from, to, err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, err.Error()))
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// HandleExportModels 导出模型包
+// @Summary      导出模型包
+// @Description  将已下载语言对的模型文件和记录打包为 tar.gz，可在离线环境中导入，需要经过英语中转的语言对会一并导出两段模型
+// @Tags         管理
+// @Produce      application/gzip
+// @Param        pairs  query     string  true  "逗号分隔的语言对，如 en_ja,ja_en"
+// @Success      200    {file}    file
+// @Failure      400    {object}  map[string]string
+// @Failure      401    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Security     ApiKeyQuery
+// @Router       /admin/models/export [get]
+func HandleExportModels(c *gin.Context) {
+	var pairs []models.Pair
+	for _, s := range strings.Split(c.Query("pairs"), ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		p, err := models.ParsePair(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, err.Error()))
+			return
+		}
+		pairs = append(pairs, p)
+	}
+	if len(pairs) == 0 {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "pairs is required"))
+		return
+	}
+
+	filename := fmt.Sprintf("mtranserver-models-%s.tar.gz", time.Now().Format("20060102"))
+	c.Header("Content-Type", "application/gzip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	_, err := models.ExportBundle(c.Writer, config.GetConfig().ModelDir, pairs)
+	if err == nil {
+		return
+	}
+	logger.Ctx(c.Request.Context()).Error("Failed to export models: %v", err)
+	if c.Writer.Written() {
+		// 已开始发送文件，只能中断连接
+		c.Abort()
+		return
+	}
+	c.Writer.Header().Del("Content-Type")
+	c.Writer.Header().Del("Content-Disposition")
+	switch {
+	case errors.Is(err, models.ErrPairNotSupported), errors.Is(err, models.ErrModelNotFound):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, err.Error()))
+	}
+}
+
+// HandleImportModels 导入模型包
+// @Summary      导入模型包
+// @Description  校验并导入导出的模型包，所有文件与记录的哈希一致后才写入模型目录，包中的记录会合并到当前记录，离线模式下可直接使用
+// @Tags         管理
+// @Accept       application/gzip
+// @Produce      json
+// @Success      200  {object}  models.BundleManifest
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Security     ApiKeyQuery
+// @Router       /admin/models/import [post]
+func HandleImportModels(c *gin.Context) {
+	manifest, err := models.ImportBundle(c.Request.Body, config.GetConfig().ModelDir)
+	switch {
+	case errors.Is(err, models.ErrInvalidBundle):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, err.Error()))
+	case err != nil:
+		logger.Ctx(c.Request.Context()).Error("Failed to import models: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, err.Error()))
+	default:
+		logger.Ctx(c.Request.Context()).Info("Imported model bundle with %d language pair(s)", len(manifest.Pairs))
+		c.JSON(http.StatusOK, manifest)
+	}
+}
+
+// VerifyModelsRequest 校验模型请求，省略 pairs 时校验所有已下载的语言对
+type VerifyModelsRequest struct {
+	Pairs  []string `json:"pairs" example:"en_ja"`
+	Repair bool     `json:"repair"`
+}
+
+// VerifyModelsResponse 模型校验结果
+type VerifyModelsResponse struct {
+	Models []models.ModelCheck `json:"models"`
+}
+
+// HandleVerifyModels 校验模型
+// @Summary      校验模型
+// @Description  按记录中的大小和哈希校验模型文件，repair 为 true 时将损坏的文件移到模型目录下的 .quarantine 并重新下载，离线模式下需重新导入模型包，Worker 运行中的语言对只校验不修复
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      VerifyModelsRequest  false  "校验模型请求"
+// @Success      200      {object}  VerifyModelsResponse
+// @Failure      400      {object}  map[string]string
+// @Failure      401      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Security     ApiKeyQuery
+// @Router       /admin/models/verify [post]
+func HandleVerifyModels(c *gin.Context) {
+	var req VerifyModelsRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, err.Error()))
+			return
+		}
+	}
+
+	pairs := make([]models.Pair, 0, len(req.Pairs))
+	for _, s := range req.Pairs {
+		p, err := models.ParsePair(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, err.Error()))
+			return
+		}
+		if records := models.GetRecords(); records == nil || !records.HasLanguagePair(p.From, p.To) {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, fmt.Sprintf("%s: %s -> %s", models.ErrPairNotSupported, p.From, p.To)))
+			return
+		}
+		pairs = append(pairs, p)
+	}
+
+	checks, err := services.VerifyModels(pairs, req.Repair)
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Error("Failed to verify models: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, VerifyModelsResponse{Models: checks})
+}
+
+// GCModelsRequest 清理模型请求
+type GCModelsRequest struct {
+	DryRun bool `json:"dry_run"`
+}
+
+// HandleGCModels 清理模型目录
+// @Summary      清理模型目录
+// @Description  删除记录中已不存在的语言对、被新版本取代的文件、下载和解压中断留下的临时文件、隔离的损坏文件，以及超出保留数量和磁盘配额的旧版本，dry_run 为 true 时只返回将要删除的内容
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      GCModelsRequest  false  "清理模型请求"
+// @Success      200      {object}  models.GCResult
+// @Failure      400      {object}  map[string]string
+// @Failure      401      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Security     ApiKeyQuery
+// @Router       /admin/models/gc [post]
+func HandleGCModels(c *gin.Context) {
+	var req GCModelsRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, err.Error()))
+			return
+		}
+	}
+
+	result, err := models.GC(config.GetConfig().ModelDir, req.DryRun)
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Error("Failed to clean up models: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// UpgradeModelsRequest 升级运行中引擎的请求
+type UpgradeModelsRequest struct {
+	// Refresh 升级前重新下载 records.json
+	Refresh bool `json:"refresh"`
+}
+
+// UpgradeModelsResponse 开始升级的引擎
+type UpgradeModelsResponse struct {
+	Upgrades []services.EngineUpgrade `json:"upgrades"`
+}
+
+// HandleUpgradeModels 滚动升级运行中的引擎
+// @Summary      滚动升级运行中的引擎
+// @Description  对模型版本落后于记录中最新版本或配置中固定版本的运行中引擎，在后台将新版本下载到暂存目录，启动新 Worker 并通过健康检查后逐个替换旧 Worker，切换期间请求不会失败
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      UpgradeModelsRequest  false  "升级请求"
+// @Success      202      {object}  UpgradeModelsResponse
+// @Failure      400      {object}  map[string]string
+// @Failure      401      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
+// @Failure      502      {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Security     ApiKeyQuery
+// @Router       /admin/models/upgrade [post]
+func HandleUpgradeModels(c *gin.Context) {
+	var req UpgradeModelsRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, err.Error()))
+			return
+		}
+	}
+
+	if req.Refresh && !config.GetConfig().EnableOfflineMode {
+		if err := models.DownloadRecords(); err != nil {
+			logger.Ctx(c.Request.Context()).Error("Failed to refresh records: %v", err)
+			c.JSON(http.StatusBadGateway, middleware.ErrorBody(c, err.Error()))
+			return
+		}
+	}
+
+	upgrades := services.StartUpgrades()
+	logger.Ctx(c.Request.Context()).Info("Started rolling upgrade for %d engine(s)", len(upgrades))
+	c.JSON(http.StatusAccepted, UpgradeModelsResponse{Upgrades: upgrades})
+}
+
+// modelPair 读取路径中的语言对，不支持时写入 404 响应
+func modelPair(c *gin.Context) (string, string, bool) {
+	from := utils.NormalizeLanguageCode(c.Param("from"))
+	to := utils.NormalizeLanguageCode(c.Param("to"))
+	if records := models.GetRecords(); records == nil || !records.HasLanguagePair(from, to) {
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, fmt.Sprintf("%s: %s -> %s", models.ErrPairNotSupported, from, to)))
+		return "", "", false
+	}
+	return from, to, true
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/models"
+	"github.com/xxnuo/MTranServer/internal/models/modelstest"
+)
+
+func setupModelsTest(t *testing.T) (*gin.Engine, string) {
+	gin.SetMode(gin.TestMode)
+
+	modelDir := t.TempDir()
+	modelstest.UseGlobals(t, &config.Config{ConfigDir: t.TempDir(), ModelDir: modelDir},
+		models.RecordItem{SourceLanguage: "en", TargetLanguage: "ja", Version: "1.0", FileType: "model", Attachment: models.Attachment{Filename: "model.enja.bin.zst"}},
+		models.RecordItem{SourceLanguage: "en", TargetLanguage: "ja", Version: "1.1", FileType: "model", Attachment: models.Attachment{Filename: "model.enja.bin.zst"}},
+		models.RecordItem{SourceLanguage: "zh-Hans", TargetLanguage: "ja", Version: "1.0", FileType: "model", Attachment: models.Attachment{Filename: "model.zhja.bin.zst"}},
+	)
+
+	r := gin.New()
+	r.GET("/admin/models", HandleListModels)
+	r.GET("/admin/models/:from/:to", HandleGetModel)
+	r.DELETE("/admin/models/:from/:to", HandleDeleteModel)
+	r.GET("/admin/models/export", HandleExportModels)
+	r.POST("/admin/models/import", HandleImportModels)
+	r.POST("/admin/models/verify", HandleVerifyModels)
+	r.POST("/admin/models/gc", HandleGCModels)
+	return r, modelDir
+}
+
+func TestHandleListModels(t *testing.T) {
+	r, modelDir := setupModelsTest(t)
+
+	pairDir := filepath.Join(modelDir, "en_ja")
+	require.NoError(t, os.MkdirAll(pairDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(pairDir, "model.enja.bin"), make([]byte, 100), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(pairDir, ".version"), []byte("1.1\n"), 0644))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/models", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp ModelsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Models, 2)
+
+	assert.Equal(t, "en", resp.Models[0].From)
+	assert.Equal(t, "1.1", resp.Models[0].Version)
+	assert.Equal(t, "1.1", resp.Models[0].LatestVersion)
+	assert.Greater(t, resp.Models[0].Size, int64(100))
+	assert.False(t, resp.Models[0].Loaded)
+
+	assert.Equal(t, "zh-Hans", resp.Models[1].From)
+	assert.False(t, resp.Models[1].Downloaded)
+	assert.Zero(t, resp.Models[1].Size)
+}
+
+func TestHandleGetModelUnsupported(t *testing.T) {
+	r, _ := setupModelsTest(t)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/models/en/fr", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandleDeleteModel(t *testing.T) {
+	r, modelDir := setupModelsTest(t)
+
+	pairDir := filepath.Join(modelDir, "zh-Hans_ja")
+	require.NoError(t, os.MkdirAll(pairDir, 0755))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/admin/models/zh-Hans/ja", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.NoDirExists(t, pairDir)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/admin/models/zh-Hans/ja", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandleExportModelsErrors(t *testing.T) {
+	r, _ := setupModelsTest(t)
+
+	tests := []struct {
+		query string
+		code  int
+	}{
+		{"", http.StatusBadRequest},
+		{"?pairs=en-ja", http.StatusBadRequest},
+		{"?pairs=en_fr", http.StatusNotFound},
+		{"?pairs=en_ja", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/admin/models/export"+tt.query, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tt.code, w.Code, tt.query)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json", tt.query)
+		assert.Empty(t, w.Header().Get("Content-Disposition"), tt.query)
+	}
+}
+
+func TestHandleImportModelsInvalid(t *testing.T) {
+	r, modelDir := setupModelsTest(t)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/admin/models/import", strings.NewReader("not a bundle"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	entries, err := os.ReadDir(modelDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestHandleVerifyModels(t *testing.T) {
+	r, modelDir := setupModelsTest(t)
+
+	pairDir := filepath.Join(modelDir, "en_ja")
+	require.NoError(t, os.MkdirAll(pairDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(pairDir, "model.enja.bin"), []byte("model"), 0644))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/admin/models/verify", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp VerifyModelsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Models, 1)
+	assert.Equal(t, "en", resp.Models[0].From)
+	// 缺少 lex 和 vocab 文件
+	assert.False(t, resp.Models[0].Healthy)
+	require.Len(t, resp.Models[0].Files, 1)
+	assert.Equal(t, models.FileOK, resp.Models[0].Files[0].Status)
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"pairs":["en-ja"]}`, http.StatusBadRequest},
+		{`{"pairs":["en_fr"]}`, http.StatusNotFound},
+		{`{"pairs":["zh-Hans_ja"]}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/admin/models/verify", strings.NewReader(tt.body))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tt.code, w.Code, tt.body)
+	}
+}
+
+func TestHandleGCModels(t *testing.T) {
+	r, modelDir := setupModelsTest(t)
+
+	orphan := filepath.Join(modelDir, "fr_de")
+	require.NoError(t, os.MkdirAll(orphan, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(orphan, "model.frde.bin"), []byte("model"), 0644))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/admin/models/gc", strings.NewReader(`{"dry_run":true}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp models.GCResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.DryRun)
+	assert.Equal(t, []string{"fr_de"}, resp.Removed)
+	assert.Equal(t, int64(5), resp.FreedBytes)
+	assert.DirExists(t, orphan)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/admin/models/gc", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoDirExists(t, orphan)
+}
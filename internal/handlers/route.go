@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/middleware"
+	"github.com/xxnuo/MTranServer/internal/models"
+	"github.com/xxnuo/MTranServer/internal/services"
+	"github.com/xxnuo/MTranServer/internal/utils"
+)
+
+// RoutesResponse 翻译路径配置和使用统计
+type RoutesResponse struct {
+	PivotLanguage string `json:"pivot_language" example:"en"`
+	MaxHops       int    `json:"max_hops" example:"2"`
+	// Route 查询的 from 到 to 的翻译路径
+	Route []string              `json:"route,omitempty" example:"ja,en,ko"`
+	Stats []services.RouteStats `json:"stats"`
+}
+
+// HandleRoutes 查看翻译路径
+// @Summary      查看翻译路径
+// @Description  返回中转语言、最大跳数和启动以来各翻译路径的使用统计，指定 from 和 to 时同时返回两者之间的翻译路径
+// @Tags         管理
+// @Produce      json
+// @Param        from  query     string  false  "源语言"
+// @Param        to    query     string  false  "目标语言"
+// @Success      200   {object}  RoutesResponse
+// @Failure      401   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Security     ApiKeyQuery
+// @Router       /admin/routes [get]
+func HandleRoutes(c *gin.Context) {
+	cfg := config.GetConfig()
+	resp := RoutesResponse{
+		PivotLanguage: cfg.PivotLanguage,
+		MaxHops:       cfg.PivotHops(),
+		Stats:         services.ListRouteStats(),
+	}
+
+	from := utils.NormalizeLanguageCode(c.Query("from"))
+	to := utils.NormalizeLanguageCode(c.Query("to"))
+	if from != "" && to != "" {
+		route, err := services.FindRoute(from, to)
+		if errors.Is(err, models.ErrNoRoute) {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, err.Error()))
+			return
+		}
+		resp.Route = route
+	}
+	c.JSON(http.StatusOK, resp)
+}
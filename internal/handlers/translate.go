@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/xxnuo/MTranServer/internal/logger"
+	"github.com/xxnuo/MTranServer/internal/middleware"
 	"github.com/xxnuo/MTranServer/internal/services"
 	"github.com/xxnuo/MTranServer/internal/utils"
 )
@@ -18,11 +20,19 @@ type TranslateRequest struct {
 	To   string `json:"to" binding:"required" example:"zh-Hans"`
 	Text string `json:"text" binding:"required" example:"Hello, world!"`
 	HTML bool   `json:"html" example:"false"`
+	// Hints 源语言为 auto 时的检测提示
+	Hints *services.DetectHints `json:"hints,omitempty"`
 }
 
 // TranslateResponse 翻译响应
 type TranslateResponse struct {
 	Result string `json:"result" example:"你好，世界！"`
+	// DetectedLanguage 实际使用的源语言，多语言文本为占比最大的语言
+	DetectedLanguage string `json:"detected_language" example:"ja"`
+	// Confidence 源语言由检测得到时的置信度
+	Confidence float64 `json:"confidence,omitempty" example:"0.93"`
+	// Route 翻译路径，经过中转时包含中转语言，无需翻译时为空
+	Route []string `json:"route,omitempty" example:"ja,en,zh-Hans"`
 }
 
 // handleTranslate 单文本翻译
@@ -34,7 +44,10 @@ type TranslateResponse struct {
 // @Param        request  body      TranslateRequest  true  "翻译请求"
 // @Success      200      {object}  TranslateResponse
 // @Failure      400      {object}  map[string]string
+// @Failure      413      {object}  map[string]string
+// @Failure      429      {object}  map[string]string
 // @Failure      500      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
 // @Security     ApiKeyAuth
 // @Security     ApiKeyQuery
 // @Router       /translate [post]
@@ -42,31 +55,41 @@ func HandleTranslate(c *gin.Context) {
 	var req TranslateRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, err.Error()))
 		return
 	}
 
 	req.From = utils.NormalizeLanguageCode(req.From)
 	req.To = utils.NormalizeLanguageCode(req.To)
 
-	logger.Debug("Translation request: %s -> %s, text length: %d", req.From, req.To, len(req.Text))
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	if !authorizeTranslation(c, req.From, req.To, req.Text) {
+		return
+	}
+
+	logger.Ctx(c.Request.Context()).Debug("Translation request: %s -> %s, text length: %d", req.From, req.To, len(req.Text))
+	ctx, cancel := context.WithTimeout(translateContext(c, req.Hints), 60*time.Second)
 	defer cancel()
 
 	result, err := services.TranslateWithPivot(ctx, req.From, req.To, req.Text, req.HTML)
 	if err != nil {
-		logger.Error("Translation failed (%s -> %s): %v", req.From, req.To, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Translation failed: %v", err),
-		})
+		if modelLoading(c, err) {
+			return
+		}
+		if pairDenied(c, err) {
+			return
+		}
+		refundQuota(c)
+		logger.Ctx(c.Request.Context()).Error("Translation failed (%s -> %s): %v", req.From, req.To, err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, fmt.Sprintf("Translation failed: %v", err)))
 		return
 	}
 
-	logger.Debug("Translation completed: %s -> %s", req.From, req.To)
-	c.JSON(http.StatusOK, gin.H{
-		"result": result,
+	logger.Ctx(c.Request.Context()).Debug("Translation completed: %s -> %s in %s", result.DetectedLanguage, req.To, result.Duration)
+	c.JSON(http.StatusOK, TranslateResponse{
+		Result:           result.Text,
+		DetectedLanguage: result.DetectedLanguage,
+		Confidence:       result.Confidence,
+		Route:            result.Route,
 	})
 }
 
@@ -75,10 +98,16 @@ type TranslateBatchRequest struct {
 	To    string   `json:"to" binding:"required" example:"zh-Hans"`
 	Texts []string `json:"texts" binding:"required" example:"Hello, world!,Good morning!"`
 	HTML  bool     `json:"html" example:"false"`
+	// Hints 源语言为 auto 时的检测提示
+	Hints *services.DetectHints `json:"hints,omitempty"`
 }
 
 type TranslateBatchResponse struct {
 	Results []string `json:"results" example:"你好，世界！,早上好！"`
+	// DetectedLanguages 每个文本实际使用的源语言
+	DetectedLanguages []string `json:"detected_languages" example:"en,en"`
+	// Route 所有文本使用相同翻译路径时的路径，各文本路径不同或无需翻译时为空
+	Route []string `json:"route,omitempty" example:"ja,en,zh-Hans"`
 }
 
 // handleTranslateBatch 批量翻译
@@ -90,7 +119,10 @@ type TranslateBatchResponse struct {
 // @Param        request  body      TranslateBatchRequest  true  "批量翻译请求"
 // @Success      200      {object}  TranslateBatchResponse
 // @Failure      400      {object}  map[string]string
+// @Failure      413      {object}  map[string]string
+// @Failure      429      {object}  map[string]string
 // @Failure      500      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
 // @Security     ApiKeyAuth
 // @Security     ApiKeyQuery
 // @Router       /translate/batch [post]
@@ -98,34 +130,60 @@ func HandleTranslateBatch(c *gin.Context) {
 	var req TranslateBatchRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, err.Error()))
 		return
 	}
 
 	req.From = utils.NormalizeLanguageCode(req.From)
 	req.To = utils.NormalizeLanguageCode(req.To)
 
-	logger.Debug("Batch translation request: %s -> %s, count: %d", req.From, req.To, len(req.Texts))
+	if !authorizeTranslation(c, req.From, req.To, req.Texts...) {
+		return
+	}
+
+	logger.Ctx(c.Request.Context()).Debug("Batch translation request: %s -> %s, count: %d", req.From, req.To, len(req.Texts))
 	results := make([]string, len(req.Texts))
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 120*time.Second)
+	detected := make([]string, len(req.Texts))
+	var routes [][]string
+	ctx, cancel := context.WithTimeout(translateContext(c, req.Hints), 120*time.Second)
 	defer cancel()
 
 	for i, text := range req.Texts {
 		result, err := services.TranslateWithPivot(ctx, req.From, req.To, text, req.HTML)
 		if err != nil {
-			logger.Error("Batch translation failed at index %d (%s -> %s): %v", i, req.From, req.To, err)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": fmt.Sprintf("Translation failed at index %d: %v", i, err),
-			})
+			if modelLoading(c, err) {
+				return
+			}
+			if pairDenied(c, err) {
+				return
+			}
+			refundQuota(c)
+			logger.Ctx(c.Request.Context()).Error("Batch translation failed at index %d (%s -> %s): %v", i, req.From, req.To, err)
+			c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, fmt.Sprintf("Translation failed at index %d: %v", i, err)))
 			return
 		}
-		results[i] = result
+		results[i] = result.Text
+		detected[i] = result.DetectedLanguage
+		routes = append(routes, result.Route)
 	}
 
-	logger.Debug("Batch translation completed: %s -> %s, count: %d", req.From, req.To, len(req.Texts))
-	c.JSON(http.StatusOK, gin.H{
-		"results": results,
+	logger.Ctx(c.Request.Context()).Debug("Batch translation completed: %s -> %s, count: %d", req.From, req.To, len(req.Texts))
+	c.JSON(http.StatusOK, TranslateBatchResponse{
+		Results:           results,
+		DetectedLanguages: detected,
+		Route:             commonRoute(routes),
 	})
 }
+
+// commonRoute 返回所有文本共同的翻译路径，不完全相同时返回 nil
+func commonRoute(routes [][]string) []string {
+	if len(routes) == 0 {
+		return nil
+	}
+	for _, route := range routes[1:] {
+		if !slices.Equal(route, routes[0]) {
+			return nil
+		}
+	}
+	return routes[0]
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/models"
+	"github.com/xxnuo/MTranServer/internal/models/modelstest"
+	"github.com/xxnuo/MTranServer/internal/services"
+)
+
+func TestTranslateIgnoresTargetLocale(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 模型下载立即失败，翻译返回 en -> de 模型加载中，说明文本没有被当作德语原样返回
+	mirror := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(mirror.Close)
+	modelstest.UseGlobals(t, &config.Config{
+		ConfigDir:    t.TempDir(),
+		ModelDir:     t.TempDir(),
+		ModelLoading: "reject",
+		ModelMirrors: mirror.URL,
+		MaxPivotHops: 2,
+	},
+		models.RecordItem{SourceLanguage: "en", TargetLanguage: "de", Version: "1.0", FileType: "model", Attachment: models.Attachment{Filename: "model.ende.bin.zst", Location: "model.ende.bin.zst"}},
+		models.RecordItem{SourceLanguage: "de", TargetLanguage: "en", Version: "1.0", FileType: "model", Attachment: models.Attachment{Filename: "model.deen.bin.zst", Location: "model.deen.bin.zst"}},
+	)
+	t.Cleanup(func() {
+		assert.Eventually(t, func() bool { return !models.IsDownloading("en", "de") }, 5*time.Second, 10*time.Millisecond)
+	})
+	services.RebuildDetector()
+
+	r := gin.New()
+	r.POST("/translate", HandleTranslate)
+
+	bodies := []string{
+		`{"from":"auto","to":"de","text":"Gift"}`,
+		`{"from":"auto","to":"de","text":"Gift","hints":{"locale":"de-DE"}}`,
+	}
+	for _, body := range bodies {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/translate", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", "de-DE,de;q=0.9")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, body)
+		var resp map[string]string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Contains(t, resp["error"], "model for en -> de is loading", body)
+	}
+}
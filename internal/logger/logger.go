@@ -1,10 +1,17 @@
 package logger
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 type LogLevel int
@@ -16,6 +23,13 @@ const (
 	ERROR
 )
 
+type Format int
+
+const (
+	FormatText Format = iota
+	FormatJSON
+)
+
 const (
 	colorReset  = "\033[0m"
 	colorCyan   = "\033[36m"
@@ -24,22 +38,154 @@ const (
 	colorRed    = "\033[31m"
 )
 
+// 常用结构化字段名
+const (
+	FieldRequestID  = "request_id"
+	FieldPair       = "pair"
+	FieldWorkerPort = "worker_port"
+	FieldTokenID    = "token_id"
+)
+
+const textTimeLayout = "2006/01/02 15:04:05"
+
+type Fields map[string]interface{}
+
+// Options 日志输出配置
+type Options struct {
+	Level  string
+	Format string
+
+	// File 不为空时日志写入该文件，不再输出到控制台
+	File string
+	// MaxSizeMB 单个日志文件最大大小（MB），0 表示不按大小轮转
+	MaxSizeMB int
+	// RotateInterval 按时间轮转的周期，0 表示不按时间轮转
+	RotateInterval time.Duration
+	// MaxBackups 最多保留的历史日志文件数，0 表示不限制
+	MaxBackups int
+	// MaxAge 历史日志文件最长保留时间，0 表示不限制
+	MaxAge time.Duration
+}
+
+type output struct {
+	w     io.Writer
+	color bool
+}
+
 var (
+	mu           sync.Mutex
 	currentLevel LogLevel = INFO
-	debugLogger  *log.Logger
-	infoLogger   *log.Logger
-	warnLogger   *log.Logger
-	errorLogger  *log.Logger
+	format       Format   = FormatText
+	stdOutput    output
+	errOutput    output
+	logFile      io.Closer
 )
 
 func init() {
-	debugLogger = log.New(os.Stdout, colorCyan+"[DEBUG]"+colorReset+" ", log.Ldate|log.Ltime|log.Lshortfile)
-	infoLogger = log.New(os.Stdout, colorGreen+"[INFO]"+colorReset+" ", log.Ldate|log.Ltime)
-	warnLogger = log.New(os.Stdout, colorYellow+"[WARN]"+colorReset+" ", log.Ldate|log.Ltime)
-	errorLogger = log.New(os.Stderr, colorRed+"[ERROR]"+colorReset+" ", log.Ldate|log.Ltime|log.Lshortfile)
+	stdOutput = newOutput(os.Stdout)
+	errOutput = newOutput(os.Stderr)
+}
+
+func newOutput(w io.Writer) output {
+	return output{w: w, color: isTerminal(w)}
+}
+
+// isTerminal 判断输出是否为终端，非终端时不输出颜色控制码
+func isTerminal(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Configure 按配置设置日志级别、格式与输出目标
+func Configure(opts Options) error {
+	SetLevel(opts.Level)
+	if err := SetFormat(opts.Format); err != nil {
+		return err
+	}
+
+	if opts.File == "" {
+		SetOutput(os.Stdout, os.Stderr)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(opts.File), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	w, err := NewRotatingWriter(RotateOptions{
+		Filename:   opts.File,
+		MaxSizeMB:  opts.MaxSizeMB,
+		Interval:   opts.RotateInterval,
+		MaxBackups: opts.MaxBackups,
+		MaxAge:     opts.MaxAge,
+	})
+	if err != nil {
+		return err
+	}
+
+	SetOutput(w, w)
+
+	mu.Lock()
+	logFile = w
+	mu.Unlock()
+	return nil
+}
+
+// SetOutput 设置普通日志与错误日志的输出目标
+func SetOutput(stdout, stderr io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if logFile != nil {
+		logFile.Close()
+		logFile = nil
+	}
+	stdOutput = newOutput(stdout)
+	errOutput = newOutput(stderr)
+}
+
+// Close 关闭日志文件
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if logFile == nil {
+		return nil
+	}
+	err := logFile.Close()
+	logFile = nil
+	return err
+}
+
+func SetFormat(f string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	switch strings.ToLower(f) {
+	case "", "text":
+		format = FormatText
+	case "json":
+		format = FormatJSON
+	default:
+		return fmt.Errorf("unknown log format: %s", f)
+	}
+	return nil
 }
 
 func SetLevel(level string) {
+	mu.Lock()
+	defer mu.Unlock()
+
 	switch strings.ToLower(level) {
 	case "debug":
 		currentLevel = DEBUG
@@ -55,7 +201,15 @@ func SetLevel(level string) {
 }
 
 func GetLevel() string {
-	switch currentLevel {
+	mu.Lock()
+	level := currentLevel
+	mu.Unlock()
+
+	return level.String()
+}
+
+func (l LogLevel) String() string {
+	switch l {
 	case DEBUG:
 		return "debug"
 	case INFO:
@@ -69,32 +223,222 @@ func GetLevel() string {
 	}
 }
 
+func (l LogLevel) label() (string, string) {
+	switch l {
+	case DEBUG:
+		return "[DEBUG]", colorCyan
+	case WARN:
+		return "[WARN]", colorYellow
+	case ERROR:
+		return "[ERROR]", colorRed
+	default:
+		return "[INFO]", colorGreen
+	}
+}
+
+func enabled(level LogLevel) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return currentLevel <= level
+}
+
+func write(level LogLevel, skip int, fields Fields, msg string) {
+	now := time.Now()
+
+	var caller string
+	if level == DEBUG || level == ERROR {
+		if _, file, line, ok := runtime.Caller(skip); ok {
+			caller = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	out := stdOutput
+	if level == ERROR {
+		out = errOutput
+	}
+
+	var line []byte
+	if format == FormatJSON {
+		line = formatJSON(now, level, caller, fields, msg)
+	} else {
+		line = formatText(now, level, caller, fields, msg, out.color)
+	}
+	out.w.Write(line)
+}
+
+func formatText(now time.Time, level LogLevel, caller string, fields Fields, msg string, color bool) []byte {
+	var b strings.Builder
+
+	label, c := level.label()
+	if color {
+		b.WriteString(c + label + colorReset)
+	} else {
+		b.WriteString(label)
+	}
+	b.WriteByte(' ')
+	b.WriteString(now.Format(textTimeLayout))
+	b.WriteByte(' ')
+	if caller != "" {
+		b.WriteString(caller)
+		b.WriteString(": ")
+	}
+	b.WriteString(msg)
+
+	for _, k := range sortedKeys(fields) {
+		fmt.Fprintf(&b, " %s=%v", k, fields[k])
+	}
+
+	if !strings.HasSuffix(msg, "\n") {
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
+
+func formatJSON(now time.Time, level LogLevel, caller string, fields Fields, msg string) []byte {
+	entry := make(map[string]interface{}, len(fields)+4)
+	for k, v := range fields {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		entry[k] = v
+	}
+	entry["time"] = now.Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	entry["msg"] = strings.TrimSuffix(msg, "\n")
+	if caller != "" {
+		entry["caller"] = caller
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		data, _ = json.Marshal(map[string]interface{}{
+			"time":  now.Format(time.RFC3339Nano),
+			"level": level.String(),
+			"msg":   entry["msg"],
+			"error": fmt.Sprintf("failed to marshal log fields: %v", err),
+		})
+	}
+	return append(data, '\n')
+}
+
+func sortedKeys(fields Fields) []string {
+	if len(fields) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Entry 携带结构化字段的日志记录器
+type Entry struct {
+	fields Fields
+}
+
+// With 创建带结构化字段的日志记录器
+func With(fields Fields) *Entry {
+	return (&Entry{}).With(fields)
+}
+
+type requestIDKey struct{}
+
+// ContextWithRequestID 将请求 ID 写入 context
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext 从 context 中读取请求 ID
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Ctx 创建带有 context 中请求 ID 的日志记录器
+func Ctx(ctx context.Context) *Entry {
+	return (&Entry{}).WithContext(ctx)
+}
+
+// WithContext 追加 context 中的请求 ID 字段
+func (e *Entry) WithContext(ctx context.Context) *Entry {
+	id := RequestIDFromContext(ctx)
+	if id == "" {
+		return e
+	}
+	return e.With(Fields{FieldRequestID: id})
+}
+
+// With 在当前字段基础上追加字段，返回新的记录器
+func (e *Entry) With(fields Fields) *Entry {
+	merged := make(Fields, len(e.fields)+len(fields))
+	for k, v := range e.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &Entry{fields: merged}
+}
+
+func (e *Entry) Debug(format string, v ...interface{}) {
+	if enabled(DEBUG) {
+		write(DEBUG, 2, e.fields, fmt.Sprintf(format, v...))
+	}
+}
+
+func (e *Entry) Info(format string, v ...interface{}) {
+	if enabled(INFO) {
+		write(INFO, 2, e.fields, fmt.Sprintf(format, v...))
+	}
+}
+
+func (e *Entry) Warn(format string, v ...interface{}) {
+	if enabled(WARN) {
+		write(WARN, 2, e.fields, fmt.Sprintf(format, v...))
+	}
+}
+
+func (e *Entry) Error(format string, v ...interface{}) {
+	if enabled(ERROR) {
+		write(ERROR, 2, e.fields, fmt.Sprintf(format, v...))
+	}
+}
+
 func Debug(format string, v ...interface{}) {
-	if currentLevel <= DEBUG {
-		debugLogger.Output(2, fmt.Sprintf(format, v...))
+	if enabled(DEBUG) {
+		write(DEBUG, 2, nil, fmt.Sprintf(format, v...))
 	}
 }
 
 func Info(format string, v ...interface{}) {
-	if currentLevel <= INFO {
-		infoLogger.Output(2, fmt.Sprintf(format, v...))
+	if enabled(INFO) {
+		write(INFO, 2, nil, fmt.Sprintf(format, v...))
 	}
 }
 
 func Warn(format string, v ...interface{}) {
-	if currentLevel <= WARN {
-		warnLogger.Output(2, fmt.Sprintf(format, v...))
+	if enabled(WARN) {
+		write(WARN, 2, nil, fmt.Sprintf(format, v...))
 	}
 }
 
 func Error(format string, v ...interface{}) {
-	if currentLevel <= ERROR {
-		errorLogger.Output(2, fmt.Sprintf(format, v...))
+	if enabled(ERROR) {
+		write(ERROR, 2, nil, fmt.Sprintf(format, v...))
 	}
 }
 
 func Fatal(format string, v ...interface{}) {
-	errorLogger.Output(2, fmt.Sprintf(format, v...))
+	write(ERROR, 2, nil, fmt.Sprintf(format, v...))
+	Close()
 	os.Exit(1)
 }
 
@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func captureOutput(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	SetOutput(&buf, &buf)
+	t.Cleanup(func() {
+		SetOutput(os.Stdout, os.Stderr)
+		SetFormat("text")
+		SetLevel("info")
+	})
+	return &buf
+}
+
+func TestTextFormatWithoutColorWhenNotTTY(t *testing.T) {
+	buf := captureOutput(t)
+	SetLevel("info")
+
+	Info("hello %s", "world")
+
+	line := buf.String()
+	assert.True(t, strings.HasPrefix(line, "[INFO] "), line)
+	assert.Contains(t, line, "hello world")
+	assert.NotContains(t, line, "\033[")
+}
+
+func TestJSONFormatWithFields(t *testing.T) {
+	buf := captureOutput(t)
+	SetLevel("debug")
+	require.NoError(t, SetFormat("json"))
+
+	With(Fields{FieldRequestID: "req-1", FieldPair: "en-ja"}).
+		With(Fields{FieldWorkerPort: 9000}).
+		Warn("worker %s", "slow")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "warn", entry["level"])
+	assert.Equal(t, "worker slow", entry["msg"])
+	assert.Equal(t, "req-1", entry[FieldRequestID])
+	assert.Equal(t, "en-ja", entry[FieldPair])
+	assert.Equal(t, float64(9000), entry[FieldWorkerPort])
+	assert.NotEmpty(t, entry["time"])
+}
+
+func TestCtxAddsRequestID(t *testing.T) {
+	buf := captureOutput(t)
+	require.NoError(t, SetFormat("json"))
+
+	ctx := ContextWithRequestID(context.Background(), "req-7")
+	Ctx(ctx).With(Fields{FieldPair: "en-de"}).Info("translating")
+	Ctx(context.Background()).Info("no id")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var first, second map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, "req-7", first[FieldRequestID])
+	assert.Equal(t, "en-de", first[FieldPair])
+	assert.NotContains(t, second, FieldRequestID)
+}
+
+func TestLevelFiltering(t *testing.T) {
+	buf := captureOutput(t)
+	SetLevel("warn")
+
+	Debug("debug")
+	Info("info")
+	Warn("warn")
+
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+	assert.Equal(t, "warn", GetLevel())
+}
+
+func TestSetFormatRejectsUnknown(t *testing.T) {
+	assert.Error(t, SetFormat("xml"))
+}
+
+func TestRotatingWriterBySize(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "server.log")
+
+	w, err := NewRotatingWriter(RotateOptions{
+		Filename:   filename,
+		MaxSizeMB:  1,
+		MaxBackups: 2,
+	})
+	require.NoError(t, err)
+	defer w.Close()
+
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.now = func() time.Time {
+		current = current.Add(time.Second)
+		return current
+	}
+
+	chunk := bytes.Repeat([]byte("x"), 600*1024)
+	for i := 0; i < 5; i++ {
+		_, err := w.Write(chunk)
+		require.NoError(t, err)
+	}
+
+	backups := w.backups()
+	assert.Len(t, backups, 2)
+
+	info, err := os.Stat(filename)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(chunk)), info.Size())
+}
+
+func TestRotatingWriterByInterval(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "server.log")
+
+	current := time.Date(2025, 1, 1, 23, 59, 0, 0, time.UTC)
+	w, err := NewRotatingWriter(RotateOptions{
+		Filename: filename,
+		Interval: 24 * time.Hour,
+	})
+	require.NoError(t, err)
+	defer w.Close()
+	w.now = func() time.Time { return current }
+	w.openedAt = current
+
+	_, err = w.Write([]byte("day one\n"))
+	require.NoError(t, err)
+	assert.Empty(t, w.backups())
+
+	current = current.Add(2 * time.Minute)
+	_, err = w.Write([]byte("day two\n"))
+	require.NoError(t, err)
+
+	backups := w.backups()
+	require.Len(t, backups, 1)
+
+	old, err := os.ReadFile(backups[0])
+	require.NoError(t, err)
+	assert.Equal(t, "day one\n", string(old))
+
+	latest, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, "day two\n", string(latest))
+}
@@ -0,0 +1,184 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const backupTimeLayout = "20060102T150405.000"
+
+type RotateOptions struct {
+	Filename   string
+	MaxSizeMB  int
+	Interval   time.Duration
+	MaxBackups int
+	MaxAge     time.Duration
+}
+
+// RotatingWriter 按大小或时间轮转的日志文件
+type RotatingWriter struct {
+	opts     RotateOptions
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	now      func() time.Time
+}
+
+func NewRotatingWriter(opts RotateOptions) (*RotatingWriter, error) {
+	if opts.Filename == "" {
+		return nil, fmt.Errorf("log filename cannot be empty")
+	}
+
+	w := &RotatingWriter{
+		opts: opts,
+		now:  time.Now,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to rotate log file: %v\n", err)
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *RotatingWriter) open() error {
+	f, err := os.OpenFile(w.opts.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	w.file = f
+	w.size = info.Size()
+	w.openedAt = w.now()
+	if w.size > 0 {
+		w.openedAt = info.ModTime()
+	}
+	return nil
+}
+
+func (w *RotatingWriter) shouldRotate(n int64) bool {
+	if w.opts.MaxSizeMB > 0 && w.size > 0 && w.size+n > int64(w.opts.MaxSizeMB)*1024*1024 {
+		return true
+	}
+	if w.opts.Interval > 0 {
+		now := w.now()
+		return !now.Truncate(w.opts.Interval).Equal(w.openedAt.Truncate(w.opts.Interval))
+	}
+	return false
+}
+
+func (w *RotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+
+	if err := os.Rename(w.opts.Filename, w.backupName(w.now())); err != nil && !os.IsNotExist(err) {
+		if openErr := w.open(); openErr != nil {
+			return openErr
+		}
+		return fmt.Errorf("failed to rename log file: %w", err)
+	}
+
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	w.removeOldBackups()
+	return nil
+}
+
+// backupName 生成历史文件名，如 mtranserver-20250101T120000.000.log
+func (w *RotatingWriter) backupName(t time.Time) string {
+	dir := filepath.Dir(w.opts.Filename)
+	ext := filepath.Ext(w.opts.Filename)
+	base := strings.TrimSuffix(filepath.Base(w.opts.Filename), ext)
+	return filepath.Join(dir, fmt.Sprintf("%s-%s%s", base, t.Format(backupTimeLayout), ext))
+}
+
+func (w *RotatingWriter) backups() []string {
+	dir := filepath.Dir(w.opts.Filename)
+	ext := filepath.Ext(w.opts.Filename)
+	base := strings.TrimSuffix(filepath.Base(w.opts.Filename), ext)
+
+	matches, err := filepath.Glob(filepath.Join(dir, base+"-*"+ext))
+	if err != nil {
+		return nil
+	}
+
+	backups := make([]string, 0, len(matches))
+	prefix := base + "-"
+	for _, m := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(m), prefix), ext)
+		if _, err := time.Parse(backupTimeLayout, stamp); err == nil {
+			backups = append(backups, m)
+		}
+	}
+	sort.Strings(backups)
+	return backups
+}
+
+func (w *RotatingWriter) removeOldBackups() {
+	backups := w.backups()
+
+	var remove []string
+	if w.opts.MaxBackups > 0 && len(backups) > w.opts.MaxBackups {
+		remove = append(remove, backups[:len(backups)-w.opts.MaxBackups]...)
+		backups = backups[len(backups)-w.opts.MaxBackups:]
+	}
+
+	if w.opts.MaxAge > 0 {
+		cutoff := w.now().Add(-w.opts.MaxAge)
+		for _, b := range backups {
+			if info, err := os.Stat(b); err == nil && info.ModTime().Before(cutoff) {
+				remove = append(remove, b)
+			}
+		}
+	}
+
+	for _, b := range remove {
+		if err := os.Remove(b); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Failed to remove old log file %s: %v\n", b, err)
+		}
+	}
+}
@@ -9,6 +9,8 @@ import (
 
 	"github.com/gorilla/websocket"
 	"github.com/xxnuo/MTranServer/internal/logger"
+	"github.com/xxnuo/MTranServer/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type WSMessage struct {
@@ -129,7 +131,22 @@ func (c *Client) IsConnected() bool {
 	return c.connected
 }
 
-func (c *Client) sendRequest(ctx context.Context, msgType string, data interface{}) (*WSResponse, error) {
+func (c *Client) sendRequest(ctx context.Context, msgType string, data interface{}) (resp *WSResponse, err error) {
+	ctx, span := tracing.Start(ctx, "manager.Client.sendRequest",
+		attribute.String("ws.message_type", msgType),
+		attribute.String("worker.url", c.url),
+	)
+	if id := logger.RequestIDFromContext(ctx); id != "" {
+		span.SetAttributes(attribute.String("request.id", id))
+	}
+	defer func() {
+		if resp != nil {
+			span.SetAttributes(attribute.Int("ws.response_code", resp.Code))
+		}
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
 	dataBytes, err := json.Marshal(data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal data: %w", err)
@@ -200,27 +217,27 @@ func (c *Client) Health(ctx context.Context) (bool, error) {
 }
 
 func (c *Client) Trans(ctx context.Context, req TransRequest) (string, error) {
-	logger.Debug("Client.Trans: sending request, text length: %d, isHTML: %v, text: %q", len(req.Text), req.HTML, req.Text)
+	logger.Ctx(ctx).Debug("Client.Trans: sending request, text length: %d, isHTML: %v, text: %q", len(req.Text), req.HTML, req.Text)
 	resp, err := c.sendRequest(ctx, "trans", req)
 	if err != nil {
-		logger.Debug("Client.Trans: sendRequest error: %v", err)
+		logger.Ctx(ctx).Debug("Client.Trans: sendRequest error: %v", err)
 		return "", err
 	}
 
 	if resp.Code != 200 {
-		logger.Debug("Client.Trans: response code %d: %s", resp.Code, resp.Msg)
+		logger.Ctx(ctx).Debug("Client.Trans: response code %d: %s", resp.Code, resp.Msg)
 		return "", fmt.Errorf("trans failed (code %d): %s", resp.Code, resp.Msg)
 	}
 
 	var result TransResponse
 	if resp.Data != nil {
 		if err := json.Unmarshal(resp.Data, &result); err != nil {
-			logger.Debug("Client.Trans: unmarshal error: %v", err)
+			logger.Ctx(ctx).Debug("Client.Trans: unmarshal error: %v", err)
 			return "", fmt.Errorf("failed to unmarshal response: %w", err)
 		}
 	}
 
-	logger.Debug("Client.Trans: success, result length: %d", len(result.TranslatedText))
+	logger.Ctx(ctx).Debug("Client.Trans: success, result length: %d", len(result.TranslatedText))
 	return result.TranslatedText, nil
 }
 
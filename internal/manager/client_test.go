@@ -12,6 +12,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/xxnuo/MTranServer/internal/manager"
+	"github.com/xxnuo/MTranServer/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 var upgrader = websocket.Upgrader{
@@ -218,6 +223,37 @@ func TestClient_Trans(t *testing.T) {
 	assert.Equal(t, "translated: Hello", result)
 }
 
+func TestClient_TransTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := tracing.NewProvider(sdktrace.NewSimpleSpanProcessor(exporter))
+	oldProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(oldProvider)
+
+	server := mockWSServer(t, handleTrans)
+	defer server.Close()
+
+	wsURL := "ws" + server.URL[4:]
+
+	client := manager.NewClient(wsURL)
+	defer client.Close()
+
+	err := client.Connect()
+	require.NoError(t, err)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	_, err = client.Trans(ctx, manager.TransRequest{Text: "Hello"})
+	parent.End()
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "manager.Client.sendRequest", spans[0].Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent.SpanID())
+	assert.Contains(t, spans[0].Attributes, attribute.String("ws.message_type", "trans"))
+	assert.Contains(t, spans[0].Attributes, attribute.Int("ws.response_code", 200))
+}
+
 func TestClient_Trans_EmptyText(t *testing.T) {
 	server := mockWSServer(t, handleTrans)
 	defer server.Close()
@@ -69,6 +69,7 @@ type Worker struct {
 	wg         sync.WaitGroup
 	running    bool
 	pid        int
+	log        *logger.Entry
 }
 
 func NewWorker(args *WorkerArgs) *Worker {
@@ -93,6 +94,7 @@ func NewWorker(args *WorkerArgs) *Worker {
 		done:       make(chan struct{}),
 		running:    false,
 		pid:        0,
+		log:        logger.With(logger.Fields{logger.FieldWorkerPort: args.Port}),
 	}
 
 	return w
@@ -197,7 +199,7 @@ func (w *Worker) Start() error {
 
 	args := w.buildArgs()
 
-	logger.Debug("Starting worker %s on port %d", w.id, w.args.Port)
+	w.log.Debug("Starting worker %s on port %d", w.id, w.args.Port)
 
 	cmd := exec.Command(w.binaryPath, args...)
 	cmd.Dir = w.args.WorkDir
@@ -224,7 +226,7 @@ func (w *Worker) Start() error {
 	go w.collectLogs(stderrPipe, "ERROR")
 	go w.monitorProcess()
 
-	logger.Debug("Worker %s started with PID %d", w.id, w.pid)
+	w.log.Debug("Worker %s started with PID %d", w.id, w.pid)
 	return nil
 }
 
@@ -240,9 +242,9 @@ func (w *Worker) monitorProcess() {
 		w.running = false
 		w.pid = 0
 		if err != nil {
-			logger.Warn("Worker %s process exited unexpectedly: %v", w.id, err)
+			w.log.Warn("Worker %s process exited unexpectedly: %v", w.id, err)
 		} else {
-			logger.Info("Worker %s process exited normally", w.id)
+			w.log.Info("Worker %s process exited normally", w.id)
 		}
 	}
 }
@@ -255,10 +257,10 @@ func (w *Worker) Stop() error {
 		return fmt.Errorf("worker not running")
 	}
 
-	logger.Debug("Stopping worker %s", w.id)
+	w.log.Debug("Stopping worker %s", w.id)
 
 	if err := w.cmd.Process.Signal(syscall.SIGTERM); err != nil {
-		logger.Warn("Failed to send SIGTERM to worker: %v", err)
+		w.log.Warn("Failed to send SIGTERM to worker: %v", err)
 	}
 	w.mu.Unlock()
 
@@ -271,9 +273,9 @@ func (w *Worker) Stop() error {
 		case <-timeout:
 			w.mu.Lock()
 			if w.running && w.cmd != nil && w.cmd.Process != nil {
-				logger.Warn("Worker %s stop timeout, forcing kill", w.id)
+				w.log.Warn("Worker %s stop timeout, forcing kill", w.id)
 				if err := w.cmd.Process.Kill(); err != nil {
-					logger.Warn("Failed to kill worker: %v", err)
+					w.log.Warn("Failed to kill worker: %v", err)
 				}
 			}
 			w.mu.Unlock()
@@ -285,7 +287,7 @@ func (w *Worker) Stop() error {
 			w.mu.RUnlock()
 
 			if !stillRunning {
-				logger.Debug("Worker %s stopped", w.id)
+				w.log.Debug("Worker %s stopped", w.id)
 				return nil
 			}
 		}
@@ -294,7 +296,7 @@ func (w *Worker) Stop() error {
 
 func (w *Worker) Restart() error {
 	if err := w.Stop(); err != nil {
-		logger.Warn("Failed to stop worker during restart: %v", err)
+		w.log.Warn("Failed to stop worker during restart: %v", err)
 	}
 
 	time.Sleep(500 * time.Millisecond)
@@ -364,9 +366,9 @@ func (w *Worker) Cleanup() error {
 
 	w.mu.Lock()
 	if w.running && w.cmd != nil && w.cmd.Process != nil {
-		logger.Debug("Stopping worker %s during cleanup", w.id)
+		w.log.Debug("Stopping worker %s during cleanup", w.id)
 		if err := w.cmd.Process.Signal(syscall.SIGTERM); err != nil {
-			logger.Warn("Failed to send SIGTERM during cleanup: %v", err)
+			w.log.Warn("Failed to send SIGTERM during cleanup: %v", err)
 		}
 		w.mu.Unlock()
 
@@ -380,9 +382,9 @@ func (w *Worker) Cleanup() error {
 			case <-timeout:
 				w.mu.Lock()
 				if w.running && w.cmd != nil && w.cmd.Process != nil {
-					logger.Warn("Worker %s cleanup timeout, forcing kill", w.id)
+					w.log.Warn("Worker %s cleanup timeout, forcing kill", w.id)
 					if err := w.cmd.Process.Kill(); err != nil {
-						logger.Warn("Failed to kill worker during cleanup: %v", err)
+						w.log.Warn("Failed to kill worker during cleanup: %v", err)
 						errs = append(errs, fmt.Errorf("failed to kill worker: %w", err))
 					}
 				}
@@ -402,7 +404,7 @@ func (w *Worker) Cleanup() error {
 
 		w.mu.Lock()
 	} else {
-		logger.Debug("Worker %s not running during cleanup", w.id)
+		w.log.Debug("Worker %s not running during cleanup", w.id)
 	}
 
 	select {
@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/xxnuo/MTranServer/internal/logger"
+	"github.com/xxnuo/MTranServer/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -25,6 +28,9 @@ type Manager struct {
 	taskQueue chan struct{} // Token bucket for serializing tasks
 	closed    bool
 	state     int
+	log       *logger.Entry
+	// inflight 正在处理和排队的请求数
+	inflight atomic.Int64
 }
 
 type ManagerOption func(*Manager)
@@ -38,6 +44,7 @@ func NewManager(args *WorkerArgs, opts ...ManagerOption) *Manager {
 		url:       url,
 		taskQueue: make(chan struct{}, 1),
 		state:     StateStopped,
+		log:       logger.With(logger.Fields{logger.FieldWorkerPort: args.Port}),
 	}
 
 	for _, opt := range opts {
@@ -228,6 +235,10 @@ func (m *Manager) Health(ctx context.Context) (bool, error) {
 }
 
 func (m *Manager) Trans(ctx context.Context, req TransRequest) (string, error) {
+	log := m.log.WithContext(ctx)
+	m.inflight.Add(1)
+	defer m.inflight.Add(-1)
+
 	// 1. Check state immediately
 	m.mu.RLock()
 	if m.state != StateRunning {
@@ -238,14 +249,18 @@ func (m *Manager) Trans(ctx context.Context, req TransRequest) (string, error) {
 	m.mu.RUnlock()
 
 	// 2. Wait for task slot (concurrency control)
+	_, waitSpan := tracing.Start(ctx, "manager.taskQueue.wait", attribute.String("worker.url", m.url))
 	select {
 	case m.taskQueue <- struct{}{}:
+		waitSpan.End()
 		defer func() { <-m.taskQueue }()
 	case <-ctx.Done():
+		tracing.RecordError(waitSpan, ctx.Err())
+		waitSpan.End()
 		return "", ctx.Err()
 	}
 
-	logger.Debug("Manager.Trans: text length: %d, isHTML: %v", len(req.Text), req.HTML)
+	log.Debug("Manager.Trans: text length: %d, isHTML: %v", len(req.Text), req.HTML)
 	m.mu.RLock()
 	if m.closed {
 		m.mu.RUnlock()
@@ -263,18 +278,18 @@ func (m *Manager) Trans(ctx context.Context, req TransRequest) (string, error) {
 	m.mu.RUnlock()
 
 	if client == nil {
-		logger.Error("Manager.Trans: client not initialized")
+		log.Error("Manager.Trans: client not initialized")
 		m.TriggerRestartAsync()
 		return "", fmt.Errorf("client not initialized")
 	}
 
-	logger.Debug("Manager.Trans: calling client.Trans")
+	log.Debug("Manager.Trans: calling client.Trans")
 	result, err := client.Trans(ctx, req)
 	if err == nil {
-		logger.Debug("Manager.Trans: success, result length: %d", len(result))
+		log.Debug("Manager.Trans: success, result length: %d", len(result))
 		return result, nil
 	}
-	logger.Debug("Manager.Trans: client.Trans error: %v", err)
+	log.Debug("Manager.Trans: client.Trans error: %v", err)
 
 	errMsg := err.Error()
 	isConnectionError := !client.IsConnected() ||
@@ -297,6 +312,20 @@ func (m *Manager) Trans(ctx context.Context, req TransRequest) (string, error) {
 	return "", fmt.Errorf("worker connection failed, restarting: %w", err)
 }
 
+// Drain 等待正在处理和排队的请求完成，用于停止前排空 Worker
+func (m *Manager) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for m.inflight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
 func (m *Manager) TriggerRestartAsync() {
 	m.mu.Lock()
 	if m.state == StateRestarting || m.state == StateStopped {
@@ -307,16 +336,16 @@ func (m *Manager) TriggerRestartAsync() {
 	m.mu.Unlock()
 
 	go func() {
-		logger.Info("Async restart triggered for worker on port %d", m.worker.args.Port)
+		m.log.Info("Async restart triggered for worker on port %d", m.worker.args.Port)
 		if err := m.RestartWorker(); err != nil {
-			logger.Error("Async restart failed: %v", err)
+			m.log.Error("Async restart failed: %v", err)
 			// Ensure we mark as stopped so it can be picked up or retried later if needed?
 			// or maybe we should try again? For now, leave it as stopped/failed.
 			m.mu.Lock()
 			m.state = StateStopped
 			m.mu.Unlock()
 		} else {
-			logger.Info("Async restart completed successfully")
+			m.log.Info("Async restart completed successfully")
 		}
 	}()
 }
@@ -334,11 +363,11 @@ func (m *Manager) RestartWorker() error {
 	}
 	m.mu.Unlock()
 
-	logger.Info("Stopping old worker...")
+	m.log.Info("Stopping old worker...")
 	// Force kill if necessary, make sure port is freed
 	if oldWorker != nil {
 		if err := oldWorker.Cleanup(); err != nil {
-			logger.Warn("Failed to cleanup old worker: %v", err)
+			m.log.Warn("Failed to cleanup old worker: %v", err)
 		}
 	}
 
@@ -352,7 +381,7 @@ func (m *Manager) RestartWorker() error {
 	m.worker = newWorker
 	m.mu.Unlock()
 
-	logger.Info("Starting new worker on port %d...", newWorker.args.Port)
+	m.log.Info("Starting new worker on port %d...", newWorker.args.Port)
 	if err := newWorker.Start(); err != nil {
 		return fmt.Errorf("failed to start new worker: %w", err)
 	}
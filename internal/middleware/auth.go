@@ -1,37 +1,235 @@
 package middleware
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxnuo/MTranServer/internal/auth"
 	"github.com/xxnuo/MTranServer/internal/logger"
 )
 
+// TokenKey 认证通过的令牌在 gin.Context 中的键
+const TokenKey = "auth_token"
+
+// errorBodyKey 路由的错误响应体构造函数在 gin.Context 中的键
+const errorBodyKey = "error_body"
+
+// CredentialSource 凭证来源，Extract 返回空字符串表示该来源未携带凭证
+type CredentialSource struct {
+	Name    string
+	Extract func(c *gin.Context) string
+}
+
+// AuthorizationCredential 从 Authorization 头读取凭证，去掉给定的认证方案前缀
+// 没有匹配的前缀时使用完整的头部值
+func AuthorizationCredential(schemes ...string) CredentialSource {
+	return CredentialSource{
+		Name: "authorization",
+		Extract: func(c *gin.Context) string {
+			value := c.GetHeader("Authorization")
+			for _, scheme := range schemes {
+				if strings.HasPrefix(value, scheme+" ") {
+					return strings.TrimPrefix(value, scheme+" ")
+				}
+			}
+			return value
+		},
+	}
+}
+
+// HeaderCredential 从指定请求头读取凭证
+func HeaderCredential(name string) CredentialSource {
+	return CredentialSource{
+		Name: "header:" + name,
+		Extract: func(c *gin.Context) string {
+			return c.GetHeader(name)
+		},
+	}
+}
+
+// QueryCredential 从指定查询参数读取凭证
+func QueryCredential(name string) CredentialSource {
+	return CredentialSource{
+		Name: "query:" + name,
+		Extract: func(c *gin.Context) string {
+			return c.Query(name)
+		},
+	}
+}
+
+// FormCredential 从表单字段读取凭证，请求体不是表单时不读取请求体
+func FormCredential(name string) CredentialSource {
+	return CredentialSource{
+		Name: "form:" + name,
+		Extract: func(c *gin.Context) string {
+			return c.PostForm(name)
+		},
+	}
+}
+
+// maxJSONCredentialBytes JSONCredential 最多读取的请求体字节数
+const maxJSONCredentialBytes = 1 << 20
+
+// JSONCredential 从 JSON 请求体的顶层字段读取凭证，读取的内容会放回请求体供后续处理
+func JSONCredential(name string) CredentialSource {
+	return CredentialSource{
+		Name: "json:" + name,
+		Extract: func(c *gin.Context) string {
+			if c.Request.Body == nil || c.ContentType() != "application/json" {
+				return ""
+			}
+			body := c.Request.Body
+			data, err := io.ReadAll(io.LimitReader(body, maxJSONCredentialBytes))
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(data), body), body}
+			if err != nil {
+				return ""
+			}
+
+			var fields map[string]json.RawMessage
+			var value string
+			if json.Unmarshal(data, &fields) != nil || json.Unmarshal(fields[name], &value) != nil {
+				return ""
+			}
+			return value
+		},
+	}
+}
+
+// 常用凭证来源
+var (
+	CredBearer     = AuthorizationCredential("Bearer")
+	CredDeepL      = AuthorizationCredential("DeepL-Auth-Key", "Bearer")
+	CredKeyHeader  = HeaderCredential("KEY")
+	CredTokenQuery = QueryCredential("token")
+	CredKeyQuery   = QueryCredential("key")
+)
+
+// AuthOptions 路由的认证配置
+type AuthOptions struct {
+	Store *auth.Store
+	Scope auth.Scope
+	// Sources 按顺序尝试，使用第一个非空的凭证
+	Sources []CredentialSource
+	// Body 认证失败时的响应体，为空时使用 DefaultErrorBody
+	Body ErrorBodyFunc
+}
+
+// RouteErrorBody 返回路由认证时使用的错误响应体构造函数，处理函数拒绝请求时使用相同的格式
+// 未经过 Authenticate 时返回 DefaultErrorBody
+func RouteErrorBody(c *gin.Context) ErrorBodyFunc {
+	if body, ok := c.Value(errorBodyKey).(ErrorBodyFunc); ok {
+		return body
+	}
+	return DefaultErrorBody
+}
+
+// Auth 使用单个令牌认证核心接口
 func Auth(apiToken string) gin.HandlerFunc {
-	return func(c *gin.Context) {
+	store := auth.NewStore("")
+	store.SetLegacyToken(apiToken)
+	return AuthStore(store, auth.ScopeAPI)
+}
+
+// AuthStore 使用令牌存储认证，接受 Bearer 令牌或 token 查询参数
+func AuthStore(store *auth.Store, scope auth.Scope) gin.HandlerFunc {
+	return Authenticate(AuthOptions{
+		Store:   store,
+		Scope:   scope,
+		Sources: []CredentialSource{CredBearer, CredTokenQuery},
+	})
+}
 
-		if apiToken == "" {
+// Authenticate 按路由声明的凭证来源认证，并检查令牌是否拥有 scope 权限
+// 未配置任何令牌时放行，但管理接口仅允许本机访问
+func Authenticate(opts AuthOptions) gin.HandlerFunc {
+	if opts.Body == nil {
+		opts.Body = DefaultErrorBody
+	}
+	return func(c *gin.Context) {
+		c.Set(errorBodyKey, opts.Body)
+		if !opts.Store.Enabled() {
+			if opts.Scope == auth.ScopeAdmin && !fromLocalhost(c) {
+				reject(c, opts, http.StatusForbidden, "Admin API is only available from localhost when no token is configured")
+				return
+			}
 			c.Next()
 			return
 		}
 
-		token := c.GetHeader("Authorization")
-		if token != "" {
-			token = strings.TrimPrefix(token, "Bearer ")
-		} else {
-			token = c.Query("token")
-		}
+		token, source := extractCredential(c, opts.Sources)
+		audit := logger.Ctx(c.Request.Context()).With(logger.Fields{
+			"client_ip":   c.ClientIP(),
+			"path":        c.Request.URL.Path,
+			"scope":       string(opts.Scope),
+			"credential":  source,
+			"auth_result": "denied",
+		})
 
-		if token != apiToken {
-			logger.Warn("Unauthorized access attempt from %s to %s", c.ClientIP(), c.Request.URL.Path)
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Unauthorized",
-			})
-			c.Abort()
+		t, err := opts.Store.Authorize(token, opts.Scope)
+		if err != nil {
+			if t != nil {
+				audit = audit.With(logger.Fields{logger.FieldTokenID: t.ID})
+			}
+			audit.Warn("Authentication failed: %v", err)
+
+			if errors.Is(err, auth.ErrScopeDenied) {
+				reject(c, opts, http.StatusForbidden, err.Error())
+			} else {
+				reject(c, opts, http.StatusUnauthorized, "Unauthorized")
+			}
 			return
 		}
 
+		audit.With(logger.Fields{
+			logger.FieldTokenID: t.ID,
+			"token_label":       t.Label,
+			"auth_result":       "allowed",
+		}).Debug("Authenticated")
+
+		c.Set(TokenKey, t)
 		c.Next()
 	}
 }
+
+func extractCredential(c *gin.Context, sources []CredentialSource) (string, string) {
+	for _, s := range sources {
+		if v := s.Extract(c); v != "" {
+			return v, s.Name
+		}
+	}
+	return "", "none"
+}
+
+func reject(c *gin.Context, opts AuthOptions, status int, message string) {
+	c.AbortWithStatusJSON(status, opts.Body(c, status, message))
+}
+
+// GetToken 获取当前请求认证通过的令牌，未启用认证时返回 nil
+func GetToken(c *gin.Context) *auth.Token {
+	v, ok := c.Get(TokenKey)
+	if !ok {
+		return nil
+	}
+	t, _ := v.(*auth.Token)
+	return t
+}
+
+// fromLocalhost 连接来自本机，且经受信任的代理转发时原始客户端也是本机
+// 连接地址不能被请求头伪造，因此总是同时检查 RemoteIP
+func fromLocalhost(c *gin.Context) bool {
+	return isLoopback(c.RemoteIP()) && isLoopback(c.ClientIP())
+}
+
+func isLoopback(ip string) bool {
+	parsed := net.ParseIP(ip)
+	return parsed != nil && parsed.IsLoopback()
+}
@@ -3,10 +3,13 @@ package middleware
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/xxnuo/MTranServer/internal/auth"
 )
 
 func TestAuthWithValidToken(t *testing.T) {
@@ -78,3 +81,171 @@ func TestAuthWithNoToken(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 	assert.Contains(t, w.Body.String(), "Unauthorized")
 }
+
+func TestAuthStoreScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := auth.NewStore("")
+	_, raw, err := store.Create("plugin", []auth.Scope{auth.ScopePlugin}, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(AuthStore(store, auth.ScopeAPI))
+	r.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, "success")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+raw)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestAdminScopeLocalOnlyWithoutTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(AuthStore(auth.NewStore(""), auth.ScopeAdmin))
+	r.GET("/admin", func(c *gin.Context) {
+		c.String(http.StatusOK, "success")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin", nil)
+	req.RemoteAddr = "127.0.0.1:12345"
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/admin", nil)
+	req.RemoteAddr = "203.0.113.1:12345"
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 伪造的 X-Forwarded-For 不能绕过本机限制
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/admin", nil)
+	req.RemoteAddr = "203.0.113.1:12345"
+	req.Header.Set("X-Forwarded-For", "127.0.0.1")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 本机的受信任代理转发的远程请求同样拒绝
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/admin", nil)
+	req.RemoteAddr = "127.0.0.1:12345"
+	req.Header.Set("X-Forwarded-For", "203.0.113.1")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestAuthenticateCredentialSources(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := auth.NewStore("")
+	store.SetLegacyToken("test-token")
+
+	r := gin.New()
+	r.GET("/deepl", Authenticate(AuthOptions{
+		Store:   store,
+		Scope:   auth.ScopePlugin,
+		Sources: []CredentialSource{CredDeepL, CredTokenQuery},
+		Body: func(c *gin.Context, status int, message string) interface{} {
+			return gin.H{"message": message}
+		},
+	}), func(c *gin.Context) {
+		c.String(http.StatusOK, "success")
+	})
+	r.GET("/kiss", Authenticate(AuthOptions{
+		Store:   store,
+		Scope:   auth.ScopePlugin,
+		Sources: []CredentialSource{CredKeyHeader},
+	}), func(c *gin.Context) {
+		c.String(http.StatusOK, "success")
+	})
+
+	cases := []struct {
+		path    string
+		header  string
+		value   string
+		allowed bool
+	}{
+		{"/deepl", "Authorization", "DeepL-Auth-Key test-token", true},
+		{"/deepl", "Authorization", "Bearer test-token", true},
+		{"/deepl?token=test-token", "", "", true},
+		{"/deepl", "Authorization", "DeepL-Auth-Key wrong", false},
+		{"/kiss", "KEY", "test-token", true},
+		{"/kiss?token=test-token", "", "", false},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", tc.path, nil)
+		if tc.header != "" {
+			req.Header.Set(tc.header, tc.value)
+		}
+		r.ServeHTTP(w, req)
+
+		if tc.allowed {
+			assert.Equal(t, http.StatusOK, w.Code, tc.path)
+		} else {
+			assert.Equal(t, http.StatusUnauthorized, w.Code, tc.path)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/deepl", nil)
+	r.ServeHTTP(w, req)
+	assert.JSONEq(t, `{"message":"Unauthorized"}`, w.Body.String())
+}
+
+func TestAuthenticateBodyCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := auth.NewStore("")
+	store.SetLegacyToken("test-token")
+
+	r := gin.New()
+	r.POST("/libre", Authenticate(AuthOptions{
+		Store:   store,
+		Scope:   auth.ScopePlugin,
+		Sources: []CredentialSource{FormCredential("api_key"), JSONCredential("api_key")},
+	}), func(c *gin.Context) {
+		var req struct {
+			Q string `json:"q" form:"q"`
+		}
+		if err := c.ShouldBind(&req); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.String(http.StatusOK, req.Q)
+	})
+
+	cases := []struct {
+		contentType string
+		body        string
+		allowed     bool
+	}{
+		{"application/x-www-form-urlencoded", "q=hello&api_key=test-token", true},
+		{"application/json", `{"q":"hello","api_key":"test-token"}`, true},
+		{"application/json", `{"q":"hello","api_key":"wrong"}`, false},
+		{"application/json", `{"q":"hello"}`, false},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/libre", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", tc.contentType)
+		r.ServeHTTP(w, req)
+
+		if tc.allowed {
+			assert.Equal(t, http.StatusOK, w.Code, tc.body)
+			// 读取凭证后请求体仍可被处理函数读取
+			assert.Equal(t, "hello", w.Body.String(), tc.body)
+		} else {
+			assert.Equal(t, http.StatusUnauthorized, w.Code, tc.body)
+		}
+	}
+}
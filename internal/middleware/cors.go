@@ -1,19 +1,213 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func CORS() gin.HandlerFunc {
+// CORSPolicy 跨域策略
+type CORSPolicy struct {
+	// AllowOrigins 允许的来源，支持 "*"、完整匹配和 "https://*.example.com" 形式的通配
+	AllowOrigins     []string
+	AllowHeaders     []string
+	AllowMethods     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	// MaxAge 预检请求结果的缓存时间，0 表示不设置
+	MaxAge time.Duration
+}
+
+// DefaultCORSPolicy 默认策略，允许任意来源
+func DefaultCORSPolicy() *CORSPolicy {
+	return &CORSPolicy{
+		AllowOrigins:     []string{"*"},
+		AllowHeaders:     []string{"Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "accept", "origin", "Cache-Control", "X-Requested-With", "KEY", RequestIDHeader},
+		AllowMethods:     []string{"POST", "OPTIONS", "GET", "PUT", "DELETE"},
+		ExposeHeaders:    []string{RequestIDHeader, "Retry-After"},
+		AllowCredentials: true,
+	}
+}
+
+// ParseCORS 解析跨域配置，如 "origins=https://app.example.com https://*.example.com,credentials=true,max-age=10m"
+// 未设置的选项使用默认策略的值，为空时返回默认策略
+func ParseCORS(spec string) (*CORSPolicy, error) {
+	p := DefaultCORSPolicy()
+
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return p, nil
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid CORS option: %q", part)
+		}
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+
+		switch k {
+		case "origins":
+			p.AllowOrigins = strings.Fields(v)
+			for _, o := range p.AllowOrigins {
+				if strings.Count(o, "*") > 1 {
+					return nil, fmt.Errorf("invalid CORS origin %q: only one wildcard is allowed", o)
+				}
+			}
+		case "headers":
+			p.AllowHeaders = strings.Fields(v)
+		case "methods":
+			p.AllowMethods = strings.Fields(strings.ToUpper(v))
+		case "expose":
+			p.ExposeHeaders = strings.Fields(v)
+		case "credentials":
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CORS credentials: %w", err)
+			}
+			p.AllowCredentials = b
+		case "max-age":
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				return nil, fmt.Errorf("invalid CORS max-age: %q", v)
+			}
+			p.MaxAge = d
+		default:
+			return nil, fmt.Errorf("unknown CORS option: %q", k)
+		}
+	}
+	return p, nil
+}
+
+func (p *CORSPolicy) allowAll() bool {
+	for _, o := range p.AllowOrigins {
+		if o == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *CORSPolicy) allowOrigin(origin string) bool {
+	for _, o := range p.AllowOrigins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+		if prefix, suffix, ok := strings.Cut(o, "*"); ok {
+			if len(origin) > len(prefix)+len(suffix) &&
+				strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// apply 写入跨域响应头
+func (p *CORSPolicy) apply(c *gin.Context) {
+	h := c.Writer.Header()
+	origin := c.GetHeader("Origin")
+
+	credentials := p.AllowCredentials
+	switch {
+	case origin == "":
+		// 非跨域请求
+		if p.allowAll() {
+			h.Set("Access-Control-Allow-Origin", "*")
+		}
+	case !p.allowOrigin(origin):
+		return
+	case p.allowAll():
+		// "*" 不能与凭证同时使用，需要携带凭证时应配置具体的来源
+		h.Set("Access-Control-Allow-Origin", "*")
+		credentials = false
+	default:
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Add("Vary", "Origin")
+	}
+
+	if credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if len(p.ExposeHeaders) > 0 {
+		h.Set("Access-Control-Expose-Headers", strings.Join(p.ExposeHeaders, ", "))
+	}
+	if len(p.AllowHeaders) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(p.AllowHeaders, ", "))
+	}
+	if len(p.AllowMethods) > 0 {
+		h.Set("Access-Control-Allow-Methods", strings.Join(p.AllowMethods, ", "))
+	}
+	if p.MaxAge > 0 && c.Request.Method == http.MethodOptions {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge.Seconds())))
+	}
+}
+
+type corsRule struct {
+	prefixes []string
+	policy   *CORSPolicy
+}
+
+// CORSRouter 按路径前缀选择跨域策略，策略可在运行时替换
+// 预检请求不会匹配到路由分组的中间件，因此需要注册为全局中间件
+type CORSRouter struct {
+	mu       sync.RWMutex
+	rules    []*corsRule
+	fallback *CORSPolicy
+}
+
+func NewCORSRouter(fallback *CORSPolicy) *CORSRouter {
+	return &CORSRouter{fallback: fallback}
+}
+
+// SetFallback 设置未匹配任何前缀时使用的策略
+func (r *CORSRouter) SetFallback(policy *CORSPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallback = policy
+}
+
+// Set 为一组路径前缀设置策略，相同前缀组会被替换
+func (r *CORSRouter) Set(prefixes []string, policy *CORSPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := strings.Join(prefixes, "\x00")
+	for _, rule := range r.rules {
+		if strings.Join(rule.prefixes, "\x00") == key {
+			rule.policy = policy
+			return
+		}
+	}
+	r.rules = append(r.rules, &corsRule{prefixes: prefixes, policy: policy})
+}
+
+func (r *CORSRouter) policy(path string) *CORSPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, rule := range r.rules {
+		for _, prefix := range rule.prefixes {
+			if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+				return rule.policy
+			}
+		}
+	}
+	return r.fallback
+}
+
+func (r *CORSRouter) Handler() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, KEY")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		if p := r.policy(c.Request.URL.Path); p != nil {
+			p.apply(c)
+		}
 
-		if c.Request.Method == "OPTIONS" {
+		if c.Request.Method == http.MethodOptions {
 			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
@@ -21,3 +215,8 @@ func CORS() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// CORS 使用默认策略的跨域中间件
+func CORS() gin.HandlerFunc {
+	return NewCORSRouter(DefaultCORSPolicy()).Handler()
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -44,3 +45,116 @@ func TestCORSOptions(t *testing.T) {
 
 	assert.Equal(t, http.StatusNoContent, w.Code)
 }
+
+func TestParseCORS(t *testing.T) {
+	p, err := ParseCORS("")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"*"}, p.AllowOrigins)
+
+	p, err = ParseCORS("origins=https://a.example.com https://*.example.org, methods=get post, credentials=false, max-age=10m")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"https://a.example.com", "https://*.example.org"}, p.AllowOrigins)
+	assert.Equal(t, []string{"GET", "POST"}, p.AllowMethods)
+	assert.False(t, p.AllowCredentials)
+	assert.Equal(t, 10*time.Minute, p.MaxAge)
+
+	for _, spec := range []string{"origins", "foo=bar", "credentials=maybe", "max-age=-1s", "origins=https://*.*.com"} {
+		_, err := ParseCORS(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestCORSOrigins(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	p, err := ParseCORS("origins=https://app.example.com https://*.example.org,max-age=1m")
+	assert.NoError(t, err)
+
+	r := gin.New()
+	r.Use(NewCORSRouter(p).Handler())
+	r.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, "test")
+	})
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://app.example.com", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"https://evil.com", false},
+		{"http://app.example.com", false},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("OPTIONS", "/test", nil)
+		req.Header.Set("Origin", tt.origin)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code, tt.origin)
+		if tt.allowed {
+			assert.Equal(t, tt.origin, w.Header().Get("Access-Control-Allow-Origin"), tt.origin)
+			assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"), tt.origin)
+			assert.Equal(t, "Origin", w.Header().Get("Vary"), tt.origin)
+			assert.Equal(t, "60", w.Header().Get("Access-Control-Max-Age"), tt.origin)
+		} else {
+			assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"), tt.origin)
+			assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"), tt.origin)
+		}
+	}
+}
+
+func TestCORSWildcardWithOrigin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(CORS())
+	r.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, "test")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORSRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	plugin, err := ParseCORS("origins=https://plugin.example.com")
+	assert.NoError(t, err)
+
+	cors := NewCORSRouter(DefaultCORSPolicy())
+	cors.Set([]string{"/imme", "/google"}, plugin)
+
+	r := gin.New()
+	r.Use(cors.Handler())
+	for _, path := range []string{"/translate", "/imme", "/google/language/translate/v2", "/googlex"} {
+		r.GET(path, func(c *gin.Context) {
+			c.String(http.StatusOK, "test")
+		})
+	}
+
+	get := func(path, origin string) string {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Origin", origin)
+		r.ServeHTTP(w, req)
+		return w.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	assert.Equal(t, "*", get("/translate", "https://other.com"))
+	assert.Equal(t, "*", get("/googlex", "https://other.com"))
+	assert.Empty(t, get("/imme", "https://other.com"))
+	assert.Empty(t, get("/google/language/translate/v2", "https://other.com"))
+	assert.Equal(t, "https://plugin.example.com", get("/imme", "https://plugin.example.com"))
+
+	// 替换策略立即生效
+	cors.Set([]string{"/imme", "/google"}, DefaultCORSPolicy())
+	assert.Equal(t, "*", get("/imme", "https://other.com"))
+}
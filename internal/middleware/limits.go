@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxnuo/MTranServer/internal/logger"
+)
+
+const requestLimitsContextKey = "request_limits"
+
+// RequestLimits 请求大小限制，0 表示不限制
+type RequestLimits struct {
+	// MaxBodyBytes 请求体字节数
+	MaxBodyBytes int64
+	// MaxTextChars 单条文本的字符数
+	MaxTextChars int
+	// MaxBatchItems 单次请求的文本条数
+	MaxBatchItems int
+}
+
+type requestLimitsState struct {
+	limits RequestLimits
+	body   ErrorBodyFunc
+}
+
+// RequestLimit 限制请求体大小，超出时返回 413
+// 文本长度与条数需要在解析请求后由处理函数调用 CheckTexts 检查
+// limits 在每个请求时调用，配置重新加载后立即生效
+func RequestLimit(limits func() RequestLimits, body ErrorBodyFunc) gin.HandlerFunc {
+	if body == nil {
+		body = DefaultErrorBody
+	}
+
+	return func(c *gin.Context) {
+		l := limits()
+		c.Set(requestLimitsContextKey, &requestLimitsState{limits: l, body: body})
+
+		if l.MaxBodyBytes <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > l.MaxBodyBytes {
+			rejectTooLarge(c, body, fmt.Sprintf("Request body too large, maximum is %d bytes", l.MaxBodyBytes))
+			return
+		}
+
+		// Content-Length 可能缺失或不准确，按实际读取的字节数判断
+		data, err := io.ReadAll(io.LimitReader(c.Request.Body, l.MaxBodyBytes+1))
+		c.Request.Body.Close()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, body(c, http.StatusBadRequest, "Failed to read request body"))
+			return
+		}
+		if int64(len(data)) > l.MaxBodyBytes {
+			rejectTooLarge(c, body, fmt.Sprintf("Request body too large, maximum is %d bytes", l.MaxBodyBytes))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(data))
+
+		c.Next()
+	}
+}
+
+// CheckTexts 检查文本条数与每条文本的字符数，超出时写入 413 响应
+// 未使用 RequestLimit 中间件时不做限制
+func CheckTexts(c *gin.Context, texts ...string) bool {
+	v, ok := c.Get(requestLimitsContextKey)
+	if !ok {
+		return true
+	}
+	state := v.(*requestLimitsState)
+	l := state.limits
+
+	if l.MaxBatchItems > 0 && len(texts) > l.MaxBatchItems {
+		rejectTooLarge(c, state.body, fmt.Sprintf("Too many texts: %d, maximum is %d", len(texts), l.MaxBatchItems))
+		return false
+	}
+
+	if l.MaxTextChars > 0 {
+		for i, text := range texts {
+			// 字符数不会超过字节数，短文本无需计数
+			if len(text) <= l.MaxTextChars {
+				continue
+			}
+			if n := utf8.RuneCountInString(text); n > l.MaxTextChars {
+				rejectTooLarge(c, state.body, fmt.Sprintf("Text at index %d is too long: %d characters, maximum is %d", i, n, l.MaxTextChars))
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func rejectTooLarge(c *gin.Context, body ErrorBodyFunc, message string) {
+	logger.Ctx(c.Request.Context()).Warn("Rejected request on %s: %s", c.Request.URL.Path, message)
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, body(c, http.StatusRequestEntityTooLarge, message))
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLimitedRouter(limits RequestLimits) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(RequestLimit(func() RequestLimits { return limits }, nil))
+	r.POST("/test", func(c *gin.Context) {
+		var req struct {
+			Texts []string `json:"texts"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorBody(c, err.Error()))
+			return
+		}
+		if !CheckTexts(c, req.Texts...) {
+			return
+		}
+		c.String(http.StatusOK, "success")
+	})
+	return r
+}
+
+func postJSON(r *gin.Engine, body string, contentLength bool) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/test", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if !contentLength {
+		req.ContentLength = -1
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequestLimitBody(t *testing.T) {
+	r := newLimitedRouter(RequestLimits{MaxBodyBytes: 32})
+
+	w := postJSON(r, `{"texts":["hello"]}`, true)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	large := `{"texts":["` + strings.Repeat("a", 64) + `"]}`
+	w = postJSON(r, large, true)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "maximum is 32 bytes")
+
+	// 没有 Content-Length 时按实际读取的字节数判断
+	w = postJSON(r, large, false)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestRequestLimitTexts(t *testing.T) {
+	r := newLimitedRouter(RequestLimits{MaxTextChars: 5, MaxBatchItems: 2})
+
+	w := postJSON(r, `{"texts":["你好世界！","abc"]}`, true)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = postJSON(r, `{"texts":["a","b","c"]}`, true)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "Too many texts: 3, maximum is 2")
+
+	w = postJSON(r, `{"texts":["ok","too long"]}`, true)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "Text at index 1 is too long: 8 characters, maximum is 5")
+}
+
+func TestRequestLimitDisabled(t *testing.T) {
+	r := newLimitedRouter(RequestLimits{})
+
+	w := postJSON(r, `{"texts":["`+strings.Repeat("a", 4096)+`"]}`, false)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestCheckTextsWithoutMiddleware(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	assert.True(t, CheckTexts(c, strings.Repeat("a", 1<<20)))
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -29,11 +30,12 @@ func Logger() gin.HandlerFunc {
 			path = path + "?" + raw
 		}
 
-		logFunc := logger.Info
+		log := logger.Ctx(c.Request.Context())
+		logFunc := log.Info
 		if statusCode >= 500 {
-			logFunc = logger.Error
+			logFunc = log.Error
 		} else if statusCode >= 400 {
-			logFunc = logger.Warn
+			logFunc = log.Warn
 		}
 
 		msg := fmt.Sprintf("%s %s %d %v %s",
@@ -48,7 +50,7 @@ func Logger() gin.HandlerFunc {
 			msg = fmt.Sprintf("%s | Error: %s", msg, errorMessage)
 		}
 
-		logFunc(msg)
+		logFunc("%s", msg)
 	}
 }
 
@@ -56,8 +58,8 @@ func Recovery() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
-				logger.Error("Panic recovered: %v", err)
-				c.AbortWithStatus(500)
+				logger.Ctx(c.Request.Context()).Error("Panic recovered: %v", err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorBody(c, "Internal Server Error"))
 			}
 		}()
 		c.Next()
@@ -0,0 +1,341 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxnuo/MTranServer/internal/logger"
+)
+
+type RateLimitKey string
+
+const (
+	// RateLimitByToken 按令牌限流，未携带令牌时按 IP
+	RateLimitByToken RateLimitKey = "token"
+	// RateLimitByIP 按客户端 IP 限流
+	RateLimitByIP RateLimitKey = "ip"
+	// RateLimitByBoth 令牌和 IP 同时限流
+	RateLimitByBoth RateLimitKey = "both"
+)
+
+const rateLimitContextKey = "rate_limit"
+
+// RateLimitPolicy 限流策略，Requests 和 Chars 为每个 Window 内允许的请求数和字符数，0 表示不限制
+type RateLimitPolicy struct {
+	Requests int
+	Chars    int
+	Window   time.Duration
+	Key      RateLimitKey
+}
+
+// ErrorBodyFunc 构造限流等错误的响应体，用于兼容不同插件的错误格式
+type ErrorBodyFunc func(c *gin.Context, status int, message string) interface{}
+
+// DefaultErrorBody 默认错误响应体
+func DefaultErrorBody(c *gin.Context, status int, message string) interface{} {
+	return ErrorBody(c, message)
+}
+
+// ParseRateLimit 解析限流配置，如 "requests=60,chars=100000,window=1m,key=token"，为空时返回 nil
+func ParseRateLimit(spec string) (*RateLimitPolicy, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	p := &RateLimitPolicy{
+		Window: time.Minute,
+		Key:    RateLimitByToken,
+	}
+	for _, part := range strings.Split(spec, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit option: %q", part)
+		}
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+
+		var err error
+		switch k {
+		case "requests":
+			p.Requests, err = strconv.Atoi(v)
+		case "chars":
+			p.Chars, err = strconv.Atoi(v)
+		case "window":
+			p.Window, err = time.ParseDuration(v)
+			if err == nil && p.Window <= 0 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "key":
+			p.Key = RateLimitKey(v)
+			if p.Key != RateLimitByToken && p.Key != RateLimitByIP && p.Key != RateLimitByBoth {
+				err = fmt.Errorf("must be token, ip or both")
+			}
+		default:
+			return nil, fmt.Errorf("unknown rate limit option: %q", k)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit %s: %w", k, err)
+		}
+	}
+
+	if p.Requests < 0 || p.Chars < 0 {
+		return nil, fmt.Errorf("rate limit values must not be negative")
+	}
+	if p.Requests == 0 && p.Chars == 0 {
+		return nil, nil
+	}
+	return p, nil
+}
+
+type bucket struct {
+	tokens   float64
+	capacity float64
+	rate     float64
+	last     time.Time
+}
+
+func newBucket(limit int, window time.Duration, now time.Time) *bucket {
+	return &bucket{
+		tokens:   float64(limit),
+		capacity: float64(limit),
+		rate:     float64(limit) / window.Seconds(),
+		last:     now,
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+}
+
+// wait 返回取出 n 个令牌需要等待的时间，n 超过容量时需要等待桶满
+func (b *bucket) wait(n float64) time.Duration {
+	need := math.Min(n, b.capacity)
+	if b.tokens >= need {
+		return 0
+	}
+	return time.Duration((need - b.tokens) / b.rate * float64(time.Second))
+}
+
+type limiterEntry struct {
+	requests *bucket
+	chars    *bucket
+}
+
+// RateLimiter 令牌桶限流器，同一路由分组共享一个实例
+type RateLimiter struct {
+	policy    *RateLimitPolicy
+	mu        sync.Mutex
+	entries   map[string]*limiterEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewRateLimiter(policy RateLimitPolicy) *RateLimiter {
+	l := &RateLimiter{now: time.Now}
+	l.SetPolicy(&policy)
+	return l
+}
+
+// SetPolicy 替换限流策略并清空已有的桶，policy 为 nil 时不限流
+func (l *RateLimiter) SetPolicy(policy *RateLimitPolicy) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.policy = policy
+	l.entries = make(map[string]*limiterEntry)
+	l.lastSweep = l.now()
+}
+
+// Enabled 是否启用了限流
+func (l *RateLimiter) Enabled() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.policy != nil
+}
+
+// allow 检查所有 key 的请求数与字符数额度，全部满足时才扣减
+func (l *RateLimiter) allow(keys []string, requests, chars int) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.policy == nil {
+		return true, 0
+	}
+
+	now := l.now()
+	l.sweep(now)
+
+	var wait time.Duration
+	entries := make([]*limiterEntry, 0, len(keys))
+	for _, key := range keys {
+		e := l.entry(key, now)
+		entries = append(entries, e)
+
+		if e.requests != nil && requests > 0 {
+			e.requests.refill(now)
+			wait = max(wait, e.requests.wait(float64(requests)))
+		}
+		if e.chars != nil && chars > 0 {
+			e.chars.refill(now)
+			wait = max(wait, e.chars.wait(float64(chars)))
+		}
+	}
+	if wait > 0 {
+		return false, wait
+	}
+
+	for _, e := range entries {
+		if e.requests != nil && requests > 0 {
+			e.requests.tokens -= math.Min(float64(requests), e.requests.capacity)
+		}
+		if e.chars != nil && chars > 0 {
+			e.chars.tokens -= math.Min(float64(chars), e.chars.capacity)
+		}
+	}
+	return true, 0
+}
+
+func (l *RateLimiter) entry(key string, now time.Time) *limiterEntry {
+	e, ok := l.entries[key]
+	if ok {
+		return e
+	}
+	e = &limiterEntry{}
+	if l.policy.Requests > 0 {
+		e.requests = newBucket(l.policy.Requests, l.policy.Window, now)
+	}
+	if l.policy.Chars > 0 {
+		e.chars = newBucket(l.policy.Chars, l.policy.Window, now)
+	}
+	l.entries[key] = e
+	return e
+}
+
+// sweep 每个窗口清理一次已回满的桶，避免内存无限增长
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.policy.Window {
+		return
+	}
+	l.lastSweep = now
+
+	for key, e := range l.entries {
+		full := true
+		if e.requests != nil {
+			e.requests.refill(now)
+			full = full && e.requests.tokens >= e.requests.capacity
+		}
+		if e.chars != nil {
+			e.chars.refill(now)
+			full = full && e.chars.tokens >= e.chars.capacity
+		}
+		if full {
+			delete(l.entries, key)
+		}
+	}
+}
+
+// keys 根据策略生成限流 key
+// 服务只信任 MT_TRUSTED_PROXIES 中的代理，其余请求的 ClientIP 即连接地址，伪造 X-Forwarded-For 不会得到新的桶
+func (l *RateLimiter) keys(c *gin.Context) []string {
+	ip := "ip:" + c.ClientIP()
+
+	l.mu.Lock()
+	key := RateLimitByToken
+	if l.policy != nil {
+		key = l.policy.Key
+	}
+	l.mu.Unlock()
+
+	switch key {
+	case RateLimitByIP:
+		return []string{ip}
+	case RateLimitByBoth:
+		if token := credentialKey(c); token != "" {
+			return []string{token, ip}
+		}
+		return []string{ip}
+	default:
+		if token := credentialKey(c); token != "" {
+			return []string{token}
+		}
+		return []string{ip}
+	}
+}
+
+// credentialKey 使用认证通过的令牌 ID，限流中间件需注册在认证之后
+func credentialKey(c *gin.Context) string {
+	if t := GetToken(c); t != nil {
+		return "token:" + t.ID
+	}
+	return ""
+}
+
+type rateLimitState struct {
+	limiter *RateLimiter
+	keys    []string
+	body    ErrorBodyFunc
+}
+
+// RateLimit 按请求数限流，并将限流器写入 context 供处理函数按字符数限流
+func RateLimit(l *RateLimiter, body ErrorBodyFunc) gin.HandlerFunc {
+	if body == nil {
+		body = DefaultErrorBody
+	}
+	return func(c *gin.Context) {
+		if !l.Enabled() {
+			c.Next()
+			return
+		}
+
+		state := &rateLimitState{
+			limiter: l,
+			keys:    l.keys(c),
+			body:    body,
+		}
+		c.Set(rateLimitContextKey, state)
+
+		if ok, wait := l.allow(state.keys, 1, 0); !ok {
+			rejectRateLimited(c, state, wait, "Too many requests")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ChargeRateLimit 扣减字符数限流额度，超出时写入 429 响应并返回 false
+func ChargeRateLimit(c *gin.Context, chars int) bool {
+	v, ok := c.Get(rateLimitContextKey)
+	if !ok {
+		return true
+	}
+	state := v.(*rateLimitState)
+
+	if ok, wait := state.limiter.allow(state.keys, 0, chars); !ok {
+		rejectRateLimited(c, state, wait, "Too many characters")
+		return false
+	}
+	return true
+}
+
+func rejectRateLimited(c *gin.Context, state *rateLimitState, wait time.Duration, message string) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	logger.Ctx(c.Request.Context()).Warn("Rate limited %s on %s: %s, retry after %ds", strings.Join(state.keys, ","), c.Request.URL.Path, message, seconds)
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, state.body(c, http.StatusTooManyRequests, message))
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xxnuo/MTranServer/internal/auth"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	p, err := ParseRateLimit("requests=10, chars=500, window=30s, key=both")
+	require.NoError(t, err)
+	assert.Equal(t, RateLimitPolicy{Requests: 10, Chars: 500, Window: 30 * time.Second, Key: RateLimitByBoth}, *p)
+
+	p, err = ParseRateLimit("")
+	assert.NoError(t, err)
+	assert.Nil(t, p)
+
+	for _, spec := range []string{"requests", "requests=x", "window=0s", "key=user", "foo=1", "requests=-1"} {
+		_, err := ParseRateLimit(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func newRateLimitedRouter(l *RateLimiter, body ErrorBodyFunc, chars int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	_ = r.SetTrustedProxies(nil)
+	r.Use(RateLimit(l, body))
+	r.GET("/test", func(c *gin.Context) {
+		if !ChargeRateLimit(c, chars) {
+			return
+		}
+		c.String(http.StatusOK, "success")
+	})
+	return r
+}
+
+func doRequest(r *gin.Engine, ip, token string) *httptest.ResponseRecorder {
+	return doForwardedRequest(r, ip, "", token)
+}
+
+func doForwardedRequest(r *gin.Engine, ip, forwardedFor, token string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.RemoteAddr = ip + ":1234"
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitRequests(t *testing.T) {
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(RateLimitPolicy{Requests: 2, Window: time.Minute, Key: RateLimitByIP})
+	l.now = func() time.Time { return current }
+	r := newRateLimitedRouter(l, nil, 0)
+
+	assert.Equal(t, http.StatusOK, doRequest(r, "10.0.0.1", "").Code)
+	assert.Equal(t, http.StatusOK, doRequest(r, "10.0.0.1", "").Code)
+
+	w := doRequest(r, "10.0.0.1", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "Too many requests")
+
+	assert.Equal(t, http.StatusOK, doRequest(r, "10.0.0.2", "").Code)
+
+	current = current.Add(30 * time.Second)
+	assert.Equal(t, http.StatusOK, doRequest(r, "10.0.0.1", "").Code)
+}
+
+func TestRateLimitIgnoresUntrustedForwardedFor(t *testing.T) {
+	l := NewRateLimiter(RateLimitPolicy{Requests: 1, Window: time.Minute, Key: RateLimitByIP})
+	r := newRateLimitedRouter(l, nil, 0)
+
+	assert.Equal(t, http.StatusOK, doForwardedRequest(r, "10.0.0.1", "192.0.2.1", "").Code)
+	assert.Equal(t, http.StatusTooManyRequests, doForwardedRequest(r, "10.0.0.1", "192.0.2.2", "").Code)
+
+	// 受信任的代理转发的请求按原始客户端限流
+	require.NoError(t, r.SetTrustedProxies([]string{"10.0.0.0/8"}))
+	assert.Equal(t, http.StatusOK, doForwardedRequest(r, "10.0.0.1", "192.0.2.3", "").Code)
+	assert.Equal(t, http.StatusTooManyRequests, doForwardedRequest(r, "10.0.0.2", "192.0.2.3", "").Code)
+}
+
+func TestRateLimitByToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := auth.NewStore("")
+	_, tokenA, err := store.Create("a", nil, nil, 0)
+	require.NoError(t, err)
+	_, tokenB, err := store.Create("b", nil, nil, 0)
+	require.NoError(t, err)
+
+	l := NewRateLimiter(RateLimitPolicy{Requests: 1, Window: time.Minute, Key: RateLimitByToken})
+	r := gin.New()
+	r.Use(AuthStore(store, auth.ScopeAPI), RateLimit(l, nil))
+	r.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, "success")
+	})
+
+	assert.Equal(t, http.StatusOK, doRequest(r, "10.0.0.1", tokenA).Code)
+	assert.Equal(t, http.StatusOK, doRequest(r, "10.0.0.1", tokenB).Code)
+	assert.Equal(t, http.StatusTooManyRequests, doRequest(r, "10.0.0.2", tokenA).Code)
+}
+
+func TestRateLimitCharsWithCustomBody(t *testing.T) {
+	l := NewRateLimiter(RateLimitPolicy{Chars: 100, Window: time.Minute, Key: RateLimitByIP})
+	body := func(c *gin.Context, status int, message string) interface{} {
+		return gin.H{"message": message}
+	}
+	r := newRateLimitedRouter(l, body, 60)
+
+	assert.Equal(t, http.StatusOK, doRequest(r, "10.0.0.1", "").Code)
+
+	w := doRequest(r, "10.0.0.1", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.JSONEq(t, `{"message":"Too many characters"}`, w.Body.String())
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
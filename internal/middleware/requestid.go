@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/xxnuo/MTranServer/internal/logger"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	RequestIDKey    = "request_id"
+
+	maxRequestIDLength = 128
+)
+
+// RequestID 读取或生成 X-Request-ID，写入 context 并在响应头中返回
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !isValidRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Set(RequestIDKey, id)
+		c.Request = c.Request.WithContext(logger.ContextWithRequestID(c.Request.Context(), id))
+		c.Writer.Header().Set(RequestIDHeader, id)
+
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("request.id", id))
+
+		c.Next()
+	}
+}
+
+// GetRequestID 获取当前请求的请求 ID
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(RequestIDKey)
+}
+
+// ErrorBody 构造带请求 ID 的错误响应体
+func ErrorBody(c *gin.Context, message string) gin.H {
+	body := gin.H{"error": message}
+	if id := GetRequestID(c); id != "" {
+		body[RequestIDKey] = id
+	}
+	return body
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// isValidRequestID 仅接受长度受限的可见 ASCII 字符，避免日志注入
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
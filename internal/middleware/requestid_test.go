@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xxnuo/MTranServer/internal/logger"
+)
+
+func TestRequestIDGenerated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var ctxID string
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/test", func(c *gin.Context) {
+		ctxID = logger.RequestIDFromContext(c.Request.Context())
+		c.String(http.StatusOK, GetRequestID(c))
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	r.ServeHTTP(w, req)
+
+	id := w.Header().Get(RequestIDHeader)
+	assert.Len(t, id, 32)
+	assert.Equal(t, id, w.Body.String())
+	assert.Equal(t, id, ctxID)
+}
+
+func TestRequestIDAcceptsIncoming(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, GetRequestID(c))
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, "abc-123", w.Header().Get(RequestIDHeader))
+	assert.Equal(t, "abc-123", w.Body.String())
+}
+
+func TestRequestIDRejectsInvalid(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, bad := range []string{"has space", "line\nbreak", strings.Repeat("a", 200)} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set(RequestIDHeader, bad)
+		r.ServeHTTP(w, req)
+
+		assert.NotEqual(t, bad, w.Header().Get(RequestIDHeader))
+		assert.Len(t, w.Header().Get(RequestIDHeader), 32)
+	}
+}
+
+func TestRequestIDInErrorBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(RequestID())
+	r.Use(Auth("test-token"))
+	r.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set(RequestIDHeader, "req-42")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "Unauthorized", body["error"])
+	assert.Equal(t, "req-42", body[RequestIDKey])
+}
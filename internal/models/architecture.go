@@ -0,0 +1,140 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/utils"
+)
+
+// selectArchitecture 从记录中选择一种架构：按偏好顺序第一个可用的架构，
+// 没有偏好或偏好的架构都不可用时选择版本最新的架构，版本相同时按名称排序
+func selectArchitecture(records []RecordItem, prefs []string) string {
+	versions := make(map[string][]string)
+	for _, r := range records {
+		versions[r.Architecture] = append(versions[r.Architecture], r.Version)
+	}
+	for _, arch := range prefs {
+		if _, ok := versions[arch]; ok {
+			return arch
+		}
+	}
+
+	archs := make([]string, 0, len(versions))
+	for arch := range versions {
+		archs = append(archs, arch)
+	}
+	sort.Strings(archs)
+	best, bestVersion := "", ""
+	for _, arch := range archs {
+		v := utils.GetLargestVersion(versions[arch])
+		if bestVersion == "" || utils.CompareVersions(v, bestVersion) > 0 {
+			best, bestVersion = arch, v
+		}
+	}
+	return best
+}
+
+// TargetArchitecture 返回语言对按配置应使用的模型架构，记录中没有架构时返回空字符串
+func TargetArchitecture(fromLang, toLang string) string {
+	if GetRecords() == nil {
+		return ""
+	}
+	records, err := selectRecords(fromLang, toLang, config.GetConfig().ModelVersionFor(fromLang, toLang))
+	if err != nil || len(records) == 0 {
+		return ""
+	}
+	return records[0].Architecture
+}
+
+// InstalledArchitecture 按模型文件的名称和大小判断语言对目录中模型的架构，未知时返回空字符串
+func InstalledArchitecture(modelDir, fromLang, toLang string) string {
+	records := GetRecords()
+	if records == nil {
+		return ""
+	}
+	dir := PairDir(modelDir, fromLang, toLang)
+	for _, record := range records.Data {
+		if record.SourceLanguage != fromLang || record.TargetLanguage != toLang || record.FileType != "model" {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(dir, strings.TrimSuffix(record.Attachment.Filename, ".zst")))
+		if err != nil {
+			continue
+		}
+		if record.DecompressedSize == 0 || info.Size() == record.DecompressedSize {
+			return record.Architecture
+		}
+	}
+	return ""
+}
+
+// PairInfo 支持的语言对及其使用的模型
+type PairInfo struct {
+	From string `json:"from" example:"en"`
+	To   string `json:"to" example:"ja"`
+	// Architecture 已下载的模型架构，未下载时为按配置应使用的架构
+	Architecture string `json:"architecture,omitempty" example:"base-memory"`
+	// Local 使用本地注册的模型
+	Local bool `json:"local,omitempty"`
+}
+
+// SupportedPairs 返回记录和本地模型中的所有语言对，按语言对排序
+func SupportedPairs(modelDir string) []PairInfo {
+	seen := make(map[Pair]bool)
+	var pairs []PairInfo
+	if records := GetRecords(); records != nil {
+		for _, record := range records.Data {
+			p := Pair{From: record.SourceLanguage, To: record.TargetLanguage}
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			pairs = append(pairs, PairInfo{From: p.From, To: p.To})
+		}
+	}
+	for _, m := range LocalModels() {
+		if p := (Pair{From: m.From, To: m.To}); !seen[p] {
+			seen[p] = true
+			pairs = append(pairs, PairInfo{From: p.From, To: p.To})
+		}
+	}
+
+	for i := range pairs {
+		p := &pairs[i]
+		if UsesLocalModel(p.From, p.To) {
+			p.Local = true
+			continue
+		}
+		if p.Architecture = InstalledArchitecture(modelDir, p.From, p.To); p.Architecture == "" {
+			p.Architecture = TargetArchitecture(p.From, p.To)
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].From != pairs[j].From {
+			return pairs[i].From < pairs[j].From
+		}
+		return pairs[i].To < pairs[j].To
+	})
+	return pairs
+}
+
+// removeStaleFiles 删除语言对目录中其他版本或架构使用、当前记录不再使用的模型文件，
+// 避免 Worker 读取到不同架构的文件，调用前需持有语言对的锁
+func removeStaleFiles(dir string, p Pair, current []RecordItem) {
+	keep := make(map[string]bool, len(current))
+	for _, record := range current {
+		keep[strings.TrimSuffix(record.Attachment.Filename, ".zst")] = true
+	}
+	for _, record := range GetRecords().Data {
+		if record.SourceLanguage != p.From || record.TargetLanguage != p.To {
+			continue
+		}
+		if name := strings.TrimSuffix(record.Attachment.Filename, ".zst"); !keep[name] {
+			os.Remove(filepath.Join(dir, name))
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xxnuo/MTranServer/internal/config"
+)
+
+func setupArchitectureRecords(t *testing.T) {
+	record := func(arch, version, fileType, name string, size int64) RecordItem {
+		return RecordItem{SourceLanguage: "en", TargetLanguage: "ko", Architecture: arch, Version: version,
+			FileType: fileType, DecompressedSize: size, Attachment: Attachment{Filename: name + ".zst"}}
+	}
+	useTestGlobals(t, &config.Config{ModelDir: t.TempDir()},
+		record("base", "3.0", "model", "model.enko.intgemm.alphas.bin", 6),
+		record("base", "3.0", "lex", "lex.50.50.enko.s2t.bin", 3),
+		record("base", "3.0", "vocab", "vocab.enko.spm", 3),
+		record("base-memory", "3.1", "model", "model.enko.intgemm.alphas.bin", 4),
+		record("base-memory", "3.1", "lex", "lex.50.50.enko.s2t.bin", 3),
+		record("base-memory", "3.1", "srcvocab", "srcvocab.enko.spm", 3),
+		record("base-memory", "3.1", "trgvocab", "trgvocab.enko.spm", 3),
+		record("tiny", "1.0", "model", "model.enko.intgemm8.bin", 2),
+		record("tiny", "1.0", "lex", "lex.enko.s2t.bin", 3),
+		record("tiny", "1.0", "vocab", "vocab.enko.spm", 3),
+	)
+}
+
+func TestSelectRecordsArchitecture(t *testing.T) {
+	setupArchitectureRecords(t)
+
+	archOf := func(records []RecordItem) []string {
+		var archs []string
+		for _, r := range records {
+			archs = append(archs, r.Architecture)
+		}
+		return archs
+	}
+
+	// 没有偏好时使用版本最新的架构，不混用其他架构的文件
+	records, err := selectRecords("en", "ko", "")
+	require.NoError(t, err)
+	assert.Len(t, records, 4)
+	assert.Equal(t, []string{"base-memory", "base-memory", "base-memory", "base-memory"}, archOf(records))
+	assert.Equal(t, "base-memory", TargetArchitecture("en", "ko"))
+
+	config.GlobalConfig.ModelArchitecture = "fast"
+	records, err = selectRecords("en", "ko", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tiny", "tiny", "tiny"}, archOf(records))
+
+	// 固定的版本中没有偏好的架构时使用该版本的架构
+	config.GlobalConfig.Pairs = map[string]config.PairConfig{"en-ko": {Version: "3.0"}}
+	records, err = selectRecords("en", "ko", "3.0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"base", "base", "base"}, archOf(records))
+	assert.Equal(t, "base", TargetArchitecture("en", "ko"))
+}
+
+func TestInstalledArchitecture(t *testing.T) {
+	setupArchitectureRecords(t)
+	modelDir := config.GetConfig().ModelDir
+	dir := PairDir(modelDir, "en", "ko")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	assert.Equal(t, "", InstalledArchitecture(modelDir, "en", "ko"))
+
+	for name, size := range map[string]int{"model.enko.intgemm.alphas.bin": 6, "lex.50.50.enko.s2t.bin": 3, "vocab.enko.spm": 3} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, versionFileName), []byte("3.0\n"), 0644))
+	assert.Equal(t, "base", InstalledArchitecture(modelDir, "en", "ko"))
+	assert.Equal(t, "base", SupportedPairs(modelDir)[0].Architecture)
+
+	// 切换架构后删除旧架构的文件
+	records, err := selectRecords("en", "ko", "")
+	require.NoError(t, err)
+	removeStaleFiles(dir, Pair{From: "en", To: "ko"}, records)
+	assert.FileExists(t, filepath.Join(dir, "model.enko.intgemm.alphas.bin"))
+	assert.NoFileExists(t, filepath.Join(dir, "vocab.enko.spm"))
+}
@@ -0,0 +1,545 @@
+package models
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/logger"
+	"github.com/xxnuo/MTranServer/internal/utils"
+)
+
+const (
+	// bundleFormat 模型包格式版本，格式不兼容时递增
+	bundleFormat = 1
+
+	bundleManifestName = "manifest.json"
+	bundleRecordsName  = "records.json"
+	bundleModelsPrefix = "models/"
+
+	// importedRecordsFileName 保存导入的记录，加载 records.json 后合并
+	importedRecordsFileName = "records.imported.json"
+)
+
+var ErrInvalidBundle = errors.New("invalid model bundle")
+
+// Pair 语言对
+type Pair struct {
+	From string `json:"from" example:"en"`
+	To   string `json:"to" example:"ja"`
+}
+
+func (p Pair) String() string {
+	return pairKey(p.From, p.To)
+}
+
+// ParsePair 解析 from_to 形式的语言对，与模型目录名一致
+func ParsePair(s string) (Pair, error) {
+	from, to, ok := strings.Cut(s, "_")
+	if !ok || from == "" || to == "" {
+		return Pair{}, fmt.Errorf("invalid language pair %q, expected from_to such as en_ja", s)
+	}
+	return Pair{From: utils.NormalizeLanguageCode(from), To: utils.NormalizeLanguageCode(to)}, nil
+}
+
+// BundleFile 模型包中的文件，Path 相对于 models/ 目录
+type BundleFile struct {
+	Path   string `json:"path" example:"en_ja/model.enja.intgemm.alphas.bin"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// BundleManifest 模型包清单，位于包的第一个文件
+type BundleManifest struct {
+	Format    int          `json:"format"`
+	CreatedAt time.Time    `json:"created_at"`
+	Pairs     []Pair       `json:"pairs"`
+	Files     []BundleFile `json:"files"`
+}
+
+type bundlePair struct {
+	Pair
+	dir     string
+	records []RecordItem
+	files   []BundleFile
+}
+
+// ResolvePairs 补全中转翻译路径上的语言对，返回去重并排序后的语言对
+func ResolvePairs(pairs []Pair) ([]Pair, error) {
+	if GetRecords() == nil {
+		if err := InitRecords(); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg := config.GetConfig()
+	graph := recordsGraph(GetRecords())
+	seen := make(map[Pair]bool)
+	var resolved []Pair
+	for _, p := range pairs {
+		if p.From == p.To {
+			return nil, fmt.Errorf("source and target language are the same: %s", p.From)
+		}
+		route, err := graph.Route(p.From, p.To, cfg.PivotLanguage, cfg.PivotHops())
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s -> %s", ErrPairNotSupported, p.From, p.To)
+		}
+		for _, leg := range RoutePairs(route) {
+			if !seen[leg] {
+				seen[leg] = true
+				resolved = append(resolved, leg)
+			}
+		}
+	}
+
+	sort.Slice(resolved, func(i, j int) bool {
+		return resolved[i].String() < resolved[j].String()
+	})
+	return resolved, nil
+}
+
+// ExportBundle 将语言对已下载的模型文件及其记录写入模型包
+// 中转翻译所需的语言对会一并导出
+func ExportBundle(w io.Writer, modelDir string, pairs []Pair) (*BundleManifest, error) {
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("no language pairs to export")
+	}
+	pairs, err := ResolvePairs(pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按排序后的顺序加锁，导出期间模型不会被下载或删除
+	for _, p := range pairs {
+		unlock := lockPair(p.From, p.To)
+		defer unlock()
+	}
+
+	manifest := &BundleManifest{
+		Format:    bundleFormat,
+		CreatedAt: time.Now().UTC(),
+		Pairs:     pairs,
+	}
+	var records []RecordItem
+	bundled := make([]*bundlePair, 0, len(pairs))
+	for _, p := range pairs {
+		bp, err := collectPair(modelDir, p)
+		if err != nil {
+			return nil, err
+		}
+		bundled = append(bundled, bp)
+		records = append(records, bp.records...)
+		manifest.Files = append(manifest.Files, bp.files...)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if err := writeBundleJSON(tw, bundleManifestName, manifest); err != nil {
+		return nil, err
+	}
+	if err := writeBundleJSON(tw, bundleRecordsName, RecordsData{Data: records}); err != nil {
+		return nil, err
+	}
+	for _, bp := range bundled {
+		for _, f := range bp.files {
+			if err := writeBundleFile(tw, filepath.Join(bp.dir, path.Base(f.Path)), f); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	logger.Info("Exported %d language pair(s), %d file(s)", len(manifest.Pairs), len(manifest.Files))
+	return manifest, nil
+}
+
+// collectPair 找出语言对目录中与记录哈希一致的模型文件
+func collectPair(modelDir string, p Pair) (*bundlePair, error) {
+	if !IsModelDownloaded(modelDir, p.From, p.To) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrModelNotFound, p.From, p.To)
+	}
+
+	bp := &bundlePair{Pair: p, dir: PairDir(modelDir, p.From, p.To)}
+	hashes := make(map[string]string)
+	for _, record := range GetRecords().Data {
+		if record.SourceLanguage != p.From || record.TargetLanguage != p.To {
+			continue
+		}
+		name := strings.TrimSuffix(record.Attachment.Filename, ".zst")
+		hash, ok := hashes[name]
+		if !ok {
+			info, err := os.Stat(filepath.Join(bp.dir, name))
+			if err != nil {
+				continue
+			}
+			if hash, err = utils.ComputeSHA256(filepath.Join(bp.dir, name)); err != nil {
+				return nil, err
+			}
+			hashes[name] = hash
+			bp.files = append(bp.files, BundleFile{
+				Path:   path.Join(p.String(), name),
+				Size:   info.Size(),
+				SHA256: hash,
+			})
+		}
+		if record.DecompressedHash == hash {
+			bp.records = append(bp.records, record)
+		}
+	}
+
+	// 每个文件都需要有对应的记录，否则导入后无法使用
+	for _, f := range bp.files {
+		if !hasRecordForFile(bp.records, path.Base(f.Path)) {
+			return nil, fmt.Errorf("model file %s does not match any record, download the model again", f.Path)
+		}
+	}
+	return bp, nil
+}
+
+func hasRecordForFile(records []RecordItem, name string) bool {
+	for _, r := range records {
+		if strings.TrimSuffix(r.Attachment.Filename, ".zst") == name {
+			return true
+		}
+	}
+	return false
+}
+
+func writeBundleJSON(tw *tar.Writer, name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+func writeBundleFile(tw *tar.Writer, src string, f BundleFile) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hdr := &tar.Header{
+		Name:    bundleModelsPrefix + f.Path,
+		Mode:    0644,
+		Size:    f.Size,
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	// 文件在统计大小后被修改时 tar 会返回错误
+	if _, err := io.CopyN(tw, file, f.Size); err != nil {
+		return fmt.Errorf("failed to write %s to bundle: %w", f.Path, err)
+	}
+	return nil
+}
+
+// ImportBundle 校验并导入模型包，所有文件校验通过后才会写入模型目录，
+// 包中的记录合并到当前记录并保存，离线模式下同样可用
+func ImportBundle(r io.Reader, modelDir string) (*BundleManifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	var manifest BundleManifest
+	if err := readBundleJSON(tr, bundleManifestName, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Format != bundleFormat {
+		return nil, fmt.Errorf("%w: unsupported format %d", ErrInvalidBundle, manifest.Format)
+	}
+	var records RecordsData
+	if err := readBundleJSON(tr, bundleRecordsName, &records); err != nil {
+		return nil, err
+	}
+
+	expected, err := checkManifest(&manifest, records.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(modelDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create model directory: %w", err)
+	}
+	// 暂存在模型目录中，保证移动文件时在同一文件系统
+	staging, err := os.MkdirTemp(modelDir, ".import-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	received := make(map[string]bool)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+
+		name, ok := strings.CutPrefix(hdr.Name, bundleModelsPrefix)
+		f, listed := expected[name]
+		if !ok || !listed || received[name] {
+			return nil, fmt.Errorf("%w: unexpected file %s", ErrInvalidBundle, hdr.Name)
+		}
+		if err := stageBundleFile(tr, filepath.Join(staging, filepath.FromSlash(name)), f); err != nil {
+			return nil, err
+		}
+		received[name] = true
+	}
+	for name := range expected {
+		if !received[name] {
+			return nil, fmt.Errorf("%w: missing file %s", ErrInvalidBundle, name)
+		}
+	}
+
+	for _, p := range manifest.Pairs {
+		if err := installPair(staging, modelDir, p, manifest.Files, records.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	// 只合并包中语言对的记录
+	var merged []RecordItem
+	for _, r := range records.Data {
+		for _, p := range manifest.Pairs {
+			if r.SourceLanguage == p.From && r.TargetLanguage == p.To {
+				merged = append(merged, r)
+				break
+			}
+		}
+	}
+	if err := MergeRecords(merged); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Imported %d language pair(s), %d file(s)", len(manifest.Pairs), len(manifest.Files))
+	return &manifest, nil
+}
+
+func readBundleJSON(tr *tar.Reader, name string, v any) error {
+	hdr, err := tr.Next()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	if hdr.Name != name {
+		return fmt.Errorf("%w: expected %s, got %s", ErrInvalidBundle, name, hdr.Name)
+	}
+	if err := json.NewDecoder(tr).Decode(v); err != nil {
+		return fmt.Errorf("%w: failed to parse %s: %v", ErrInvalidBundle, name, err)
+	}
+	return nil
+}
+
+// checkManifest 检查清单中的路径和哈希，每个文件都需与包中记录的 DecompressedHash 一致
+func checkManifest(m *BundleManifest, records []RecordItem) (map[string]BundleFile, error) {
+	pairs := make(map[string]bool)
+	for _, p := range m.Pairs {
+		if p.From == "" || p.To == "" || strings.ContainsAny(p.From+p.To, `_/\.`) {
+			return nil, fmt.Errorf("%w: invalid language pair %s -> %s", ErrInvalidBundle, p.From, p.To)
+		}
+		pairs[p.String()] = true
+	}
+
+	expected := make(map[string]BundleFile, len(m.Files))
+	covered := make(map[string]bool)
+	for _, f := range m.Files {
+		dir, name := path.Split(f.Path)
+		dir = strings.TrimSuffix(dir, "/")
+		if !pairs[dir] || name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return nil, fmt.Errorf("%w: invalid file path %s", ErrInvalidBundle, f.Path)
+		}
+
+		matched := false
+		for _, r := range records {
+			if pairKey(r.SourceLanguage, r.TargetLanguage) == dir &&
+				strings.TrimSuffix(r.Attachment.Filename, ".zst") == name {
+				if r.DecompressedHash != f.SHA256 {
+					return nil, fmt.Errorf("%w: %s hash does not match its record", ErrInvalidBundle, f.Path)
+				}
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("%w: no record for %s", ErrInvalidBundle, f.Path)
+		}
+		expected[f.Path] = f
+		covered[dir] = true
+	}
+	for pair := range pairs {
+		if !covered[pair] {
+			return nil, fmt.Errorf("%w: no files for %s", ErrInvalidBundle, pair)
+		}
+	}
+	return expected, nil
+}
+
+// stageBundleFile 写入暂存目录并校验大小和哈希
+func stageBundleFile(r io.Reader, dst string, f BundleFile) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(out, io.TeeReader(r, h))
+	if err != nil {
+		return fmt.Errorf("%w: failed to read %s: %v", ErrInvalidBundle, f.Path, err)
+	}
+	if n != f.Size {
+		return fmt.Errorf("%w: %s size mismatch: expected %d, got %d", ErrInvalidBundle, f.Path, f.Size, n)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != f.SHA256 {
+		return fmt.Errorf("%w: %s SHA256 mismatch: expected %s, got %s", ErrInvalidBundle, f.Path, f.SHA256, sum)
+	}
+	return out.Close()
+}
+
+// installPair 将暂存的文件移动到语言对目录，覆盖同名文件
+func installPair(staging, modelDir string, p Pair, files []BundleFile, records []RecordItem) error {
+	unlock := lockPair(p.From, p.To)
+	defer unlock()
+
+	dir := PairDir(modelDir, p.From, p.To)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create language pair directory: %w", err)
+	}
+
+	var installed []RecordItem
+	for _, f := range files {
+		if path.Dir(f.Path) != p.String() {
+			continue
+		}
+		name := path.Base(f.Path)
+		if err := os.Rename(filepath.Join(staging, filepath.FromSlash(f.Path)), filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("failed to install %s: %w", f.Path, err)
+		}
+		for _, r := range records {
+			if pairKey(r.SourceLanguage, r.TargetLanguage) == p.String() &&
+				strings.TrimSuffix(r.Attachment.Filename, ".zst") == name {
+				installed = append(installed, r)
+			}
+		}
+	}
+	writeVersionFile(dir, installed)
+	return nil
+}
+
+// MergeRecords 将记录合并到当前记录，并保存到配置目录以便重启后继续使用
+// 与刷新记录一样通知订阅者，新增的语言可以立即被检测
+func MergeRecords(records []RecordItem) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if GetRecords() == nil {
+		if err := InitRecords(); err != nil {
+			return err
+		}
+	}
+
+	refreshMu.Lock()
+	defer refreshMu.Unlock()
+
+	importedPath := filepath.Join(config.GetConfig().ConfigDir, importedRecordsFileName)
+	var imported RecordsData
+	if data, err := os.ReadFile(importedPath); err == nil {
+		if err := json.Unmarshal(data, &imported); err != nil {
+			logger.Warn("Failed to parse %s, replacing it: %v", importedPath, err)
+		}
+	}
+	imported.Data = mergeRecordItems(imported.Data, records)
+
+	data, err := json.Marshal(imported)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(importedPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to save imported records: %w", err)
+	}
+
+	merged := &RecordsData{Data: mergeRecordItems(append([]RecordItem(nil), GetRecords().Data...), records)}
+	replaceRecords(getBaseRecords(), merged)
+	return nil
+}
+
+// mergeImportedRecords 合并之前导入的记录，文件不存在时不做处理
+func mergeImportedRecords(records *RecordsData) {
+	importedPath := filepath.Join(config.GetConfig().ConfigDir, importedRecordsFileName)
+	data, err := os.ReadFile(importedPath)
+	if err != nil {
+		return
+	}
+	var imported RecordsData
+	if err := json.Unmarshal(data, &imported); err != nil {
+		logger.Warn("Failed to parse imported records %s: %v", importedPath, err)
+		return
+	}
+	records.Data = mergeRecordItems(records.Data, imported.Data)
+	logger.Debug("Merged %d imported model records", len(imported.Data))
+}
+
+// mergeRecordItems 追加 dst 中不存在的记录
+func mergeRecordItems(dst, src []RecordItem) []RecordItem {
+	seen := make(map[string]bool, len(dst))
+	for _, r := range dst {
+		seen[recordKey(r)] = true
+	}
+	for _, r := range src {
+		if key := recordKey(r); !seen[key] {
+			seen[key] = true
+			dst = append(dst, r)
+		}
+	}
+	return dst
+}
+
+func recordKey(r RecordItem) string {
+	if r.ID != "" {
+		return r.ID
+	}
+	return strings.Join([]string{r.SourceLanguage, r.TargetLanguage, r.Attachment.Filename, r.Version}, "/")
+}
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xxnuo/MTranServer/internal/config"
+)
+
+// setupBundleTest 准备 de -> en -> ja 两段模型，返回源模型目录
+func setupBundleTest(t *testing.T) string {
+	modelDir := t.TempDir()
+	useTestGlobals(t, &config.Config{ConfigDir: t.TempDir(), ModelDir: modelDir})
+
+	for _, pair := range []Pair{{"de", "en"}, {"en", "ja"}} {
+		records := writeTestModel(t, PairDir(modelDir, pair.From, pair.To), pair, "1.0", pair.String())
+		GlobalRecords.Data = append(GlobalRecords.Data, records...)
+	}
+	return modelDir
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	modelDir := setupBundleTest(t)
+	exported := GlobalRecords
+
+	var buf bytes.Buffer
+	manifest, err := ExportBundle(&buf, modelDir, []Pair{{"de", "ja"}})
+	require.NoError(t, err)
+	// 需要中转的语言对导出两段模型
+	assert.Equal(t, []Pair{{"de", "en"}, {"en", "ja"}}, manifest.Pairs)
+	assert.Len(t, manifest.Files, 6)
+
+	// 在没有这些记录的空环境中导入
+	targetDir := t.TempDir()
+	configDir := t.TempDir()
+	config.GlobalConfig = &config.Config{ConfigDir: configDir, ModelDir: targetDir}
+	GlobalRecords = &RecordsData{}
+
+	imported, err := ImportBundle(&buf, targetDir)
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Pairs, imported.Pairs)
+
+	assert.True(t, GlobalRecords.HasLanguagePair("de", "en"))
+	assert.True(t, GlobalRecords.HasLanguagePair("en", "ja"))
+	assert.Len(t, GlobalRecords.Data, len(exported.Data))
+	assert.True(t, IsModelDownloaded(targetDir, "de", "en"))
+	assert.True(t, IsModelDownloaded(targetDir, "en", "ja"))
+	assert.Equal(t, "1.0", GetModelStatus(targetDir, "en", "ja").Version)
+
+	// 暂存目录已清理
+	entries, err := os.ReadDir(targetDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	// 重新加载记录时合并导入的记录
+	require.NoError(t, loadRecordsFromBytes([]byte(`{"data":[]}`)))
+	assert.True(t, GlobalRecords.HasLanguagePair("de", "en"))
+	assert.Len(t, GlobalRecords.Data, len(exported.Data))
+}
+
+func TestExportBundleNotDownloaded(t *testing.T) {
+	modelDir := setupBundleTest(t)
+	require.NoError(t, os.RemoveAll(PairDir(modelDir, "en", "ja")))
+
+	_, err := ExportBundle(io.Discard, modelDir, []Pair{{"de", "ja"}})
+	assert.True(t, errors.Is(err, ErrModelNotFound))
+
+	_, err = ExportBundle(io.Discard, modelDir, []Pair{{"fr", "en"}})
+	assert.True(t, errors.Is(err, ErrPairNotSupported))
+}
+
+func TestExportBundleCorruptedFile(t *testing.T) {
+	modelDir := setupBundleTest(t)
+	require.NoError(t, os.WriteFile(filepath.Join(PairDir(modelDir, "en", "ja"), "lex.enja.bin"), []byte("corrupted"), 0644))
+
+	_, err := ExportBundle(io.Discard, modelDir, []Pair{{"en", "ja"}})
+	assert.ErrorContains(t, err, "does not match any record")
+}
+
+func TestImportBundleTampered(t *testing.T) {
+	modelDir := setupBundleTest(t)
+
+	var buf bytes.Buffer
+	_, err := ExportBundle(&buf, modelDir, []Pair{{"en", "ja"}})
+	require.NoError(t, err)
+
+	// 替换一个模型文件的内容，保持大小不变
+	tampered := rewriteBundle(t, buf.Bytes(), func(hdr *tar.Header, data []byte) []byte {
+		if hdr.Name == bundleModelsPrefix+"en_ja/lex.enja.bin" {
+			return bytes.Repeat([]byte("x"), len(data))
+		}
+		return data
+	})
+
+	targetDir := t.TempDir()
+	config.GlobalConfig = &config.Config{ConfigDir: t.TempDir(), ModelDir: targetDir}
+	GlobalRecords = &RecordsData{}
+
+	_, err = ImportBundle(bytes.NewReader(tampered), targetDir)
+	assert.True(t, errors.Is(err, ErrInvalidBundle))
+	assert.ErrorContains(t, err, "SHA256 mismatch")
+
+	// 校验失败时不写入任何文件和记录
+	entries, err := os.ReadDir(targetDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.Empty(t, GlobalRecords.Data)
+}
+
+func TestImportBundleRejectsPathTraversal(t *testing.T) {
+	modelDir := setupBundleTest(t)
+
+	var buf bytes.Buffer
+	_, err := ExportBundle(&buf, modelDir, []Pair{{"en", "ja"}})
+	require.NoError(t, err)
+
+	bad := rewriteBundle(t, buf.Bytes(), func(hdr *tar.Header, data []byte) []byte {
+		if hdr.Name == bundleModelsPrefix+"en_ja/lex.enja.bin" {
+			hdr.Name = bundleModelsPrefix + "en_ja/../../lex.enja.bin"
+		}
+		return data
+	})
+
+	targetDir := t.TempDir()
+	_, err = ImportBundle(bytes.NewReader(bad), targetDir)
+	assert.True(t, errors.Is(err, ErrInvalidBundle))
+}
+
+func TestMergeRecordsNotifiesSubscribers(t *testing.T) {
+	setupBundleTest(t)
+
+	diffs, cancel := SubscribeRecords()
+	defer cancel()
+
+	records := writeTestModel(t, t.TempDir(), Pair{"fr", "en"}, "1.0", "fr_en")
+	require.NoError(t, MergeRecords(records))
+	assert.True(t, GetRecords().HasLanguagePair("fr", "en"))
+
+	// 与刷新记录一样推送差异，检测器和升级任务可以及时处理新增的语言
+	select {
+	case diff := <-diffs:
+		assert.Equal(t, []PairChange{{From: "fr", To: "en", Version: "1.0"}}, diff.Added)
+		assert.True(t, diff.LanguagesChanged)
+	default:
+		t.Fatal("no records change published")
+	}
+}
+
+func TestParsePair(t *testing.T) {
+	p, err := ParsePair("zh-Hans_en")
+	require.NoError(t, err)
+	assert.Equal(t, Pair{From: "zh-Hans", To: "en"}, p)
+
+	_, err = ParsePair("en-ja")
+	assert.Error(t, err)
+}
+
+// rewriteBundle 逐个文件改写模型包
+func rewriteBundle(t *testing.T, bundle []byte, fn func(hdr *tar.Header, data []byte) []byte) []byte {
+	gr, err := gzip.NewReader(bytes.NewReader(bundle))
+	require.NoError(t, err)
+	tr := tar.NewReader(gr)
+
+	var out bytes.Buffer
+	gw := gzip.NewWriter(&out)
+	tw := tar.NewWriter(gw)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+
+		data = fn(hdr, data)
+		hdr.Size = int64(len(data))
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err = tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return out.Bytes()
+}
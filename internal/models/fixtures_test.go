@@ -0,0 +1,45 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xxnuo/MTranServer/internal/config"
+)
+
+// useTestGlobals 替换全局配置和记录，测试结束后恢复
+func useTestGlobals(t *testing.T, cfg *config.Config, records ...RecordItem) {
+	oldConfig, oldRecords := config.GlobalConfig, GlobalRecords
+	t.Cleanup(func() {
+		config.GlobalConfig = oldConfig
+		GlobalRecords = oldRecords
+	})
+
+	config.GlobalConfig = cfg
+	GlobalRecords = &RecordsData{Data: records}
+}
+
+// writeTestModel 在 dir 中写入语言对的 model、lex、vocab 文件，内容为 "<tag>/<文件类型>"，返回与文件匹配的记录
+func writeTestModel(t *testing.T, dir string, pair Pair, version, tag string) []RecordItem {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+
+	var records []RecordItem
+	for _, fileType := range []string{"model", "lex", "vocab"} {
+		name := fileType + "." + pair.From + pair.To + ".bin"
+		content := []byte(tag + "/" + fileType)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), content, 0644))
+		records = append(records, RecordItem{
+			ID:               tag + "-" + fileType,
+			SourceLanguage:   pair.From,
+			TargetLanguage:   pair.To,
+			Version:          version,
+			FileType:         fileType,
+			Attachment:       Attachment{Filename: name + ".zst"},
+			DecompressedHash: computeHash(content),
+			DecompressedSize: int64(len(content)),
+		})
+	}
+	return records
+}
@@ -0,0 +1,224 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/logger"
+)
+
+// staleImportAge 超过该时间的导入暂存目录视为中断的导入
+const staleImportAge = time.Hour
+
+// GCResult 清理结果，Removed 为相对于模型目录的路径
+type GCResult struct {
+	Removed    []string `json:"removed"`
+	FreedBytes int64    `json:"freed_bytes"`
+	DryRun     bool     `json:"dry_run"`
+}
+
+type collector struct {
+	modelDir string
+	dryRun   bool
+	result   *GCResult
+}
+
+func (c *collector) remove(path string) {
+	size := dirSize(path)
+	if !c.dryRun {
+		if err := os.RemoveAll(path); err != nil {
+			logger.Warn("Failed to remove %s: %v", path, err)
+			return
+		}
+	}
+	c.record(path, size)
+}
+
+func (c *collector) record(path string, size int64) {
+	rel, err := filepath.Rel(c.modelDir, path)
+	if err != nil {
+		rel = path
+	}
+	c.result.Removed = append(c.result.Removed, filepath.ToSlash(rel))
+	c.result.FreedBytes += size
+}
+
+// GC 清理模型目录：记录中已不存在的语言对、被新版本取代的文件、
+// 中断的下载与解压留下的临时文件、隔离的损坏文件、未使用的暂存版本，以及超出保留数量和磁盘配额的旧版本
+// dryRun 为 true 时只返回将要删除的内容
+func GC(modelDir string, dryRun bool) (*GCResult, error) {
+	if GetRecords() == nil {
+		if err := InitRecords(); err != nil {
+			return nil, err
+		}
+	}
+
+	c := &collector{modelDir: modelDir, dryRun: dryRun, result: &GCResult{Removed: []string{}, DryRun: dryRun}}
+	entries, err := os.ReadDir(modelDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c.result, nil
+		}
+		return nil, err
+	}
+
+	pairs := make(map[string]Pair)
+	for _, record := range GetRecords().Data {
+		p := Pair{From: record.SourceLanguage, To: record.TargetLanguage}
+		pairs[p.String()] = p
+	}
+
+	cfg := config.GetConfig()
+	for _, e := range entries {
+		path := filepath.Join(modelDir, e.Name())
+		switch {
+		case !e.IsDir():
+			continue
+		case e.Name() == quarantineDirName:
+			c.remove(path)
+		case strings.HasPrefix(e.Name(), ".import-"):
+			if info, err := e.Info(); err == nil && time.Since(info.ModTime()) > staleImportAge {
+				c.remove(path)
+			}
+		case e.Name() == versionsDirName:
+			c.collectVersions(pairs, cfg.ModelKeepVersions)
+		case e.Name() == stagingDirName:
+			c.collectStaging()
+		case e.Name() == localDirName:
+			c.collectLocal()
+		case strings.HasPrefix(e.Name(), "."):
+			continue
+		default:
+			p, ok := pairs[e.Name()]
+			if !ok {
+				if strings.Contains(e.Name(), "_") {
+					c.remove(path)
+				}
+				continue
+			}
+			c.collectPair(p)
+		}
+	}
+
+	if cfg.ModelDiskQuota > 0 {
+		removed := make(map[string]bool, len(c.result.Removed))
+		for _, rel := range c.result.Removed {
+			removed[filepath.Join(modelDir, filepath.FromSlash(rel))] = true
+		}
+		used := dirSize(modelDir)
+		if dryRun {
+			used -= c.result.FreedBytes
+		}
+		for _, v := range enforceQuota(modelDir, int64(cfg.ModelDiskQuota)<<20, used, removed, dryRun) {
+			c.record(v.dir, v.size)
+		}
+	}
+
+	if len(c.result.Removed) > 0 && !dryRun {
+		logger.Info("Removed %d unused model path(s), freed %s", len(c.result.Removed), formatMB(c.result.FreedBytes))
+	}
+	return c.result, nil
+}
+
+// collectLocal 清理已不再注册的本地模型的工作目录
+func (c *collector) collectLocal() {
+	dir := filepath.Join(c.modelDir, localDirName)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		p, err := ParsePair(e.Name())
+		if err == nil && getLocalModel(p.From, p.To) != nil {
+			continue
+		}
+		c.remove(filepath.Join(dir, e.Name()))
+	}
+}
+
+// collectVersions 清理已不存在的语言对的旧版本，以及超出保留数量的旧版本
+func (c *collector) collectVersions(pairs map[string]Pair, keep int) {
+	root := filepath.Join(c.modelDir, versionsDirName)
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		p, ok := pairs[e.Name()]
+		if !ok {
+			c.remove(filepath.Join(root, e.Name()))
+			continue
+		}
+
+		unlock := lockPair(p.From, p.To)
+		active := installedVersion(PairDir(c.modelDir, p.From, p.To))
+		for _, dir := range pruneVersions(c.modelDir, p, keep, active, true) {
+			c.remove(dir)
+		}
+		unlock()
+	}
+}
+
+// collectStaging 清理没有 Worker 使用的暂存版本，包括中断的滚动升级留下的文件
+func (c *collector) collectStaging() {
+	root := filepath.Join(c.modelDir, stagingDirName)
+	pairs, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	for _, pe := range pairs {
+		versions, _ := os.ReadDir(filepath.Join(root, pe.Name()))
+		for _, ve := range versions {
+			dir := filepath.Join(root, pe.Name(), ve.Name())
+			if !isStaged(dir) {
+				c.remove(dir)
+			}
+		}
+	}
+}
+
+// collectPair 清理语言对目录中不属于当前版本的模型文件和临时文件，不认识的文件保留
+func (c *collector) collectPair(p Pair) {
+	if IsDownloading(p.From, p.To) {
+		return
+	}
+	unlock := lockPair(p.From, p.To)
+	defer unlock()
+
+	dir := PairDir(c.modelDir, p.From, p.To)
+	active := installedVersion(dir)
+
+	known := make(map[string]bool)
+	current := make(map[string]bool)
+	for _, record := range GetRecords().Data {
+		if record.SourceLanguage != p.From || record.TargetLanguage != p.To {
+			continue
+		}
+		name := strings.TrimSuffix(record.Attachment.Filename, ".zst")
+		known[name] = true
+		if record.Version == active {
+			current[name] = true
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		name := e.Name()
+		switch {
+		case e.IsDir():
+			continue
+		case strings.HasSuffix(name, ".zst"), strings.HasSuffix(name, ".part"),
+			strings.HasSuffix(name, ".tmp"), strings.HasSuffix(name, ".restore"):
+			c.remove(filepath.Join(dir, name))
+		case active != "" && known[name] && !current[name]:
+			// 旧版本使用、当前版本不再使用的文件
+			c.remove(filepath.Join(dir, name))
+		}
+	}
+}
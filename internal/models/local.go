@@ -0,0 +1,256 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/logger"
+	"github.com/xxnuo/MTranServer/internal/utils"
+)
+
+const (
+	// localManifestFileName 本地模型目录中的清单文件
+	localManifestFileName = "models.json"
+	// localDirName 模型目录下本地模型的工作目录，结构为 .local/<from>_<to>/
+	localDirName = ".local"
+)
+
+// LocalModel 本地注册的 Bergamot 模型，路径相对于本地模型目录
+// 文件名需要符合 Bergamot 的命名，与 Mozilla 的模型相同
+type LocalModel struct {
+	From    string `json:"from" example:"en"`
+	To      string `json:"to" example:"de"`
+	Version string `json:"version,omitempty" example:"legal-1"`
+	Model   string `json:"model" example:"legal-ende/model.ende.intgemm.alphas.bin"`
+	Lex     string `json:"lex" example:"legal-ende/lex.50.50.ende.s2t.bin"`
+	// Vocab 共享词表，源语言和目标语言词表不同时使用 SrcVocab 和 TrgVocab
+	Vocab    string `json:"vocab,omitempty" example:"legal-ende/vocab.ende.spm"`
+	SrcVocab string `json:"srcvocab,omitempty"`
+	TrgVocab string `json:"trgvocab,omitempty"`
+	// Override 记录中也有该语言对时使用本地模型
+	Override bool `json:"override,omitempty"`
+}
+
+type localManifest struct {
+	Models []LocalModel `json:"models"`
+}
+
+var (
+	localMu     sync.RWMutex
+	localModels = make(map[Pair]*LocalModel)
+)
+
+// files 返回与 GetModelFiles 相同格式的文件路径
+func (m *LocalModel) files() map[string]string {
+	files := map[string]string{"model": m.Model, "lex": m.Lex, "vocab_src": m.Vocab, "vocab_trg": m.Vocab}
+	if m.Vocab == "" {
+		files["vocab_src"] = m.SrcVocab
+		files["vocab_trg"] = m.TrgVocab
+	}
+	return files
+}
+
+// resolve 校验清单中的模型并将路径转换为绝对路径
+func (m *LocalModel) resolve(dir string) error {
+	m.From, m.To = utils.NormalizeLanguageCode(m.From), utils.NormalizeLanguageCode(m.To)
+	if m.From == "" || m.To == "" || m.From == m.To {
+		return fmt.Errorf("invalid language pair %s -> %s", m.From, m.To)
+	}
+	if m.Model == "" || m.Lex == "" {
+		return fmt.Errorf("model and lex are required")
+	}
+	if m.Vocab == "" && (m.SrcVocab == "" || m.TrgVocab == "") {
+		return fmt.Errorf("vocab or both srcvocab and trgvocab are required")
+	}
+	for _, path := range []*string{&m.Model, &m.Lex, &m.Vocab, &m.SrcVocab, &m.TrgVocab} {
+		if *path == "" {
+			continue
+		}
+		if !filepath.IsAbs(*path) {
+			*path = filepath.Join(dir, *path)
+		}
+		if _, err := os.Stat(*path); err != nil {
+			return err
+		}
+	}
+	if m.Version == "" {
+		m.Version = "local"
+	}
+	return nil
+}
+
+// LoadLocalModels 读取 LocalModelsDir 中的清单并替换已注册的本地模型，未配置时清空
+// 无效的模型会被跳过，语言对变化时通知记录订阅者
+func LoadLocalModels() error {
+	dir := config.GetConfig().LocalModelsDir
+	loaded := make(map[Pair]*LocalModel)
+	var err error
+	if dir != "" {
+		loaded, err = readLocalManifest(dir)
+	}
+	if err != nil {
+		return err
+	}
+
+	localMu.Lock()
+	old := localModels
+	localModels = loaded
+	localMu.Unlock()
+	rebuildPairGraph()
+	if dir != "" {
+		logger.Info("Registered %d local model(s) from %s", len(loaded), dir)
+	}
+
+	diff := diffRecords(localRecords(old), localRecords(loaded))
+	if !diff.Empty() {
+		publishRecords(diff)
+	}
+	return nil
+}
+
+func readLocalManifest(dir string) (map[Pair]*LocalModel, error) {
+	path := filepath.Join(dir, localManifestFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local model manifest: %w", err)
+	}
+	var manifest localManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	loaded := make(map[Pair]*LocalModel, len(manifest.Models))
+	for i := range manifest.Models {
+		m := manifest.Models[i]
+		if err := m.resolve(dir); err != nil {
+			logger.Warn("Skipping local model %d (%s -> %s): %v", i, m.From, m.To, err)
+			continue
+		}
+		p := Pair{From: m.From, To: m.To}
+		if _, ok := loaded[p]; ok {
+			logger.Warn("Skipping duplicate local model for %s -> %s", m.From, m.To)
+			continue
+		}
+		loaded[p] = &m
+	}
+	return loaded, nil
+}
+
+// localRecords 将本地模型转换为记录，用于计算差异
+func localRecords(local map[Pair]*LocalModel) *RecordsData {
+	records := &RecordsData{Data: make([]RecordItem, 0, len(local))}
+	for p, m := range local {
+		records.Data = append(records.Data, RecordItem{SourceLanguage: p.From, TargetLanguage: p.To, Version: m.Version})
+	}
+	return records
+}
+
+// LocalModels 返回已注册的本地模型，按语言对排序
+func LocalModels() []LocalModel {
+	localMu.RLock()
+	list := make([]LocalModel, 0, len(localModels))
+	for _, m := range localModels {
+		list = append(list, *m)
+	}
+	localMu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].From != list[j].From {
+			return list[i].From < list[j].From
+		}
+		return list[i].To < list[j].To
+	})
+	return list
+}
+
+func getLocalModel(fromLang, toLang string) *LocalModel {
+	localMu.RLock()
+	defer localMu.RUnlock()
+	return localModels[Pair{From: fromLang, To: toLang}]
+}
+
+// UsesLocalModel 语言对使用本地注册的模型：记录中没有该语言对，或本地模型设置了 Override
+func UsesLocalModel(fromLang, toLang string) bool {
+	m := getLocalModel(fromLang, toLang)
+	if m == nil {
+		return false
+	}
+	if m.Override {
+		return true
+	}
+	records := GetRecords()
+	return records == nil || !records.HasLanguagePair(fromLang, toLang)
+}
+
+// SupportsPair 记录或本地模型中有该语言对
+func SupportsPair(fromLang, toLang string) bool {
+	if getLocalModel(fromLang, toLang) != nil {
+		return true
+	}
+	records := GetRecords()
+	return records != nil && records.HasLanguagePair(fromLang, toLang)
+}
+
+// PrepareLocalModel 将本地模型的文件链接到模型目录下的工作目录，返回目录和版本
+func PrepareLocalModel(modelDir, fromLang, toLang string) (string, string, error) {
+	m := getLocalModel(fromLang, toLang)
+	if m == nil {
+		return "", "", fmt.Errorf("%w: no local model for %s -> %s", ErrModelNotFound, fromLang, toLang)
+	}
+
+	unlock := lockPair(fromLang, toLang)
+	defer unlock()
+
+	dir := filepath.Join(modelDir, localDirName, pairKey(fromLang, toLang))
+	if localPrepared(dir, m) {
+		return dir, m.Version, nil
+	}
+	// 清除之前版本的文件，避免 Worker 读取到多个模型
+	if err := os.RemoveAll(dir); err != nil {
+		return "", "", fmt.Errorf("failed to clear local model directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create local model directory: %w", err)
+	}
+	linked := make(map[string]bool)
+	for _, src := range m.files() {
+		name := filepath.Base(src)
+		if linked[name] {
+			continue
+		}
+		linked[name] = true
+		if err := linkLocalFile(src, filepath.Join(dir, name)); err != nil {
+			return "", "", fmt.Errorf("failed to link %s: %w", src, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, versionFileName), []byte(m.Version+"\n"), 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write model version file: %w", err)
+	}
+	return dir, m.Version, nil
+}
+
+// localPrepared 工作目录中已经是该版本的全部文件
+func localPrepared(dir string, m *LocalModel) bool {
+	if installedVersion(dir) != m.Version {
+		return false
+	}
+	for _, src := range m.files() {
+		if _, err := os.Stat(filepath.Join(dir, filepath.Base(src))); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// linkLocalFile 优先使用符号链接，不支持时使用硬链接或复制
+func linkLocalFile(src, dst string) error {
+	if err := os.Symlink(src, dst); err == nil {
+		return nil
+	}
+	return linkOrCopy(src, dst)
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xxnuo/MTranServer/internal/config"
+)
+
+const testLocalManifest = `{"models":[
+	{"from":"en","to":"de","version":"legal-1","model":"legal/model.ende.bin","lex":"legal/lex.ende.bin","vocab":"shared/vocab.ende.spm","override":true},
+	{"from":"en","to":"xx","model":"xx/model.enxx.bin","lex":"xx/lex.enxx.bin","srcvocab":"xx/srcvocab.enxx.spm","trgvocab":"xx/trgvocab.enxx.spm"},
+	{"from":"en","to":"ja","model":"ja/model.enja.bin","lex":"ja/lex.enja.bin","vocab":"ja/vocab.enja.spm"},
+	{"from":"en","to":"ko","model":"missing/model.enko.bin","lex":"missing/lex.enko.bin","vocab":"missing/vocab.enko.spm"}
+]}`
+
+func setupLocalModels(t *testing.T) string {
+	t.Cleanup(func() {
+		localModels = make(map[Pair]*LocalModel)
+	})
+
+	dir := t.TempDir()
+	for _, name := range []string{
+		"legal/model.ende.bin", "legal/lex.ende.bin", "shared/vocab.ende.spm",
+		"xx/model.enxx.bin", "xx/lex.enxx.bin", "xx/srcvocab.enxx.spm", "xx/trgvocab.enxx.spm",
+		"ja/model.enja.bin", "ja/lex.enja.bin", "ja/vocab.enja.spm",
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(name), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, localManifestFileName), []byte(testLocalManifest), 0644))
+
+	useTestGlobals(t, &config.Config{ModelDir: t.TempDir(), LocalModelsDir: dir},
+		RecordItem{SourceLanguage: "en", TargetLanguage: "de", Version: "1.0", FileType: "model"},
+		RecordItem{SourceLanguage: "en", TargetLanguage: "ja", Version: "1.0", FileType: "model"},
+	)
+	return dir
+}
+
+func TestLoadLocalModels(t *testing.T) {
+	dir := setupLocalModels(t)
+	diffs, cancel := SubscribeRecords()
+	defer cancel()
+
+	require.NoError(t, LoadLocalModels())
+	local := LocalModels()
+	require.Len(t, local, 3)
+	assert.Equal(t, "local", local[2].Version)
+	assert.Equal(t, filepath.Join(dir, "xx/model.enxx.bin"), local[2].Model)
+
+	diff := <-diffs
+	assert.Len(t, diff.Added, 3)
+	assert.True(t, diff.LanguagesChanged)
+
+	// 设置 Override 或记录中没有的语言对使用本地模型
+	assert.True(t, UsesLocalModel("en", "de"))
+	assert.True(t, UsesLocalModel("en", "xx"))
+	assert.False(t, UsesLocalModel("en", "ja"))
+	assert.False(t, SupportsPair("en", "ko"))
+	assert.True(t, SupportsPair("en", "xx"))
+	assert.NoError(t, ValidateLanguagePair("en", "xx"))
+
+	files, err := GetModelFiles(config.GetConfig().ModelDir, "en", "xx")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "xx/trgvocab.enxx.spm"), files["vocab_trg"])
+
+	langs, err := GetSupportedLanguages()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"en", "de", "ja", "xx"}, langs)
+
+	status := GetModelStatus(config.GetConfig().ModelDir, "en", "de")
+	assert.True(t, status.Local)
+	assert.Equal(t, "legal-1", status.Version)
+	assert.True(t, status.Downloaded)
+}
+
+func TestPrepareLocalModel(t *testing.T) {
+	setupLocalModels(t)
+	require.NoError(t, LoadLocalModels())
+	modelDir := config.GetConfig().ModelDir
+
+	dir, version, err := PrepareLocalModel(modelDir, "en", "de")
+	require.NoError(t, err)
+	assert.Equal(t, "legal-1", version)
+	assert.Equal(t, filepath.Join(modelDir, localDirName, "en_de"), dir)
+	for _, name := range []string{"model.ende.bin", "lex.ende.bin", "vocab.ende.spm"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), name)
+	}
+	assert.Equal(t, "legal-1", installedVersion(dir))
+
+	// 注销后由 GC 清理工作目录
+	config.GlobalConfig.LocalModelsDir = ""
+	require.NoError(t, LoadLocalModels())
+	result, err := GC(modelDir, false)
+	require.NoError(t, err)
+	assert.Contains(t, result.Removed, ".local/en_de")
+	assert.NoDirExists(t, dir)
+}
@@ -0,0 +1,273 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/logger"
+	"github.com/xxnuo/MTranServer/internal/utils"
+)
+
+// versionFileName 记录语言对目录中已下载模型的版本
+const versionFileName = ".version"
+
+var (
+	ErrModelNotFound     = errors.New("model is not downloaded")
+	ErrPairNotSupported  = errors.New("language pair is not supported")
+	ErrDownloadInProcess = errors.New("model download is already in progress")
+	ErrModelBusy         = errors.New("model files are in use by another operation")
+)
+
+// ModelStatus 语言对模型状态
+type ModelStatus struct {
+	From string `json:"from" example:"en"`
+	To   string `json:"to" example:"ja"`
+	// Version 已下载的模型版本，未知时为空
+	Version       string `json:"version,omitempty" example:"1.0"`
+	LatestVersion string `json:"latest_version" example:"1.0"`
+	// Architecture 已下载的模型架构，未知时为空
+	Architecture string `json:"architecture,omitempty" example:"base-memory"`
+	// TargetArchitecture 按配置的架构偏好应使用的架构
+	TargetArchitecture string `json:"target_architecture,omitempty" example:"tiny"`
+	// PinnedVersion 配置中固定的版本
+	PinnedVersion string `json:"pinned_version,omitempty"`
+	// RetainedVersions 保留的旧版本，从新到旧
+	RetainedVersions []string `json:"retained_versions,omitempty"`
+	Downloaded       bool     `json:"downloaded"`
+	Downloading      bool     `json:"downloading"`
+	// Size 模型文件占用的磁盘空间（字节）
+	Size int64 `json:"size"`
+	// Local 使用本地注册的模型
+	Local bool `json:"local"`
+	// Loaded 是否有运行中的 Worker，由 services 填充
+	Loaded bool `json:"loaded"`
+	// Progress 最近一次下载的进度
+	Progress *DownloadProgress `json:"progress,omitempty"`
+}
+
+var (
+	pairLocksMu sync.Mutex
+	pairLocks   = make(map[string]*sync.Mutex)
+
+	downloadingMu sync.Mutex
+	downloading   = make(map[string]bool)
+)
+
+func pairKey(fromLang, toLang string) string {
+	return fmt.Sprintf("%s_%s", fromLang, toLang)
+}
+
+// PairDir 返回语言对的模型目录
+func PairDir(modelDir, fromLang, toLang string) string {
+	return filepath.Join(modelDir, pairKey(fromLang, toLang))
+}
+
+// lockPair 对语言对的模型目录加锁，避免同时下载或在下载时删除
+func lockPair(fromLang, toLang string) func() {
+	mu := pairLock(fromLang, toLang)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// tryLockPair 与 lockPair 相同，但语言对已被加锁时不等待，返回 false
+func tryLockPair(fromLang, toLang string) (func(), bool) {
+	mu := pairLock(fromLang, toLang)
+	if !mu.TryLock() {
+		return nil, false
+	}
+	return mu.Unlock, true
+}
+
+func pairLock(fromLang, toLang string) *sync.Mutex {
+	key := pairKey(fromLang, toLang)
+
+	pairLocksMu.Lock()
+	defer pairLocksMu.Unlock()
+	mu, ok := pairLocks[key]
+	if !ok {
+		mu = &sync.Mutex{}
+		pairLocks[key] = mu
+	}
+	return mu
+}
+
+func setDownloading(fromLang, toLang string, v bool) {
+	downloadingMu.Lock()
+	defer downloadingMu.Unlock()
+	if v {
+		downloading[pairKey(fromLang, toLang)] = true
+	} else {
+		delete(downloading, pairKey(fromLang, toLang))
+	}
+}
+
+// IsDownloading 语言对的模型是否正在下载
+func IsDownloading(fromLang, toLang string) bool {
+	downloadingMu.Lock()
+	defer downloadingMu.Unlock()
+	return downloading[pairKey(fromLang, toLang)]
+}
+
+// StartDownload 在后台下载语言对的模型，已在下载时返回 ErrDownloadInProcess
+func StartDownload(fromLang, toLang string) error {
+	if GetRecords() == nil || !GetRecords().HasLanguagePair(fromLang, toLang) {
+		return ErrPairNotSupported
+	}
+
+	downloadingMu.Lock()
+	if downloading[pairKey(fromLang, toLang)] {
+		downloadingMu.Unlock()
+		return ErrDownloadInProcess
+	}
+	// 先标记为下载中，保证返回后立即查询的状态一致
+	downloading[pairKey(fromLang, toLang)] = true
+	downloadingMu.Unlock()
+
+	go func() {
+		// DownloadModel 在加锁前出错时不会清除标记
+		defer setDownloading(fromLang, toLang, false)
+		if err := DownloadModel(toLang, fromLang, ""); err != nil {
+			logger.Error("Failed to download model %s -> %s: %v", fromLang, toLang, err)
+		}
+	}()
+	return nil
+}
+
+// DeleteModel 删除语言对的模型目录，调用方需确保没有使用该模型的 Worker
+// 语言对正在下载或被其他操作加锁时不等待，返回 ErrDownloadInProcess 或 ErrModelBusy
+func DeleteModel(modelDir, fromLang, toLang string) error {
+	if IsDownloading(fromLang, toLang) {
+		return ErrDownloadInProcess
+	}
+	unlock, ok := tryLockPair(fromLang, toLang)
+	if !ok {
+		return ErrModelBusy
+	}
+	defer unlock()
+
+	dir := PairDir(modelDir, fromLang, toLang)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return ErrModelNotFound
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to delete model %s -> %s: %w", fromLang, toLang, err)
+	}
+	logger.Info("Deleted model files for %s -> %s", fromLang, toLang)
+	return nil
+}
+
+// GetModelStatus 返回语言对的模型状态
+func GetModelStatus(modelDir, fromLang, toLang string) ModelStatus {
+	s := ModelStatus{
+		From:        fromLang,
+		To:          toLang,
+		Downloaded:  IsModelDownloaded(modelDir, fromLang, toLang),
+		Downloading: IsDownloading(fromLang, toLang),
+	}
+	if records := GetRecords(); records != nil {
+		s.LatestVersion = utils.GetLargestVersion(records.GetVersions(fromLang, toLang))
+	}
+
+	dir := PairDir(modelDir, fromLang, toLang)
+	s.Version = installedVersion(dir)
+	s.Architecture = InstalledArchitecture(modelDir, fromLang, toLang)
+	s.TargetArchitecture = TargetArchitecture(fromLang, toLang)
+	s.PinnedVersion = config.GetConfig().ModelVersionFor(fromLang, toLang)
+	s.RetainedVersions = RetainedVersions(modelDir, fromLang, toLang)
+	s.Size = dirSize(dir)
+	s.Progress = GetProgress(fromLang, toLang)
+	if UsesLocalModel(fromLang, toLang) {
+		s.Local = true
+		s.Version = getLocalModel(fromLang, toLang).Version
+		s.Architecture, s.TargetArchitecture = "", ""
+	}
+	return s
+}
+
+// ListModels 返回记录和本地模型中所有语言对的模型状态，按语言对排序
+func ListModels(modelDir string) ([]ModelStatus, error) {
+	if GetRecords() == nil {
+		if err := InitRecords(); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]ModelStatus, 0)
+	seen := make(map[string]bool)
+	for _, record := range GetRecords().Data {
+		key := pairKey(record.SourceLanguage, record.TargetLanguage)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		statuses = append(statuses, GetModelStatus(modelDir, record.SourceLanguage, record.TargetLanguage))
+	}
+	for _, m := range LocalModels() {
+		if key := pairKey(m.From, m.To); !seen[key] {
+			seen[key] = true
+			statuses = append(statuses, GetModelStatus(modelDir, m.From, m.To))
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].From != statuses[j].From {
+			return statuses[i].From < statuses[j].From
+		}
+		return statuses[i].To < statuses[j].To
+	})
+	return statuses, nil
+}
+
+// PairsForTarget 返回目标语言为 toLang 的所有语言对的源语言
+func PairsForTarget(toLang string) []string {
+	if GetRecords() == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var sources []string
+	for _, record := range GetRecords().Data {
+		if record.TargetLanguage == toLang && !seen[record.SourceLanguage] {
+			seen[record.SourceLanguage] = true
+			sources = append(sources, record.SourceLanguage)
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// recordsVersion 返回记录中最大的版本
+func recordsVersion(records []RecordItem) string {
+	versions := make([]string, 0, len(records))
+	for _, r := range records {
+		versions = append(versions, r.Version)
+	}
+	return utils.GetLargestVersion(versions)
+}
+
+func writeVersionFile(dir string, records []RecordItem) {
+	version := recordsVersion(records)
+	if err := os.WriteFile(filepath.Join(dir, versionFileName), []byte(version+"\n"), 0644); err != nil {
+		logger.Warn("Failed to write model version file: %v", err)
+	}
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
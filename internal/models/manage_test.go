@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xxnuo/MTranServer/internal/config"
+)
+
+func TestStartDownloadClearsFlagOnEarlyError(t *testing.T) {
+	useTestGlobals(t, &config.Config{
+		ConfigDir: t.TempDir(),
+		ModelDir:  t.TempDir(),
+		// 记录中没有固定的版本，DownloadModel 在加锁前返回错误
+		Pairs: map[string]config.PairConfig{"xx-yy": {Version: "9.9"}},
+	}, RecordItem{SourceLanguage: "xx", TargetLanguage: "yy", Version: "1.0", FileType: "model"})
+
+	require.NoError(t, StartDownload("xx", "yy"))
+	assert.Eventually(t, func() bool { return !IsDownloading("xx", "yy") }, time.Second, 10*time.Millisecond)
+
+	// 标记清除后可以再次下载
+	require.NoError(t, StartDownload("xx", "yy"))
+	assert.Eventually(t, func() bool { return !IsDownloading("xx", "yy") }, time.Second, 10*time.Millisecond)
+}
+
+func TestDeleteModelDoesNotWaitForPairLock(t *testing.T) {
+	modelDir := t.TempDir()
+	useTestGlobals(t, &config.Config{ConfigDir: t.TempDir(), ModelDir: modelDir})
+	writeTestModel(t, PairDir(modelDir, "xx", "yy"), Pair{"xx", "yy"}, "1.0", "1.0")
+
+	// 下载等操作持有语言对的锁时立即返回
+	unlock := lockPair("xx", "yy")
+	assert.ErrorIs(t, DeleteModel(modelDir, "xx", "yy"), ErrModelBusy)
+	unlock()
+
+	setDownloading("xx", "yy", true)
+	assert.ErrorIs(t, DeleteModel(modelDir, "xx", "yy"), ErrDownloadInProcess)
+	setDownloading("xx", "yy", false)
+
+	require.NoError(t, DeleteModel(modelDir, "xx", "yy"))
+	assert.NoDirExists(t, PairDir(modelDir, "xx", "yy"))
+}
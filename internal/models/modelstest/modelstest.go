@@ -0,0 +1,22 @@
+// Package modelstest 提供其他包的测试替换全局配置和模型记录的辅助函数
+package modelstest
+
+import (
+	"testing"
+
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/models"
+)
+
+// UseGlobals 替换全局配置和模型记录，测试结束后恢复，cfg 为 nil 时不替换配置
+func UseGlobals(t testing.TB, cfg *config.Config, records ...models.RecordItem) {
+	oldRecords := models.GlobalRecords
+	t.Cleanup(func() { models.GlobalRecords = oldRecords })
+	models.GlobalRecords = &models.RecordsData{Data: records}
+
+	if cfg != nil {
+		oldConfig := config.GlobalConfig
+		t.Cleanup(func() { config.GlobalConfig = oldConfig })
+		config.GlobalConfig = cfg
+	}
+}
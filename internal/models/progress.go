@@ -0,0 +1,262 @@
+package models
+
+import (
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xxnuo/MTranServer/internal/logger"
+)
+
+const (
+	// progressPublishInterval 向订阅者推送进度的最小间隔
+	progressPublishInterval = 500 * time.Millisecond
+	// progressLogInterval 记录进度日志的间隔
+	progressLogInterval = 5 * time.Second
+	// progressRateInterval 计算下载速度的采样间隔
+	progressRateInterval = time.Second
+)
+
+// FileProgress 单个文件的下载进度
+type FileProgress struct {
+	File  string `json:"file" example:"model.enja.intgemm.alphas.bin.zst"`
+	Bytes int64  `json:"bytes"`
+	Total int64  `json:"total"`
+	Done  bool   `json:"done"`
+}
+
+// DownloadProgress 语言对模型的下载进度
+type DownloadProgress struct {
+	From  string         `json:"from" example:"en"`
+	To    string         `json:"to" example:"ja"`
+	Files []FileProgress `json:"files"`
+	Bytes int64          `json:"bytes"`
+	Total int64          `json:"total"`
+	// Rate 最近的下载速度（字节/秒）
+	Rate      float64   `json:"rate"`
+	StartedAt time.Time `json:"started_at"`
+	Done      bool      `json:"done"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type progressEntry struct {
+	mu sync.Mutex
+	p  DownloadProgress
+
+	sampleAt    time.Time
+	sampleBytes int64
+	publishedAt time.Time
+	loggedAt    time.Time
+}
+
+var (
+	progressMu   sync.Mutex
+	progress     = make(map[string]*progressEntry)
+	progressSubs = make(map[chan DownloadProgress]struct{})
+)
+
+// startProgress 开始记录语言对的下载进度，替换该语言对之前的记录
+func startProgress(fromLang, toLang string, records []RecordItem) *progressEntry {
+	now := time.Now()
+	e := &progressEntry{
+		p: DownloadProgress{
+			From:      fromLang,
+			To:        toLang,
+			Files:     make([]FileProgress, 0, len(records)),
+			StartedAt: now,
+		},
+		sampleAt: now,
+		loggedAt: now,
+	}
+	for _, r := range records {
+		e.p.Files = append(e.p.Files, FileProgress{File: r.Attachment.Filename, Total: r.Attachment.Size})
+		e.p.Total += r.Attachment.Size
+	}
+
+	progressMu.Lock()
+	progress[pairKey(fromLang, toLang)] = e
+	progressMu.Unlock()
+
+	e.publish(true)
+	return e
+}
+
+// tracker 返回单个文件的进度跟踪器，用于 downloader.Downloader.SetProgressFunc
+func (e *progressEntry) tracker(file string) *progressTracker {
+	return &progressTracker{entry: e, file: file}
+}
+
+func (e *progressEntry) file(name string) *FileProgress {
+	for i := range e.p.Files {
+		if e.p.Files[i].File == name {
+			return &e.p.Files[i]
+		}
+	}
+	return nil
+}
+
+// begin 开始下载文件，currentSize 为续传时已有的字节数
+func (e *progressEntry) begin(name string, currentSize, totalSize int64) {
+	e.mu.Lock()
+	if f := e.file(name); f != nil {
+		e.p.Bytes += currentSize - f.Bytes
+		f.Bytes = currentSize
+		if totalSize > 0 {
+			e.p.Total += totalSize - f.Total
+			f.Total = totalSize
+		}
+	}
+	e.mu.Unlock()
+	e.publish(false)
+}
+
+func (e *progressEntry) add(name string, n int64) {
+	now := time.Now()
+
+	e.mu.Lock()
+	if f := e.file(name); f != nil {
+		f.Bytes += n
+	}
+	e.p.Bytes += n
+	if elapsed := now.Sub(e.sampleAt); elapsed >= progressRateInterval {
+		e.p.Rate = float64(e.p.Bytes-e.sampleBytes) / elapsed.Seconds()
+		e.sampleAt = now
+		e.sampleBytes = e.p.Bytes
+	}
+	shouldLog := now.Sub(e.loggedAt) >= progressLogInterval
+	if shouldLog {
+		e.loggedAt = now
+	}
+	p := e.p
+	e.mu.Unlock()
+
+	if shouldLog {
+		logger.Info("Downloading model %s -> %s: %.1f/%.1f MB (%.1f MB/s)",
+			p.From, p.To, float64(p.Bytes)/(1<<20), float64(p.Total)/(1<<20), p.Rate/(1<<20))
+	}
+	e.publish(false)
+}
+
+func (e *progressEntry) fileDone(name string) {
+	e.mu.Lock()
+	if f := e.file(name); f != nil {
+		f.Done = true
+	}
+	e.mu.Unlock()
+	e.publish(true)
+}
+
+// finish 结束下载，err 不为空时记录失败原因
+func (e *progressEntry) finish(err error) {
+	e.mu.Lock()
+	e.p.Done = true
+	e.p.Rate = 0
+	if err != nil {
+		e.p.Error = err.Error()
+	}
+	e.mu.Unlock()
+	e.publish(true)
+}
+
+func (e *progressEntry) snapshot() DownloadProgress {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	p := e.p
+	p.Files = append([]FileProgress(nil), e.p.Files...)
+	return p
+}
+
+// publish 向订阅者推送进度，非强制时按间隔限流
+func (e *progressEntry) publish(force bool) {
+	now := time.Now()
+	e.mu.Lock()
+	if !force && now.Sub(e.publishedAt) < progressPublishInterval {
+		e.mu.Unlock()
+		return
+	}
+	e.publishedAt = now
+	e.mu.Unlock()
+
+	p := e.snapshot()
+
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	for ch := range progressSubs {
+		// 订阅者处理不及时时丢弃，下一次推送会带上最新进度
+		select {
+		case ch <- p:
+		default:
+		}
+	}
+}
+
+// GetProgress 返回语言对最近一次下载的进度，没有记录时返回 nil
+func GetProgress(fromLang, toLang string) *DownloadProgress {
+	progressMu.Lock()
+	e, ok := progress[pairKey(fromLang, toLang)]
+	progressMu.Unlock()
+	if !ok {
+		return nil
+	}
+	p := e.snapshot()
+	return &p
+}
+
+// ListProgress 返回所有语言对最近一次下载的进度
+func ListProgress() []DownloadProgress {
+	progressMu.Lock()
+	entries := make([]*progressEntry, 0, len(progress))
+	for _, e := range progress {
+		entries = append(entries, e)
+	}
+	progressMu.Unlock()
+
+	list := make([]DownloadProgress, 0, len(entries))
+	for _, e := range entries {
+		list = append(list, e.snapshot())
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].StartedAt.Before(list[j].StartedAt)
+	})
+	return list
+}
+
+// SubscribeProgress 订阅下载进度，返回的函数用于取消订阅
+func SubscribeProgress() (<-chan DownloadProgress, func()) {
+	ch := make(chan DownloadProgress, 16)
+
+	progressMu.Lock()
+	progressSubs[ch] = struct{}{}
+	progressMu.Unlock()
+
+	return ch, func() {
+		progressMu.Lock()
+		delete(progressSubs, ch)
+		progressMu.Unlock()
+	}
+}
+
+// progressTracker 实现 downloader.ProgressTracker
+type progressTracker struct {
+	entry *progressEntry
+	file  string
+}
+
+func (t *progressTracker) TrackProgress(_ string, currentSize, totalSize int64, stream io.ReadCloser) io.ReadCloser {
+	t.entry.begin(t.file, currentSize, totalSize)
+	return &progressReader{ReadCloser: stream, tracker: t}
+}
+
+type progressReader struct {
+	io.ReadCloser
+	tracker *progressTracker
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.tracker.entry.add(r.tracker.file, int64(n))
+	}
+	return n, err
+}
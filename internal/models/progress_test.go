@@ -0,0 +1,67 @@
+package models
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadProgress(t *testing.T) {
+	events, cancel := SubscribeProgress()
+	defer cancel()
+
+	records := []RecordItem{
+		{Attachment: Attachment{Filename: "model.xxyy.bin.zst", Size: 10}},
+		{Attachment: Attachment{Filename: "lex.xxyy.s2t.bin.zst", Size: 5}},
+	}
+	e := startProgress("xx", "yy", records)
+
+	p := <-events
+	assert.Equal(t, "xx", p.From)
+	assert.Equal(t, int64(15), p.Total)
+	assert.Len(t, p.Files, 2)
+
+	// 服务器返回的大小覆盖记录中的大小
+	body := e.tracker("model.xxyy.bin.zst").TrackProgress("ignored", 0, 12, io.NopCloser(strings.NewReader("hello world!")))
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world!", string(data))
+	e.fileDone("model.xxyy.bin.zst")
+
+	got := GetProgress("xx", "yy")
+	require.NotNil(t, got)
+	assert.Equal(t, int64(12), got.Bytes)
+	assert.Equal(t, int64(17), got.Total)
+	assert.Equal(t, int64(12), got.Files[0].Bytes)
+	assert.True(t, got.Files[0].Done)
+	assert.False(t, got.Files[1].Done)
+	assert.False(t, got.Done)
+
+	e.finish(errors.New("connection reset"))
+	got = GetProgress("xx", "yy")
+	assert.True(t, got.Done)
+	assert.Equal(t, "connection reset", got.Error)
+
+	// 完成事件总是推送
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case p := <-events:
+			if p.Done {
+				assert.Equal(t, "connection reset", p.Error)
+				return
+			}
+		case <-deadline:
+			t.Fatal("did not receive finish event")
+		}
+	}
+}
+
+func TestGetProgressUnknown(t *testing.T) {
+	assert.Nil(t, GetProgress("aa", "bb"))
+}
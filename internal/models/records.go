@@ -1,13 +1,16 @@
 package models
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/xxnuo/MTranServer/data"
 	"github.com/xxnuo/MTranServer/internal/config"
@@ -17,7 +20,9 @@ import (
 )
 
 const (
-	RecordsUrl         = "https://firefox.settings.services.mozilla.com/v1/buckets/main-preview/collections/translations-models-v2/records"
+	RecordsBaseUrl     = "https://firefox.settings.services.mozilla.com/v1/buckets"
+	RecordsCollection  = "translations-models-v2"
+	RecordsUrl         = RecordsBaseUrl + "/main-preview/collections/" + RecordsCollection + "/records"
 	RecordsFileName    = "records.json"
 	AttachmentsBaseUrl = "https://firefox-settings-attachments.cdn.mozilla.net"
 )
@@ -51,9 +56,37 @@ type Attachment struct {
 }
 
 var (
+	// GlobalRecords 当前的模型记录，刷新时整体替换，读取时使用 GetRecords
 	GlobalRecords *RecordsData
+	// baseRecords 叠加附加来源之前的 records.json
+	baseRecords *RecordsData
+	recordsMu   sync.RWMutex
 )
 
+// GetRecords 返回当前的模型记录，未加载时返回 nil
+// 返回的数据不会被修改，刷新时替换为新的 RecordsData
+func GetRecords() *RecordsData {
+	recordsMu.RLock()
+	defer recordsMu.RUnlock()
+	return GlobalRecords
+}
+
+// setRecords 替换当前的模型记录，返回替换前的记录
+func setRecords(base, records *RecordsData) *RecordsData {
+	recordsMu.Lock()
+	defer recordsMu.Unlock()
+	old := GlobalRecords
+	baseRecords = base
+	GlobalRecords = records
+	return old
+}
+
+func getBaseRecords() *RecordsData {
+	recordsMu.RLock()
+	defer recordsMu.RUnlock()
+	return baseRecords
+}
+
 func (r *RecordsData) GetLanguagePairs() []string {
 	pairMap := make(map[string]bool)
 	for _, record := range r.Data {
@@ -123,8 +156,7 @@ func loadRecordsFromBytes(jsonData []byte) error {
 	if err := json.Unmarshal(jsonData, &records); err != nil {
 		return fmt.Errorf("failed to parse records.json: %w", err)
 	}
-	GlobalRecords = &records
-	logger.Debug("Loaded %d model records", len(records.Data))
+	installRecords(&records, loadSources(context.Background(), !config.GetConfig().EnableOfflineMode))
 	return nil
 }
 
@@ -149,52 +181,88 @@ func initRecordsOffline(recordsPath string) error {
 	return loadRecordsFromBytes(data.RecordsJson)
 }
 
+// initRecordsOnline 使用条件请求更新 records.json，远程未变化或无法访问时使用本地文件，
+// 本地文件无效时使用内置数据
 func initRecordsOnline(recordsPath string) error {
-	cfg := config.GetConfig()
-	d := downloader.New(cfg.ConfigDir)
-
-	logger.Info("Downloading latest records.json from remote...")
-	if err := d.Download(RecordsUrl, RecordsFileName, &downloader.DownloadOptions{
-		Overwrite: true,
-	}); err != nil {
-		logger.Warn("Failed to download records.json: %v, falling back to embedded data", err)
-		if err := os.WriteFile(recordsPath, data.RecordsJson, 0644); err != nil {
-			logger.Warn("Failed to write embedded records.json: %v", err)
-		}
-		return loadRecordsFromBytes(data.RecordsJson)
+	logger.Info("Updating records.json from remote...")
+	ctx := context.Background()
+	records, err := fetchRecords(ctx)
+	if err == nil {
+		installRecords(records, loadSources(ctx, true))
+		return nil
 	}
-
-	fileData, err := os.ReadFile(recordsPath)
-	if err != nil {
-		logger.Warn("Failed to read downloaded records.json: %v, using embedded data", err)
-		return loadRecordsFromBytes(data.RecordsJson)
+	if errors.Is(err, downloader.ErrNotModified) {
+		logger.Debug("records.json not modified, loading %s", recordsPath)
+	} else {
+		logger.Warn("Failed to download records.json: %v", err)
 	}
 
-	if !isValidRecordsFormat(fileData) {
-		logger.Warn("Downloaded records.json has invalid format, using embedded data")
-		if err := os.WriteFile(recordsPath, data.RecordsJson, 0644); err != nil {
-			logger.Warn("Failed to write embedded records.json: %v", err)
+	if fileData, err := os.ReadFile(recordsPath); err == nil {
+		records, err := parseRecords(fileData)
+		if err == nil {
+			installRecords(records, loadSources(ctx, true))
+			return nil
 		}
-		return loadRecordsFromBytes(data.RecordsJson)
+		logger.Warn("Local records.json is invalid: %v", err)
 	}
 
-	logger.Debug("Loading records.json from %s", recordsPath)
-	return loadRecordsFromBytes(fileData)
+	logger.Warn("Using embedded records.json")
+	// 下次启动时重新完整下载
+	os.Remove(filepath.Join(filepath.Dir(recordsPath), recordsMetaFileName))
+	if err := os.WriteFile(recordsPath, data.RecordsJson, 0644); err != nil {
+		logger.Warn("Failed to write embedded records.json: %v", err)
+	}
+	return loadRecordsFromBytes(data.RecordsJson)
 }
 
+// DownloadRecords 立即刷新 records.json
 func DownloadRecords() error {
-	cfg := config.GetConfig()
+	_, err := RefreshRecords(context.Background())
+	return err
+}
+
+// ChannelRecordsURL 返回 Mozilla records 渠道的地址，未知渠道使用 preview
+func ChannelRecordsURL(channel string) string {
+	if channel == "production" {
+		return RecordsBaseUrl + "/main/collections/" + RecordsCollection + "/records"
+	}
+	return RecordsUrl
+}
 
-	logger.Info("Updating records.json from remote")
+// recordsURLs 返回 records.json 的下载地址，按顺序尝试
+func recordsURLs() []string {
+	cfg := config.GetConfig()
+	if urls := splitList(cfg.RecordsURL); len(urls) > 0 {
+		return urls
+	}
+	return []string{ChannelRecordsURL(cfg.RecordsChannel)}
+}
 
-	d := downloader.New(cfg.ConfigDir)
-	if err := d.Download(RecordsUrl, RecordsFileName, &downloader.DownloadOptions{
-		Overwrite: true,
-	}); err != nil {
-		return fmt.Errorf("Failed to download records.json: %w", err)
+// attachmentURLs 返回模型文件在各个镜像中的地址，按顺序尝试
+// 附加来源中的记录可以使用完整地址，此时不使用镜像
+func attachmentURLs(location string) []string {
+	if strings.Contains(location, "://") {
+		return []string{location}
 	}
+	mirrors := splitList(config.GetConfig().ModelMirrors)
+	if len(mirrors) == 0 {
+		mirrors = []string{AttachmentsBaseUrl}
+	}
+	urls := make([]string, 0, len(mirrors))
+	for _, m := range mirrors {
+		urls = append(urls, downloader.JoinURL(m, location))
+	}
+	return urls
+}
 
-	return InitRecords()
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 func computeFileHash(filePath string) (string, error) {
@@ -208,48 +276,29 @@ func computeFileHash(filePath string) (string, error) {
 
 func DownloadModel(toLang string, fromLang string, version string) error {
 
-	if GlobalRecords == nil {
+	if GetRecords() == nil {
 		if err := InitRecords(); err != nil {
 			return err
 		}
 	}
 
-	var matchedRecords []RecordItem
-	for _, record := range GlobalRecords.Data {
-		if record.TargetLanguage == toLang && record.SourceLanguage == fromLang {
-			if version == "" || record.Version == version {
-				matchedRecords = append(matchedRecords, record)
-			}
-		}
+	cfg := config.GetConfig()
+	if version == "" {
+		// 配置中固定的版本
+		version = cfg.ModelVersionFor(fromLang, toLang)
 	}
 
-	if len(matchedRecords) == 0 {
-		return fmt.Errorf("No model found for %s -> %s (version: %s)", fromLang, toLang, version)
+	targetRecords, err := selectRecords(fromLang, toLang, version)
+	if err != nil {
+		return err
 	}
 
-	targetRecords := matchedRecords
-	if version == "" {
-
-		fileTypeMap := make(map[string][]RecordItem)
-		for _, record := range matchedRecords {
-			fileTypeMap[record.FileType] = append(fileTypeMap[record.FileType], record)
-		}
-
-		targetRecords = []RecordItem{}
-		for _, records := range fileTypeMap {
-			versions := make([]string, len(records))
-			recordMap := make(map[string]RecordItem)
-			for i, r := range records {
-				versions[i] = r.Version
-				recordMap[r.Version] = r
-			}
-			latestVersion := utils.GetLargestVersion(versions)
-			targetRecords = append(targetRecords, recordMap[latestVersion])
-		}
-	}
+	unlock := lockPair(fromLang, toLang)
+	defer unlock()
+	setDownloading(fromLang, toLang, true)
+	defer setDownloading(fromLang, toLang, false)
 
-	cfg := config.GetConfig()
-	langPairDir := filepath.Join(cfg.ModelDir, fmt.Sprintf("%s_%s", fromLang, toLang))
+	langPairDir := PairDir(cfg.ModelDir, fromLang, toLang)
 
 	if err := os.MkdirAll(cfg.ModelDir, 0755); err != nil {
 		return fmt.Errorf("Failed to create model directory: %w", err)
@@ -258,16 +307,9 @@ func DownloadModel(toLang string, fromLang string, version string) error {
 		return fmt.Errorf("Failed to create language pair directory: %w", err)
 	}
 
-	logger.Info("Downloading model files for %s -> %s", fromLang, toLang)
-
-	d := downloader.New(langPairDir)
-
+	var pending []RecordItem
 	for _, record := range targetRecords {
-		filename := record.Attachment.Filename
-		fileUrl := AttachmentsBaseUrl + "/" + record.Attachment.Location
-		compressedHash := record.Attachment.Hash
-
-		decompressedFilename := strings.TrimSuffix(filename, ".zst")
+		decompressedFilename := strings.TrimSuffix(record.Attachment.Filename, ".zst")
 		decompressedPath := filepath.Join(langPairDir, decompressedFilename)
 
 		needDownload := false
@@ -289,31 +331,138 @@ func DownloadModel(toLang string, fromLang string, version string) error {
 			logger.Debug("Model file up to date: %s", decompressedFilename)
 			continue
 		}
+		pending = append(pending, record)
+	}
+
+	pair := Pair{From: fromLang, To: toLang}
+	if len(pending) == 0 {
+		writeVersionFile(langPairDir, targetRecords)
+		removeStaleFiles(langPairDir, pair, targetRecords)
+		return nil
+	}
+
+	active := installedVersion(langPairDir)
+	target := recordsVersion(targetRecords)
+	if active != target {
+		retainVersion(cfg.ModelDir, pair, active)
+	}
+
+	// 优先从保留的旧版本恢复
+	var missing []RecordItem
+	for _, record := range pending {
+		dst := filepath.Join(langPairDir, strings.TrimSuffix(record.Attachment.Filename, ".zst"))
+		if !restoreRecord(cfg.ModelDir, record, dst) {
+			missing = append(missing, record)
+		}
+	}
+	pending = missing
+	if len(pending) == 0 {
+		writeVersionFile(langPairDir, targetRecords)
+		removeStaleFiles(langPairDir, pair, targetRecords)
+		applyRetention(cfg.ModelDir, pair, target)
+		logger.Info("Restored model version %s for %s -> %s", target, fromLang, toLang)
+		return nil
+	}
+
+	logger.Info("Downloading model files for %s -> %s", fromLang, toLang)
+
+	progress := startProgress(fromLang, toLang, pending)
+	if err := downloadRecords(langPairDir, pending, progress); err != nil {
+		progress.finish(err)
+		return err
+	}
+	progress.finish(nil)
+
+	writeVersionFile(langPairDir, targetRecords)
+	removeStaleFiles(langPairDir, pair, targetRecords)
+	applyRetention(cfg.ModelDir, pair, target)
+	logger.Info("Model files downloaded successfully for %s -> %s", fromLang, toLang)
+	return nil
+}
+
+// selectRecords 返回语言对指定版本的记录，version 为空时每种文件取最新版本
+// 只使用按配置选择的一种架构的记录，不同架构的文件不会混用
+func selectRecords(fromLang, toLang, version string) ([]RecordItem, error) {
+	var candidates []RecordItem
+	for _, record := range GetRecords().Data {
+		if record.TargetLanguage == toLang && record.SourceLanguage == fromLang {
+			if version == "" || record.Version == version {
+				candidates = append(candidates, record)
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("No model found for %s -> %s (version: %s)", fromLang, toLang, version)
+	}
+
+	arch := selectArchitecture(candidates, config.GetConfig().ArchitecturesFor(fromLang, toLang))
+	var matchedRecords []RecordItem
+	for _, record := range candidates {
+		if record.Architecture == arch {
+			matchedRecords = append(matchedRecords, record)
+		}
+	}
+
+	targetRecords := matchedRecords
+	if version == "" {
+
+		fileTypeMap := make(map[string][]RecordItem)
+		for _, record := range matchedRecords {
+			fileTypeMap[record.FileType] = append(fileTypeMap[record.FileType], record)
+		}
+
+		targetRecords = []RecordItem{}
+		for _, records := range fileTypeMap {
+			versions := make([]string, len(records))
+			recordMap := make(map[string]RecordItem)
+			for i, r := range records {
+				versions[i] = r.Version
+				recordMap[r.Version] = r
+			}
+			latestVersion := utils.GetLargestVersion(versions)
+			targetRecords = append(targetRecords, recordMap[latestVersion])
+		}
+	}
+	return targetRecords, nil
+}
+
+// downloadRecords 下载并解压模型文件，记录下载进度
+func downloadRecords(langPairDir string, records []RecordItem, progress *progressEntry) error {
+	d := downloader.New(langPairDir)
+
+	for _, record := range records {
+		filename := record.Attachment.Filename
+		decompressedPath := filepath.Join(langPairDir, strings.TrimSuffix(filename, ".zst"))
 
 		logger.Debug("Downloading model file: %s (type: %s)", filename, record.FileType)
-		if err := d.Download(fileUrl, filename, &downloader.DownloadOptions{
-			SHA256:    compressedHash,
+		d.SetProgressFunc(progress.tracker(filename))
+		if err := d.DownloadMirrors(attachmentURLs(record.Attachment.Location), filename, &downloader.DownloadOptions{
+			SHA256:    record.Attachment.Hash,
 			Overwrite: true,
 		}); err != nil {
 			return fmt.Errorf("Failed to download %s: %w", filename, err)
 		}
 
 		compressedPath := filepath.Join(langPairDir, filename)
-		logger.Debug("Decompressing: %s -> %s", filename, decompressedFilename)
+		logger.Debug("Decompressing: %s -> %s", filename, filepath.Base(decompressedPath))
 		if err := utils.DecompressZstd(compressedPath, decompressedPath); err != nil {
 			return fmt.Errorf("Failed to decompress %s: %w", filename, err)
 		}
 
 		os.Remove(compressedPath)
+		progress.fileDone(filename)
 	}
-
-	logger.Info("Model files downloaded successfully for %s -> %s", fromLang, toLang)
 	return nil
 }
 
+// GetModelFiles 返回语言对的模型文件路径，使用本地模型时返回本地模型目录中的文件
 func GetModelFiles(modelDir, fromLang, toLang string) (map[string]string, error) {
+	if UsesLocalModel(fromLang, toLang) {
+		return getLocalModel(fromLang, toLang).files(), nil
+	}
 
-	if GlobalRecords == nil {
+	if GetRecords() == nil {
 		if err := InitRecords(); err != nil {
 			return nil, fmt.Errorf("failed to init records: %w", err)
 		}
@@ -324,7 +473,14 @@ func GetModelFiles(modelDir, fromLang, toLang string) (map[string]string, error)
 	files := make(map[string]string)
 	fileTypeMap := make(map[string]string)
 
-	for _, record := range GlobalRecords.Data {
+	// 已知版本时只查找该版本中按配置选择的架构的文件
+	candidates := GetRecords().Data
+	if version := installedVersion(langPairDir); version != "" {
+		if records, err := selectRecords(fromLang, toLang, version); err == nil {
+			candidates = records
+		}
+	}
+	for _, record := range candidates {
 		if record.SourceLanguage == fromLang && record.TargetLanguage == toLang {
 			filename := strings.TrimSuffix(record.Attachment.Filename, ".zst")
 			fullPath := filepath.Join(langPairDir, filename)
@@ -375,16 +531,16 @@ func IsModelDownloaded(modelDir, fromLang, toLang string) bool {
 }
 
 func GetSupportedLanguages() ([]string, error) {
-	if GlobalRecords == nil {
+	if GetRecords() == nil {
 		if err := InitRecords(); err != nil {
 			return nil, err
 		}
 	}
 
-	langMap := make(map[string]bool)
-	for _, record := range GlobalRecords.Data {
-		langMap[record.SourceLanguage] = true
-		langMap[record.TargetLanguage] = true
+	langMap := GetRecords().languages()
+	for _, m := range LocalModels() {
+		langMap[m.From] = true
+		langMap[m.To] = true
 	}
 
 	langs := make([]string, 0, len(langMap))
@@ -395,7 +551,7 @@ func GetSupportedLanguages() ([]string, error) {
 }
 
 func ValidateLanguagePair(fromLang, toLang string) error {
-	if GlobalRecords == nil {
+	if GetRecords() == nil {
 		if err := InitRecords(); err != nil {
 			return fmt.Errorf("failed to init records: %w", err)
 		}
@@ -413,7 +569,7 @@ func ValidateLanguagePair(fromLang, toLang string) error {
 		return fmt.Errorf("source and target languages cannot be the same")
 	}
 
-	if !GlobalRecords.HasLanguagePair(fromLang, toLang) {
+	if !SupportsPair(fromLang, toLang) {
 		return fmt.Errorf("language pair %s -> %s is not supported", fromLang, toLang)
 	}
 
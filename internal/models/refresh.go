@@ -0,0 +1,324 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/downloader"
+	"github.com/xxnuo/MTranServer/internal/logger"
+	"github.com/xxnuo/MTranServer/internal/utils"
+)
+
+// recordsMetaFileName 保存 records.json 的 ETag 和 Last-Modified，用于条件请求
+const recordsMetaFileName = "records.meta.json"
+
+// refreshDisabledPoll 未启用刷新时重新检查配置的间隔
+const refreshDisabledPoll = time.Minute
+
+// PairChange 刷新后新增或更新的语言对
+type PairChange struct {
+	From string `json:"from" example:"en"`
+	To   string `json:"to" example:"ja"`
+	// OldVersion 刷新前的最新版本，新增的语言对为空
+	OldVersion string `json:"old_version,omitempty" example:"1.0"`
+	Version    string `json:"version" example:"1.1"`
+}
+
+// RecordsDiff 刷新前后记录的差异
+type RecordsDiff struct {
+	Added   []PairChange `json:"added"`
+	Updated []PairChange `json:"updated"`
+	Removed []PairChange `json:"removed"`
+	// LanguagesChanged 支持的语言发生了变化
+	LanguagesChanged bool `json:"languages_changed"`
+}
+
+// Empty 记录中的语言对和版本没有变化
+func (d RecordsDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Updated) == 0 && len(d.Removed) == 0
+}
+
+func (d RecordsDiff) String() string {
+	var parts []string
+	for _, c := range d.Added {
+		parts = append(parts, fmt.Sprintf("+%s_%s@%s", c.From, c.To, c.Version))
+	}
+	for _, c := range d.Updated {
+		parts = append(parts, fmt.Sprintf("%s_%s@%s->%s", c.From, c.To, c.OldVersion, c.Version))
+	}
+	for _, c := range d.Removed {
+		parts = append(parts, fmt.Sprintf("-%s_%s@%s", c.From, c.To, c.OldVersion))
+	}
+	return strings.Join(parts, " ")
+}
+
+var (
+	refreshMu sync.Mutex
+
+	recordsSubsMu sync.Mutex
+	recordsSubs   = make(map[chan RecordsDiff]struct{})
+)
+
+// SubscribeRecords 订阅记录变化，刷新后语言对或版本变化时推送差异，返回的函数用于取消订阅
+func SubscribeRecords() (<-chan RecordsDiff, func()) {
+	ch := make(chan RecordsDiff, 4)
+
+	recordsSubsMu.Lock()
+	recordsSubs[ch] = struct{}{}
+	recordsSubsMu.Unlock()
+
+	return ch, func() {
+		recordsSubsMu.Lock()
+		delete(recordsSubs, ch)
+		recordsSubsMu.Unlock()
+	}
+}
+
+func publishRecords(diff RecordsDiff) {
+	recordsSubsMu.Lock()
+	defer recordsSubsMu.Unlock()
+	for ch := range recordsSubs {
+		select {
+		case ch <- diff:
+		default:
+			// 订阅者处理不及时，丢弃本次通知
+		}
+	}
+}
+
+// parseRecords 解析并校验 records.json，任一记录缺少必要字段时拒绝整个文件
+func parseRecords(data []byte) (*RecordsData, error) {
+	if !isValidRecordsFormat(data) {
+		return nil, fmt.Errorf("unexpected records.json format")
+	}
+	var records RecordsData
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse records.json: %w", err)
+	}
+	if len(records.Data) == 0 {
+		return nil, fmt.Errorf("records.json has no records")
+	}
+	for i, r := range records.Data {
+		if r.SourceLanguage == "" || r.TargetLanguage == "" || r.Version == "" || r.Attachment.Filename == "" {
+			return nil, fmt.Errorf("record %d (%s) is missing language, version or attachment", i, r.ID)
+		}
+	}
+	return &records, nil
+}
+
+// installRecords 叠加附加来源并合并导入的记录后替换当前记录，返回与替换前的差异并通知订阅者
+// 合并后的记录与当前记录相同时不做替换
+func installRecords(base *RecordsData, sources [][]RecordItem) RecordsDiff {
+	records := &RecordsData{Data: layerRecords(base.Data, sources)}
+	mergeImportedRecords(records)
+	if cur := GetRecords(); cur != nil && reflect.DeepEqual(cur.Data, records.Data) {
+		return RecordsDiff{}
+	}
+	return replaceRecords(base, records)
+}
+
+// replaceRecords 替换当前记录，返回与替换前的差异，差异不为空时通知订阅者
+func replaceRecords(base, records *RecordsData) RecordsDiff {
+	old := setRecords(base, records)
+	rebuildPairGraph()
+	logger.Debug("Loaded %d model records", len(records.Data))
+	if old == nil {
+		return RecordsDiff{}
+	}
+
+	diff := diffRecords(old, records)
+	if !diff.Empty() {
+		logger.Info("Model records changed: %d added, %d updated, %d removed: %s",
+			len(diff.Added), len(diff.Updated), len(diff.Removed), diff)
+		publishRecords(diff)
+	}
+	return diff
+}
+
+// diffRecords 比较两份记录中各语言对的最新版本
+func diffRecords(old, cur *RecordsData) RecordsDiff {
+	before, after := latestVersions(old), latestVersions(cur)
+
+	diff := RecordsDiff{Added: []PairChange{}, Updated: []PairChange{}, Removed: []PairChange{}}
+	for p, v := range after {
+		prev, ok := before[p]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, PairChange{From: p.From, To: p.To, Version: v})
+		case prev != v:
+			diff.Updated = append(diff.Updated, PairChange{From: p.From, To: p.To, OldVersion: prev, Version: v})
+		}
+	}
+	for p, v := range before {
+		if _, ok := after[p]; !ok {
+			diff.Removed = append(diff.Removed, PairChange{From: p.From, To: p.To, OldVersion: v})
+		}
+	}
+	for _, list := range [][]PairChange{diff.Added, diff.Updated, diff.Removed} {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].From != list[j].From {
+				return list[i].From < list[j].From
+			}
+			return list[i].To < list[j].To
+		})
+	}
+
+	oldLangs, newLangs := old.languages(), cur.languages()
+	diff.LanguagesChanged = len(oldLangs) != len(newLangs)
+	for lang := range newLangs {
+		if !oldLangs[lang] {
+			diff.LanguagesChanged = true
+		}
+	}
+	return diff
+}
+
+func latestVersions(r *RecordsData) map[Pair]string {
+	versions := make(map[Pair][]string)
+	for _, record := range r.Data {
+		p := Pair{From: record.SourceLanguage, To: record.TargetLanguage}
+		versions[p] = append(versions[p], record.Version)
+	}
+	latest := make(map[Pair]string, len(versions))
+	for p, v := range versions {
+		latest[p] = utils.GetLargestVersion(v)
+	}
+	return latest
+}
+
+func (r *RecordsData) languages() map[string]bool {
+	langs := make(map[string]bool)
+	for _, record := range r.Data {
+		langs[record.SourceLanguage] = true
+		langs[record.TargetLanguage] = true
+	}
+	return langs
+}
+
+// recordsMeta 条件请求的校验信息及其对应的地址，地址变化后不再使用
+type recordsMeta struct {
+	URLs []string `json:"urls"`
+	downloader.Validator
+}
+
+func loadValidator(path string, urls []string) downloader.Validator {
+	var meta recordsMeta
+	data, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(data, &meta) != nil || !slices.Equal(meta.URLs, urls) {
+		return downloader.Validator{}
+	}
+	return meta.Validator
+}
+
+func saveValidator(path string, urls []string, v downloader.Validator) {
+	data, err := json.Marshal(recordsMeta{URLs: urls, Validator: v})
+	if err == nil {
+		err = os.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		logger.Warn("Failed to save %s: %v", path, err)
+	}
+}
+
+// writeFileAtomic 先写入临时文件再替换，中断时不会留下不完整的文件
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// fetchRecords 使用条件请求获取 records.json，校验通过后写入配置目录
+// 本地文件存在且远程未变化时返回 downloader.ErrNotModified
+func fetchRecords(ctx context.Context) (*RecordsData, error) {
+	cfg := config.GetConfig()
+	path := filepath.Join(cfg.ConfigDir, RecordsFileName)
+	metaPath := filepath.Join(cfg.ConfigDir, recordsMetaFileName)
+	urls := recordsURLs()
+
+	var v downloader.Validator
+	if _, err := os.Stat(path); err == nil {
+		v = loadValidator(metaPath, urls)
+	}
+
+	data, next, err := downloader.New(cfg.ConfigDir).Fetch(ctx, urls, v)
+	if err != nil {
+		return nil, err
+	}
+	records, err := parseRecords(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid records.json: %w", err)
+	}
+
+	if err := writeFileAtomic(path, data); err != nil {
+		return nil, fmt.Errorf("failed to save records.json: %w", err)
+	}
+	saveValidator(metaPath, urls, next)
+	return records, nil
+}
+
+// RefreshRecords 重新获取 records.json 和附加来源，内容变化且校验通过时替换当前记录
+// 返回与之前记录的差异，未变化时差异为空
+func RefreshRecords(ctx context.Context) (RecordsDiff, error) {
+	if config.GetConfig().EnableOfflineMode {
+		return RecordsDiff{}, fmt.Errorf("records cannot be refreshed in offline mode")
+	}
+	refreshMu.Lock()
+	defer refreshMu.Unlock()
+
+	records, err := fetchRecords(ctx)
+	if errors.Is(err, downloader.ErrNotModified) {
+		logger.Debug("records.json not modified")
+		records = getBaseRecords()
+		if records == nil || config.GetConfig().RecordsSources == "" {
+			return RecordsDiff{}, nil
+		}
+	} else if err != nil {
+		return RecordsDiff{}, err
+	}
+	return installRecords(records, loadSources(ctx, true)), nil
+}
+
+// WatchRecords 按 RecordsRefreshInterval 定期刷新 records.json，间隔为 0 时不刷新
+// 间隔可以在运行时修改，离线模式下不刷新
+func WatchRecords(ctx context.Context) {
+	for {
+		cfg := config.GetConfig()
+		interval := time.Duration(cfg.RecordsRefreshInterval) * time.Second
+		enabled := interval > 0 && !cfg.EnableOfflineMode
+		if !enabled {
+			interval = refreshDisabledPoll
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if !enabled {
+			continue
+		}
+
+		if _, err := RefreshRecords(ctx); err != nil && ctx.Err() == nil {
+			logger.Warn("Failed to refresh records.json: %v", err)
+		}
+	}
+}
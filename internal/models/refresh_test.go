@@ -0,0 +1,96 @@
+package models
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xxnuo/MTranServer/internal/config"
+)
+
+const refreshedRecords = `{"data":[
+	{"id":"a","sourceLanguage":"en","targetLanguage":"ja","version":"1.1","fileType":"model","attachment":{"filename":"model.enja.bin.zst"}},
+	{"id":"b","sourceLanguage":"ja","targetLanguage":"en","version":"1.0","fileType":"model","attachment":{"filename":"model.jaen.bin.zst"}}
+]}`
+
+func setupRefreshTest(t *testing.T, body *string) (*int, string) {
+	requests := new(int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if r.Header.Get("If-None-Match") == `"r1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"r1"`)
+		w.Write([]byte(*body))
+	}))
+	t.Cleanup(server.Close)
+
+	configDir := t.TempDir()
+	useTestGlobals(t, &config.Config{ConfigDir: configDir, ModelDir: t.TempDir(), RecordsURL: server.URL},
+		RecordItem{SourceLanguage: "en", TargetLanguage: "ja", Version: "1.0", Attachment: Attachment{Filename: "model.enja.bin.zst"}},
+		RecordItem{SourceLanguage: "en", TargetLanguage: "de", Version: "1.0", Attachment: Attachment{Filename: "model.ende.bin.zst"}},
+	)
+	return requests, configDir
+}
+
+func TestRefreshRecords(t *testing.T) {
+	body := refreshedRecords
+	requests, configDir := setupRefreshTest(t, &body)
+
+	diffs, cancel := SubscribeRecords()
+	defer cancel()
+
+	diff, err := RefreshRecords(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []PairChange{{From: "ja", To: "en", Version: "1.0"}}, diff.Added)
+	assert.Equal(t, []PairChange{{From: "en", To: "ja", OldVersion: "1.0", Version: "1.1"}}, diff.Updated)
+	assert.Equal(t, []PairChange{{From: "en", To: "de", OldVersion: "1.0"}}, diff.Removed)
+	assert.True(t, diff.LanguagesChanged)
+	assert.Equal(t, diff, <-diffs)
+
+	assert.True(t, GetRecords().HasLanguagePair("ja", "en"))
+	saved, err := os.ReadFile(filepath.Join(configDir, RecordsFileName))
+	require.NoError(t, err)
+	assert.Equal(t, refreshedRecords, string(saved))
+
+	// 未变化时服务器返回 304，记录保持不变
+	records := GetRecords()
+	diff, err = RefreshRecords(context.Background())
+	require.NoError(t, err)
+	assert.True(t, diff.Empty())
+	assert.Same(t, records, GetRecords())
+	assert.Equal(t, 2, *requests)
+}
+
+func TestRefreshRecordsRejectsInvalid(t *testing.T) {
+	body := `{"data":[{"sourceLanguage":"en","targetLanguage":"ja","attachment":{"filename":"model.enja.bin.zst"}}]}`
+	_, configDir := setupRefreshTest(t, &body)
+	records := GetRecords()
+
+	_, err := RefreshRecords(context.Background())
+	assert.ErrorContains(t, err, "invalid records.json")
+	assert.Same(t, records, GetRecords())
+	assert.NoFileExists(t, filepath.Join(configDir, RecordsFileName))
+	assert.NoFileExists(t, filepath.Join(configDir, recordsMetaFileName))
+}
+
+func TestInitRecordsNotModified(t *testing.T) {
+	body := refreshedRecords
+	requests, _ := setupRefreshTest(t, &body)
+
+	require.NoError(t, InitRecords())
+	assert.True(t, GetRecords().HasLanguagePair("ja", "en"))
+
+	// 重启后远程未变化，使用本地文件
+	GlobalRecords = nil
+	body = `{"data":[]}`
+	require.NoError(t, InitRecords())
+	assert.True(t, GetRecords().HasLanguagePair("ja", "en"))
+	assert.Equal(t, 2, *requests)
+}
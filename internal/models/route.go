@@ -0,0 +1,132 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrNoRoute 在跳数限制内没有可用的语言对组成翻译路径
+var ErrNoRoute = errors.New("no translation route")
+
+// Graph 语言对组成的有向图，键为源语言，值为可直接翻译到的目标语言
+type Graph map[string][]string
+
+var (
+	graphMu sync.Mutex
+	// pairGraph 记录和本地模型组成的图，替换记录或本地模型变化时重建
+	pairGraph Graph
+	// graphRecords 构建 pairGraph 时的记录，与当前记录不同时重建
+	graphRecords *RecordsData
+)
+
+// PairGraph 返回记录和本地模型中的语言对组成的图，图在替换记录或本地模型变化时重建，调用方不能修改
+func PairGraph() Graph {
+	graphMu.Lock()
+	defer graphMu.Unlock()
+	if pairGraph == nil || graphRecords != GetRecords() {
+		buildPairGraph()
+	}
+	return pairGraph
+}
+
+// rebuildPairGraph 记录或本地模型变化后重建语言对图
+func rebuildPairGraph() {
+	graphMu.Lock()
+	defer graphMu.Unlock()
+	buildPairGraph()
+}
+
+// buildPairGraph 需持有 graphMu
+func buildPairGraph() {
+	graphRecords = GetRecords()
+	g := recordsGraph(graphRecords)
+	for _, m := range LocalModels() {
+		g.add(m.From, m.To)
+	}
+	g.sort()
+	pairGraph = g
+}
+
+// recordsGraph 返回记录中的语言对组成的图
+func recordsGraph(records *RecordsData) Graph {
+	g := make(Graph)
+	if records != nil {
+		for _, record := range records.Data {
+			g.add(record.SourceLanguage, record.TargetLanguage)
+		}
+	}
+	g.sort()
+	return g
+}
+
+func (g Graph) add(from, to string) {
+	for _, t := range g[from] {
+		if t == to {
+			return
+		}
+	}
+	g[from] = append(g[from], to)
+}
+
+func (g Graph) sort() {
+	for _, targets := range g {
+		sort.Strings(targets)
+	}
+}
+
+// Route 返回从 from 到 to 经过语言对最少的路径，包含首尾语言，最多使用 maxHops 个语言对
+// 长度相同的路径中优先经过 pivot，其余按语言代码排序
+func (g Graph) Route(from, to, pivot string, maxHops int) ([]string, error) {
+	if from == to {
+		return []string{from}, nil
+	}
+
+	parent := map[string]string{from: ""}
+	frontier := []string{from}
+	for hops := 1; hops <= maxHops && len(frontier) > 0; hops++ {
+		var next []string
+		for _, lang := range frontier {
+			for _, t := range g.neighbors(lang, pivot) {
+				if _, seen := parent[t]; seen {
+					continue
+				}
+				parent[t] = lang
+				if t == to {
+					route := []string{to}
+					for l := lang; l != ""; l = parent[l] {
+						route = append([]string{l}, route...)
+					}
+					return route, nil
+				}
+				next = append(next, t)
+			}
+		}
+		frontier = next
+	}
+	return nil, fmt.Errorf("%w: %s -> %s within %d hop(s)", ErrNoRoute, from, to, maxHops)
+}
+
+// neighbors 返回可直接翻译到的语言，pivot 排在最前
+func (g Graph) neighbors(lang, pivot string) []string {
+	targets := g[lang]
+	for i, t := range targets {
+		if t == pivot && i > 0 {
+			ordered := make([]string, 0, len(targets))
+			ordered = append(ordered, pivot)
+			ordered = append(ordered, targets[:i]...)
+			return append(ordered, targets[i+1:]...)
+		}
+	}
+	return targets
+}
+
+// RoutePairs 将路径转换为依次使用的语言对
+func RoutePairs(route []string) []Pair {
+	pairs := make([]Pair, 0, len(route))
+	for i := 0; i+1 < len(route); i++ {
+		pairs = append(pairs, Pair{From: route[i], To: route[i+1]})
+	}
+	return pairs
+}
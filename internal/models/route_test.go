@@ -0,0 +1,63 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xxnuo/MTranServer/internal/config"
+)
+
+func TestGraphRoute(t *testing.T) {
+	g := make(Graph)
+	for _, p := range [][2]string{
+		{"ja", "en"}, {"en", "ko"}, {"ja", "fr"}, {"fr", "ko"}, {"en", "de"}, {"de", "xx"}, {"ja", "ko"},
+	} {
+		g.add(p[0], p[1])
+	}
+	g.sort()
+
+	route, err := g.Route("ja", "ko", "en", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ja", "ko"}, route, "direct pair")
+
+	delete(g, "ja")
+	g.add("ja", "fr")
+	g.add("ja", "en")
+	g.sort()
+	route, err = g.Route("ja", "ko", "en", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ja", "en", "ko"}, route, "preferred pivot")
+
+	route, err = g.Route("ja", "ko", "fr", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ja", "fr", "ko"}, route)
+
+	route, err = g.Route("ja", "xx", "en", 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ja", "en", "de", "xx"}, route)
+
+	_, err = g.Route("ja", "xx", "en", 2)
+	assert.ErrorIs(t, err, ErrNoRoute)
+	_, err = g.Route("ko", "ja", "en", 5)
+	assert.ErrorIs(t, err, ErrNoRoute)
+
+	assert.Equal(t, []Pair{{From: "ja", To: "en"}, {From: "en", To: "de"}}, RoutePairs([]string{"ja", "en", "de"}))
+}
+
+func TestPairGraphRebuiltOnReplace(t *testing.T) {
+	useTestGlobals(t, &config.Config{},
+		RecordItem{SourceLanguage: "en", TargetLanguage: "ja", Version: "1.0"},
+	)
+
+	g := PairGraph()
+	assert.Equal(t, Graph{"en": {"ja"}}, g)
+	g["en"][0] = "xx"
+	assert.Equal(t, "xx", PairGraph()["en"][0], "graph is reused until records are replaced")
+
+	replaceRecords(nil, &RecordsData{Data: []RecordItem{
+		{SourceLanguage: "en", TargetLanguage: "ja", Version: "1.0"},
+		{SourceLanguage: "ja", TargetLanguage: "en", Version: "1.0"},
+	}})
+	assert.Equal(t, Graph{"en": {"ja"}, "ja": {"en"}}, PairGraph())
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/downloader"
+	"github.com/xxnuo/MTranServer/internal/logger"
+)
+
+// recordsSourcesDirName 配置目录下缓存远程附加记录的目录
+const recordsSourcesDirName = "records.sources"
+
+// loadSources 按配置顺序读取附加记录来源，无法读取或无效的来源会被跳过
+// online 为 false 时远程来源只使用上次缓存的内容
+func loadSources(ctx context.Context, online bool) [][]RecordItem {
+	var sources [][]RecordItem
+	for _, src := range splitList(config.GetConfig().RecordsSources) {
+		records, err := loadSource(ctx, src, online)
+		if err != nil {
+			logger.Warn("Skipping records source %s: %v", src, err)
+			continue
+		}
+		logger.Debug("Loaded %d model records from %s", len(records), src)
+		sources = append(sources, records)
+	}
+	return sources
+}
+
+// loadSource 读取一个附加记录来源，本地文件直接读取，远程来源获取失败时使用缓存
+func loadSource(ctx context.Context, src string, online bool) ([]RecordItem, error) {
+	if !strings.Contains(src, "://") {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return nil, err
+		}
+		return parseSource(data)
+	}
+	if strings.HasPrefix(src, "file://") {
+		data, _, err := downloader.New(".").Fetch(ctx, []string{src}, downloader.Validator{})
+		if err != nil {
+			return nil, err
+		}
+		return parseSource(data)
+	}
+
+	cacheDir := filepath.Join(config.GetConfig().ConfigDir, recordsSourcesDirName)
+	name := computeHash([]byte(src))[:16]
+	cachePath := filepath.Join(cacheDir, name+".json")
+	metaPath := filepath.Join(cacheDir, name+".meta.json")
+	urls := []string{src}
+
+	if online {
+		var v downloader.Validator
+		if _, err := os.Stat(cachePath); err == nil {
+			v = loadValidator(metaPath, urls)
+		}
+		data, next, err := downloader.New(cacheDir).Fetch(ctx, urls, v)
+		switch {
+		case err == nil:
+			records, err := parseSource(data)
+			if err != nil {
+				logger.Warn("Records source %s is invalid, using cached copy: %v", src, err)
+				break
+			}
+			if err := os.MkdirAll(cacheDir, 0755); err == nil {
+				err = writeFileAtomic(cachePath, data)
+			}
+			if err != nil {
+				logger.Warn("Failed to cache records source %s: %v", src, err)
+			} else {
+				saveValidator(metaPath, urls, next)
+			}
+			return records, nil
+		case errors.Is(err, downloader.ErrNotModified):
+		default:
+			logger.Warn("Failed to fetch records source %s, using cached copy: %v", src, err)
+		}
+	}
+
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil, fmt.Errorf("no cached copy: %w", err)
+	}
+	return parseSource(data)
+}
+
+func parseSource(data []byte) ([]RecordItem, error) {
+	records, err := parseRecords(data)
+	if err != nil {
+		return nil, err
+	}
+	return records.Data, nil
+}
+
+// layerRecords 将附加来源依次叠加到基础记录上，返回新的列表
+// 语言对、文件类型、架构和版本都相同的记录由后面的来源覆盖，其余记录追加
+func layerRecords(base []RecordItem, sources [][]RecordItem) []RecordItem {
+	layered := append([]RecordItem(nil), base...)
+	index := make(map[string]int, len(layered))
+	for i, r := range layered {
+		index[layerKey(r)] = i
+	}
+	for _, source := range sources {
+		for _, r := range source {
+			key := layerKey(r)
+			if i, ok := index[key]; ok {
+				layered[i] = r
+				continue
+			}
+			index[key] = len(layered)
+			layered = append(layered, r)
+		}
+	}
+	return layered
+}
+
+func layerKey(r RecordItem) string {
+	return strings.Join([]string{r.SourceLanguage, r.TargetLanguage, r.FileType, r.Architecture, r.Version}, "/")
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xxnuo/MTranServer/internal/config"
+)
+
+func TestLayerRecords(t *testing.T) {
+	base := []RecordItem{
+		{SourceLanguage: "en", TargetLanguage: "ja", FileType: "model", Version: "1.0", Attachment: Attachment{Filename: "a"}},
+		{SourceLanguage: "en", TargetLanguage: "ja", FileType: "lex", Version: "1.0", Attachment: Attachment{Filename: "b"}},
+	}
+	first := []RecordItem{
+		{SourceLanguage: "en", TargetLanguage: "ja", FileType: "model", Version: "1.0", Attachment: Attachment{Filename: "c"}},
+		{SourceLanguage: "en", TargetLanguage: "ko", FileType: "model", Version: "1.0", Attachment: Attachment{Filename: "d"}},
+	}
+	second := []RecordItem{
+		{SourceLanguage: "en", TargetLanguage: "ja", FileType: "model", Version: "1.0", Attachment: Attachment{Filename: "e"}},
+	}
+
+	layered := layerRecords(base, [][]RecordItem{first, second})
+	var names []string
+	for _, r := range layered {
+		names = append(names, r.Attachment.Filename)
+	}
+	assert.Equal(t, []string{"e", "b", "d"}, names)
+	assert.Equal(t, "a", base[0].Attachment.Filename)
+}
+
+func TestChannelRecordsURL(t *testing.T) {
+	assert.Equal(t, RecordsUrl, ChannelRecordsURL("preview"))
+	assert.Equal(t, RecordsUrl, ChannelRecordsURL(""))
+	assert.Contains(t, ChannelRecordsURL("production"), "/buckets/main/collections/")
+}
+
+func TestRefreshRecordsSources(t *testing.T) {
+	body := refreshedRecords
+	setupRefreshTest(t, &body)
+
+	custom := `{"data":[
+		{"sourceLanguage":"en","targetLanguage":"ja","version":"1.1","fileType":"model","attachment":{"filename":"model.enja.bin.zst","location":"https://models.example.com/enja.zst"}},
+		{"sourceLanguage":"en","targetLanguage":"xx","version":"0.1","fileType":"model","attachment":{"filename":"model.enxx.bin.zst"}}
+	]}`
+	localPath := filepath.Join(t.TempDir(), "custom.json")
+	require.NoError(t, os.WriteFile(localPath, []byte(custom), 0644))
+
+	remote := `{"data":[{"sourceLanguage":"en","targetLanguage":"yy","version":"1.0","fileType":"model","attachment":{"filename":"model.enyy.bin.zst"}}]}`
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(remote))
+	}))
+	defer server.Close()
+	config.GlobalConfig.RecordsSources = server.URL + "," + localPath
+
+	diff, err := RefreshRecords(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, diff.Added, 3)
+	records := GetRecords()
+	assert.True(t, records.HasLanguagePair("en", "xx"))
+	assert.True(t, records.HasLanguagePair("en", "yy"))
+	enja, err := selectRecords("en", "ja", "1.1")
+	require.NoError(t, err)
+	require.Len(t, enja, 1)
+	assert.Equal(t, []string{"https://models.example.com/enja.zst"}, attachmentURLs(enja[0].Attachment.Location))
+
+	// records.json 未变化时仍然读取附加来源
+	custom = `{"data":[{"sourceLanguage":"en","targetLanguage":"zz","version":"0.1","fileType":"model","attachment":{"filename":"model.enzz.bin.zst"}}]}`
+	require.NoError(t, os.WriteFile(localPath, []byte(custom), 0644))
+	diff, err = RefreshRecords(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []PairChange{{From: "en", To: "zz", Version: "0.1"}}, diff.Added)
+	assert.Equal(t, []PairChange{{From: "en", To: "xx", OldVersion: "0.1"}}, diff.Removed)
+
+	// 远程来源无法访问时使用缓存，无效的本地来源被跳过
+	server.Close()
+	require.NoError(t, os.WriteFile(localPath, []byte(`{"data":[]}`), 0644))
+	diff, err = RefreshRecords(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, diff.Added)
+	assert.Equal(t, []PairChange{{From: "en", To: "zz", OldVersion: "0.1"}}, diff.Removed)
+	assert.True(t, GetRecords().HasLanguagePair("en", "yy"))
+	assert.Equal(t, 2, requests)
+}
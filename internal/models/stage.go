@@ -0,0 +1,214 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/logger"
+	"github.com/xxnuo/MTranServer/internal/utils"
+)
+
+// stagingDirName 模型目录下滚动升级使用的暂存目录，结构为 .staging/<from>_<to>/<version>/
+const stagingDirName = ".staging"
+
+var (
+	stagedMu sync.Mutex
+	// staged 使用中的暂存目录，GC 不会清理
+	staged = make(map[string]bool)
+)
+
+func stagingDir(modelDir string, p Pair, version string) string {
+	return filepath.Join(modelDir, stagingDirName, p.String(), version)
+}
+
+func isStaged(dir string) bool {
+	stagedMu.Lock()
+	defer stagedMu.Unlock()
+	return staged[dir]
+}
+
+// InstalledVersion 返回语言对目录中模型的版本，未知时返回空字符串
+func InstalledVersion(modelDir, fromLang, toLang string) string {
+	return installedVersion(PairDir(modelDir, fromLang, toLang))
+}
+
+// TargetVersion 返回语言对应使用的模型版本：配置中固定的版本，或记录中的最新版本
+func TargetVersion(fromLang, toLang string) (string, error) {
+	if GetRecords() == nil {
+		if err := InitRecords(); err != nil {
+			return "", err
+		}
+	}
+	records, err := selectRecords(fromLang, toLang, config.GetConfig().ModelVersionFor(fromLang, toLang))
+	if err != nil {
+		return "", err
+	}
+	return recordsVersion(records), nil
+}
+
+// StageModel 将语言对应使用的版本准备到暂存目录，不修改语言对目录中正在使用的模型
+// 与当前模型相同的文件以硬链接复用，其余文件从保留的旧版本恢复或下载
+// 返回的目录在 ReleaseStaged 之前不会被 GC 清理
+func StageModel(modelDir, fromLang, toLang string) (dir, version string, err error) {
+	if GetRecords() == nil {
+		if err := InitRecords(); err != nil {
+			return "", "", err
+		}
+	}
+	cfg := config.GetConfig()
+	records, err := selectRecords(fromLang, toLang, cfg.ModelVersionFor(fromLang, toLang))
+	if err != nil {
+		return "", "", err
+	}
+
+	p := Pair{From: fromLang, To: toLang}
+	version = recordsVersion(records)
+	dir = stagingDir(modelDir, p, version)
+
+	key := dir
+	stagedMu.Lock()
+	staged[key] = true
+	stagedMu.Unlock()
+	defer func() {
+		if err != nil {
+			// 保留已下载的部分，下次暂存时继续，未使用的暂存目录由 GC 清理
+			stagedMu.Lock()
+			delete(staged, key)
+			stagedMu.Unlock()
+		}
+	}()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	pending := stageLocal(modelDir, p, dir, records)
+	if len(pending) > 0 {
+		if cfg.EnableOfflineMode {
+			return "", "", fmt.Errorf("offline mode: %d model file(s) of version %s for %s -> %s are not available locally",
+				len(pending), version, fromLang, toLang)
+		}
+		logger.Info("Downloading model version %s for %s -> %s into staging", version, fromLang, toLang)
+		progress := startProgress(fromLang, toLang, pending)
+		err := downloadRecords(dir, pending, progress)
+		progress.finish(err)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	writeVersionFile(dir, records)
+	logger.Info("Staged model version %s for %s -> %s", version, fromLang, toLang)
+	return dir, version, nil
+}
+
+// stageLocal 从语言对目录和保留的旧版本复用文件，返回需要下载的记录
+func stageLocal(modelDir string, p Pair, dir string, records []RecordItem) []RecordItem {
+	unlock := lockPair(p.From, p.To)
+	defer unlock()
+
+	pairDir := PairDir(modelDir, p.From, p.To)
+	var pending []RecordItem
+	for _, record := range records {
+		name := strings.TrimSuffix(record.Attachment.Filename, ".zst")
+		dst := filepath.Join(dir, name)
+		if fileMatches(dst, record) {
+			continue
+		}
+		if src := filepath.Join(pairDir, name); fileMatches(src, record) {
+			if err := linkOrCopy(src, dst); err == nil {
+				continue
+			}
+		}
+		if restoreRecord(modelDir, record, dst) {
+			continue
+		}
+		pending = append(pending, record)
+	}
+	return pending
+}
+
+// fileMatches 文件存在且与记录中的大小和哈希一致
+func fileMatches(path string, record RecordItem) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if record.DecompressedSize > 0 && info.Size() != record.DecompressedSize {
+		return false
+	}
+	if record.DecompressedHash == "" {
+		return true
+	}
+	return utils.VerifySHA256(path, record.DecompressedHash) == nil
+}
+
+// PromoteStaged 将暂存目录中的模型安装到语言对目录，当前版本按配置保留
+// 暂存目录中的文件保持不变，仍可供已启动的 Worker 使用
+func PromoteStaged(modelDir, fromLang, toLang, dir string) error {
+	unlock := lockPair(fromLang, toLang)
+	defer unlock()
+
+	p := Pair{From: fromLang, To: toLang}
+	pairDir := PairDir(modelDir, fromLang, toLang)
+	active := installedVersion(pairDir)
+	version := installedVersion(dir)
+	if version == "" {
+		return fmt.Errorf("staging directory %s has no model version", dir)
+	}
+	if active != version {
+		retainVersion(modelDir, p, active)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read staging directory: %w", err)
+	}
+	if err := os.MkdirAll(pairDir, 0755); err != nil {
+		return fmt.Errorf("failed to create language pair directory: %w", err)
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || name == versionFileName || strings.HasSuffix(name, ".zst") || strings.HasSuffix(name, ".part") {
+			continue
+		}
+		dst := filepath.Join(pairDir, name)
+		tmp := dst + ".restore"
+		if err := linkOrCopy(filepath.Join(dir, name), tmp); err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("failed to install %s: %w", name, err)
+		}
+		if err := os.Rename(tmp, dst); err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("failed to install %s: %w", name, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(pairDir, versionFileName), []byte(version+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write model version file: %w", err)
+	}
+	if records, err := selectRecords(fromLang, toLang, version); err == nil {
+		removeStaleFiles(pairDir, p, records)
+	}
+
+	applyRetention(modelDir, p, version)
+	logger.Info("Installed model version %s for %s -> %s", version, fromLang, toLang)
+	return nil
+}
+
+// ReleaseStaged 删除不再使用的暂存目录
+func ReleaseStaged(dir string) {
+	stagedMu.Lock()
+	delete(staged, dir)
+	stagedMu.Unlock()
+
+	if err := os.RemoveAll(dir); err != nil {
+		logger.Warn("Failed to remove staging directory %s: %v", dir, err)
+		return
+	}
+	// 语言对下没有其他暂存版本时一并删除
+	os.Remove(filepath.Dir(dir))
+}
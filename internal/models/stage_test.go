@@ -0,0 +1,60 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xxnuo/MTranServer/internal/config"
+)
+
+func TestStageAndPromoteModel(t *testing.T) {
+	modelDir := setupStoreTest(t)
+	config.GlobalConfig.Pairs = map[string]config.PairConfig{"xx-yy": {Version: "1.0"}}
+
+	version, err := TargetVersion("xx", "yy")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0", version)
+
+	// 从保留的旧版本准备暂存目录，语言对目录保持不变
+	dir, version, err := StageModel(modelDir, "xx", "yy")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0", version)
+	assert.Equal(t, stagingDir(modelDir, Pair{"xx", "yy"}, "1.0"), dir)
+	data, err := os.ReadFile(filepath.Join(dir, "model.xxyy.bin"))
+	require.NoError(t, err)
+	assert.Equal(t, "1.0/model", string(data))
+	assert.Equal(t, "1.1", InstalledVersion(modelDir, "xx", "yy"))
+
+	// 使用中的暂存目录不会被清理
+	result, err := GC(modelDir, false)
+	require.NoError(t, err)
+	assert.Empty(t, result.Removed)
+
+	require.NoError(t, PromoteStaged(modelDir, "xx", "yy", dir))
+	assert.Equal(t, "1.0", InstalledVersion(modelDir, "xx", "yy"))
+	assert.Equal(t, "1.0/lex", readModelFile(t, modelDir, "lex.xxyy.bin"))
+	assert.Equal(t, []string{"1.1"}, RetainedVersions(modelDir, "xx", "yy"))
+	assert.FileExists(t, filepath.Join(dir, "lex.xxyy.bin"))
+
+	ReleaseStaged(dir)
+	assert.NoDirExists(t, filepath.Join(modelDir, stagingDirName, "xx_yy"))
+}
+
+func TestStageModelOffline(t *testing.T) {
+	modelDir := setupStoreTest(t)
+	config.GlobalConfig.ModelKeepVersions = 0
+	require.NoError(t, os.RemoveAll(filepath.Join(modelDir, versionsDirName)))
+	config.GlobalConfig.Pairs = map[string]config.PairConfig{"xx-yy": {Version: "1.0"}}
+	config.GlobalConfig.EnableOfflineMode = true
+
+	_, _, err := StageModel(modelDir, "xx", "yy")
+	assert.ErrorContains(t, err, "offline mode")
+
+	// 中断的暂存由 GC 清理
+	result, err := GC(modelDir, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{".staging/xx_yy/1.0"}, result.Removed)
+}
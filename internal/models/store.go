@@ -0,0 +1,221 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/logger"
+	"github.com/xxnuo/MTranServer/internal/utils"
+)
+
+// versionsDirName 模型目录下保存旧版本模型的目录，结构为 .versions/<from>_<to>/<version>/
+const versionsDirName = ".versions"
+
+func versionDir(modelDir, fromLang, toLang, version string) string {
+	return filepath.Join(modelDir, versionsDirName, pairKey(fromLang, toLang), version)
+}
+
+// installedVersion 返回语言对目录中模型的版本，未知时返回空字符串
+func installedVersion(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, versionFileName))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// RetainedVersions 返回语言对保留的旧版本，从新到旧排序
+func RetainedVersions(modelDir, fromLang, toLang string) []string {
+	entries, err := os.ReadDir(filepath.Join(modelDir, versionsDirName, pairKey(fromLang, toLang)))
+	if err != nil {
+		return nil
+	}
+	var versions []string
+	for _, e := range entries {
+		if e.IsDir() {
+			versions = append(versions, e.Name())
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return utils.CompareVersions(versions[i], versions[j]) > 0
+	})
+	return versions
+}
+
+// retainVersion 在覆盖前保留当前版本的模型文件，优先使用硬链接，不额外占用磁盘
+func retainVersion(modelDir string, p Pair, version string) {
+	if version == "" || config.GetConfig().ModelKeepVersions <= 0 {
+		return
+	}
+	dir := PairDir(modelDir, p.From, p.To)
+	dst := versionDir(modelDir, p.From, p.To, version)
+
+	retained := 0
+	for _, record := range GetRecords().Data {
+		if record.SourceLanguage != p.From || record.TargetLanguage != p.To || record.Version != version {
+			continue
+		}
+		name := strings.TrimSuffix(record.Attachment.Filename, ".zst")
+		src := filepath.Join(dir, name)
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		if err := os.MkdirAll(dst, 0755); err != nil {
+			logger.Warn("Failed to create version directory %s: %v", dst, err)
+			return
+		}
+		if err := linkOrCopy(src, filepath.Join(dst, name)); err != nil {
+			logger.Warn("Failed to keep %s of version %s: %v", name, version, err)
+			continue
+		}
+		retained++
+	}
+	if retained > 0 {
+		logger.Info("Kept model version %s for %s -> %s", version, p.From, p.To)
+	}
+}
+
+// restoreRecord 从保留的旧版本恢复文件，文件存在且哈希一致时返回 true
+func restoreRecord(modelDir string, record RecordItem, dst string) bool {
+	name := strings.TrimSuffix(record.Attachment.Filename, ".zst")
+	src := filepath.Join(versionDir(modelDir, record.SourceLanguage, record.TargetLanguage, record.Version), name)
+	if _, err := os.Stat(src); err != nil {
+		return false
+	}
+	if record.DecompressedHash != "" {
+		if err := utils.VerifySHA256(src, record.DecompressedHash); err != nil {
+			logger.Warn("Kept model file %s is corrupt, downloading again: %v", src, err)
+			return false
+		}
+	}
+
+	tmp := dst + ".restore"
+	os.Remove(tmp)
+	if err := linkOrCopy(src, tmp); err != nil {
+		logger.Warn("Failed to restore %s: %v", src, err)
+		return false
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		logger.Warn("Failed to restore %s: %v", src, err)
+		return false
+	}
+	logger.Debug("Restored %s from kept version %s", name, record.Version)
+	return true
+}
+
+// linkOrCopy 创建硬链接，跨文件系统等无法链接时复制
+func linkOrCopy(src, dst string) error {
+	os.Remove(dst)
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+// pruneVersions 删除超出保留数量的旧版本以及与当前版本相同的保留版本，返回删除的目录
+func pruneVersions(modelDir string, p Pair, keep int, active string, dryRun bool) []string {
+	var removed []string
+	kept := 0
+	for _, v := range RetainedVersions(modelDir, p.From, p.To) {
+		if v != active && kept < keep {
+			kept++
+			continue
+		}
+		dir := versionDir(modelDir, p.From, p.To, v)
+		removed = append(removed, dir)
+		if !dryRun {
+			if err := os.RemoveAll(dir); err != nil {
+				logger.Warn("Failed to remove kept model version %s: %v", dir, err)
+			}
+		}
+	}
+	return removed
+}
+
+type retainedVersion struct {
+	dir  string
+	size int64
+	// order 越小越先删除
+	order int64
+}
+
+// enforceQuota 模型目录占用 used 字节且超过配额时，从最早保留的版本开始删除，返回删除的版本
+// 当前使用的模型不会被删除，exclude 中的目录视为已删除
+func enforceQuota(modelDir string, quota, used int64, exclude map[string]bool, dryRun bool) []retainedVersion {
+	if quota <= 0 || used <= quota {
+		return nil
+	}
+
+	var candidates []retainedVersion
+	pairs, _ := os.ReadDir(filepath.Join(modelDir, versionsDirName))
+	for _, pe := range pairs {
+		versions, _ := os.ReadDir(filepath.Join(modelDir, versionsDirName, pe.Name()))
+		for _, ve := range versions {
+			info, err := ve.Info()
+			if err != nil || !ve.IsDir() {
+				continue
+			}
+			dir := filepath.Join(modelDir, versionsDirName, pe.Name(), ve.Name())
+			if exclude[dir] {
+				continue
+			}
+			candidates = append(candidates, retainedVersion{dir: dir, size: dirSize(dir), order: info.ModTime().UnixNano()})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].order < candidates[j].order
+	})
+
+	var removed []retainedVersion
+	for _, c := range candidates {
+		if used <= quota {
+			break
+		}
+		removed = append(removed, c)
+		used -= c.size
+		if !dryRun {
+			if err := os.RemoveAll(c.dir); err != nil {
+				logger.Warn("Failed to remove kept model version %s: %v", c.dir, err)
+			}
+		}
+	}
+	if used > quota {
+		logger.Warn("Model directory uses %s, exceeding the disk quota of %s",
+			formatMB(used), formatMB(quota))
+	}
+	return removed
+}
+
+func formatMB(n int64) string {
+	return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+}
+
+// applyRetention 下载新版本后按配置清理保留的旧版本
+func applyRetention(modelDir string, p Pair, active string) {
+	cfg := config.GetConfig()
+	pruneVersions(modelDir, p, cfg.ModelKeepVersions, active, false)
+	if cfg.ModelDiskQuota > 0 {
+		enforceQuota(modelDir, int64(cfg.ModelDiskQuota)<<20, dirSize(modelDir), nil, false)
+	}
+}
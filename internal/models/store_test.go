@@ -0,0 +1,131 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xxnuo/MTranServer/internal/config"
+)
+
+// setupStoreTest 准备 xx -> yy 的 1.0 和 1.1 两个版本，1.1 已安装，1.0 已保留
+func setupStoreTest(t *testing.T) string {
+	modelDir := t.TempDir()
+	useTestGlobals(t, &config.Config{ConfigDir: t.TempDir(), ModelDir: modelDir, ModelKeepVersions: 1})
+
+	pair := Pair{"xx", "yy"}
+	kept := writeTestModel(t, versionDir(modelDir, "xx", "yy", "1.0"), pair, "1.0", "1.0")
+	installed := writeTestModel(t, PairDir(modelDir, "xx", "yy"), pair, "1.1", "1.1")
+	require.NoError(t, os.WriteFile(filepath.Join(PairDir(modelDir, "xx", "yy"), versionFileName), []byte("1.1\n"), 0644))
+	GlobalRecords.Data = append(kept, installed...)
+	return modelDir
+}
+
+func readModelFile(t *testing.T, modelDir, name string) string {
+	data, err := os.ReadFile(filepath.Join(PairDir(modelDir, "xx", "yy"), name))
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestPinnedVersionRestoresKeptVersion(t *testing.T) {
+	modelDir := setupStoreTest(t)
+	config.GlobalConfig.Pairs = map[string]config.PairConfig{"xx-yy": {Version: "1.0"}}
+
+	// 固定的旧版本从保留的文件恢复，无需下载
+	require.NoError(t, DownloadModel("yy", "xx", ""))
+	assert.Equal(t, "1.0/model", readModelFile(t, modelDir, "model.xxyy.bin"))
+
+	s := GetModelStatus(modelDir, "xx", "yy")
+	assert.Equal(t, "1.0", s.Version)
+	assert.Equal(t, "1.0", s.PinnedVersion)
+	assert.Equal(t, "1.1", s.LatestVersion)
+	assert.Equal(t, []string{"1.1"}, s.RetainedVersions)
+
+	// 取消固定后恢复到最新版本
+	config.GlobalConfig.Pairs = nil
+	require.NoError(t, DownloadModel("yy", "xx", ""))
+	assert.Equal(t, "1.1/lex", readModelFile(t, modelDir, "lex.xxyy.bin"))
+	assert.Equal(t, []string{"1.0"}, RetainedVersions(modelDir, "xx", "yy"))
+}
+
+func TestKeepVersionsLimit(t *testing.T) {
+	modelDir := setupStoreTest(t)
+	config.GlobalConfig.ModelKeepVersions = 0
+	config.GlobalConfig.Pairs = map[string]config.PairConfig{"xx-yy": {Version: "1.0"}}
+
+	require.NoError(t, DownloadModel("yy", "xx", ""))
+	assert.Equal(t, "1.0/vocab", readModelFile(t, modelDir, "vocab.xxyy.bin"))
+	assert.Empty(t, RetainedVersions(modelDir, "xx", "yy"))
+}
+
+func TestGC(t *testing.T) {
+	modelDir := setupStoreTest(t)
+	pairDir := PairDir(modelDir, "xx", "yy")
+
+	// 1.0 额外使用的文件，1.1 不再使用
+	GlobalRecords.Data = append(GlobalRecords.Data, RecordItem{
+		ID: "1.0-srcvocab", SourceLanguage: "xx", TargetLanguage: "yy", Version: "1.0",
+		FileType: "srcvocab", Attachment: Attachment{Filename: "srcvocab.xxyy.spm.zst"},
+	})
+	files := map[string]string{
+		filepath.Join(pairDir, "srcvocab.xxyy.spm"):                                "old",
+		filepath.Join(pairDir, "model.xxyy.bin.zst"):                               "compressed",
+		filepath.Join(pairDir, "lex.xxyy.bin.zst.part"):                            "partial",
+		filepath.Join(pairDir, "worker.log"):                                       "unknown",
+		filepath.Join(modelDir, "aa_bb", "model.aabb.bin"):                         "orphan",
+		filepath.Join(modelDir, quarantineDirName, "x", "f"):                       "corrupt",
+		filepath.Join(modelDir, versionsDirName, "aa_bb", "1.0", "model.aabb.bin"): "orphan",
+	}
+	for path, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	dry, err := GC(modelDir, true)
+	require.NoError(t, err)
+	assert.True(t, dry.DryRun)
+	assert.ElementsMatch(t, []string{
+		".quarantine",
+		".versions/aa_bb",
+		"aa_bb",
+		"xx_yy/lex.xxyy.bin.zst.part",
+		"xx_yy/model.xxyy.bin.zst",
+		"xx_yy/srcvocab.xxyy.spm",
+	}, dry.Removed)
+	assert.Positive(t, dry.FreedBytes)
+	assert.FileExists(t, filepath.Join(pairDir, "srcvocab.xxyy.spm"))
+
+	result, err := GC(modelDir, false)
+	require.NoError(t, err)
+	assert.Equal(t, dry.Removed, result.Removed)
+	assert.Equal(t, dry.FreedBytes, result.FreedBytes)
+
+	assert.NoFileExists(t, filepath.Join(pairDir, "srcvocab.xxyy.spm"))
+	assert.NoDirExists(t, filepath.Join(modelDir, "aa_bb"))
+	assert.FileExists(t, filepath.Join(pairDir, "worker.log"))
+	assert.FileExists(t, filepath.Join(pairDir, "model.xxyy.bin"))
+	assert.Equal(t, []string{"1.0"}, RetainedVersions(modelDir, "xx", "yy"))
+	assert.True(t, IsModelDownloaded(modelDir, "xx", "yy"))
+}
+
+func TestGCDiskQuota(t *testing.T) {
+	modelDir := setupStoreTest(t)
+	config.GlobalConfig.ModelKeepVersions = 5
+
+	// 再保留一个更早的版本
+	older := versionDir(modelDir, "xx", "yy", "0.9")
+	require.NoError(t, os.MkdirAll(older, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(older, "model.xxyy.bin"), make([]byte, 1<<20), 0644))
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(older, past, past))
+
+	// 配额 1 MB，删除最早保留的 0.9 即可满足
+	config.GlobalConfig.ModelDiskQuota = 1
+	result, err := GC(modelDir, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{".versions/xx_yy/0.9"}, result.Removed)
+	assert.Equal(t, []string{"1.0"}, RetainedVersions(modelDir, "xx", "yy"))
+}
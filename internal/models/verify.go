@@ -0,0 +1,225 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/logger"
+	"github.com/xxnuo/MTranServer/internal/utils"
+)
+
+// quarantineDirName 模型目录下存放损坏文件的目录
+const quarantineDirName = ".quarantine"
+
+var ErrModelCorrupt = errors.New("model files are corrupt")
+
+// 模型文件校验结果
+const (
+	FileOK           = "ok"
+	FileSizeMismatch = "size_mismatch"
+	FileHashMismatch = "hash_mismatch"
+)
+
+// FileCheck 单个模型文件的校验结果
+type FileCheck struct {
+	File   string `json:"file" example:"model.enja.intgemm.alphas.bin"`
+	Size   int64  `json:"size"`
+	Status string `json:"status" example:"ok"`
+	// Quarantined 损坏文件移动后的路径
+	Quarantined string `json:"quarantined,omitempty"`
+}
+
+// ModelCheck 语言对模型的校验结果
+type ModelCheck struct {
+	From  string      `json:"from" example:"en"`
+	To    string      `json:"to" example:"ja"`
+	Files []FileCheck `json:"files"`
+	// Healthy 所有文件完好且模型完整
+	Healthy  bool   `json:"healthy"`
+	Repaired bool   `json:"repaired,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// InstalledPairs 返回模型目录中存在的语言对，按语言对排序
+func InstalledPairs(modelDir string) ([]Pair, error) {
+	if GetRecords() == nil {
+		if err := InitRecords(); err != nil {
+			return nil, err
+		}
+	}
+
+	seen := make(map[Pair]bool)
+	var pairs []Pair
+	for _, record := range GetRecords().Data {
+		p := Pair{From: record.SourceLanguage, To: record.TargetLanguage}
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		if _, err := os.Stat(PairDir(modelDir, p.From, p.To)); err == nil {
+			pairs = append(pairs, p)
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].String() < pairs[j].String()
+	})
+	return pairs, nil
+}
+
+// VerifyModel 按记录中的 DecompressedSize 和 DecompressedHash 校验语言对的模型文件
+// repair 为 true 时将损坏的文件移到隔离目录，并重新下载缺失的文件，离线模式下需要重新导入模型包
+func VerifyModel(modelDir string, p Pair, repair bool) ModelCheck {
+	c := CheckModel(modelDir, p, repair)
+	if !repair || c.Healthy {
+		return c
+	}
+
+	if config.GetConfig().EnableOfflineMode {
+		c.Error = "offline mode: import a model bundle to restore the model"
+		return c
+	}
+	logger.Info("Repairing model %s -> %s", p.From, p.To)
+	if err := DownloadModel(p.To, p.From, ""); err != nil {
+		c.Error = err.Error()
+		return c
+	}
+	c.Repaired = true
+	c.Healthy = IsModelDownloaded(modelDir, p.From, p.To)
+	return c
+}
+
+// CheckModel 校验语言对目录中的文件，quarantine 为 true 时将损坏的文件移到隔离目录
+func CheckModel(modelDir string, p Pair, quarantine bool) ModelCheck {
+	c := ModelCheck{From: p.From, To: p.To, Files: []FileCheck{}, Healthy: true}
+	if GetRecords() == nil {
+		if err := InitRecords(); err != nil {
+			c.Healthy = false
+			c.Error = err.Error()
+			return c
+		}
+	}
+
+	unlock := lockPair(p.From, p.To)
+	defer unlock()
+
+	dir := PairDir(modelDir, p.From, p.To)
+
+	for _, name := range pairFileNames(p) {
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		fc := FileCheck{File: name, Size: info.Size(), Status: checkFile(p, name, path, info.Size())}
+		if fc.Status != FileOK {
+			c.Healthy = false
+			logger.Warn("Model file %s/%s is corrupt: %s", p.String(), name, fc.Status)
+			if quarantine {
+				dst, err := quarantineFile(modelDir, p, path)
+				if err != nil {
+					c.Error = err.Error()
+				}
+				fc.Quarantined = dst
+			}
+		}
+		c.Files = append(c.Files, fc)
+	}
+
+	if !IsModelDownloaded(modelDir, p.From, p.To) {
+		c.Healthy = false
+	}
+	return c
+}
+
+// pairFileNames 返回语言对所有版本的模型文件名（解压后）
+func pairFileNames(p Pair) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, record := range GetRecords().Data {
+		if record.SourceLanguage != p.From || record.TargetLanguage != p.To {
+			continue
+		}
+		name := strings.TrimSuffix(record.Attachment.Filename, ".zst")
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkFile 文件与任一版本的记录一致即为完好，先比较大小以便快速发现截断的文件
+func checkFile(p Pair, name, path string, size int64) string {
+	candidates := recordsWithSize(p, name, size)
+	if len(candidates) == 0 {
+		return FileSizeMismatch
+	}
+
+	hash, err := utils.ComputeSHA256(path)
+	if err != nil {
+		logger.Warn("Failed to compute hash for %s: %v", path, err)
+		return FileHashMismatch
+	}
+	for _, record := range candidates {
+		if record.DecompressedHash == "" || record.DecompressedHash == hash {
+			return FileOK
+		}
+	}
+	return FileHashMismatch
+}
+
+// CheckModelSizes 比较模型文件与记录中的大小，用于在启动 Worker 前发现截断的文件
+func CheckModelSizes(modelDir, fromLang, toLang string) error {
+	files, err := GetModelFiles(modelDir, fromLang, toLang)
+	if err != nil {
+		return err
+	}
+	p := Pair{From: fromLang, To: toLang}
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if len(recordsWithSize(p, filepath.Base(path), info.Size())) > 0 {
+			continue
+		}
+		return fmt.Errorf("%w: %s size %d does not match any record, run verify --repair",
+			ErrModelCorrupt, filepath.Base(path), info.Size())
+	}
+	return nil
+}
+
+// recordsWithSize 返回文件名和解压后大小一致的记录，记录中没有大小时视为一致
+func recordsWithSize(p Pair, name string, size int64) []RecordItem {
+	var records []RecordItem
+	for _, record := range GetRecords().Data {
+		if record.SourceLanguage == p.From && record.TargetLanguage == p.To &&
+			strings.TrimSuffix(record.Attachment.Filename, ".zst") == name &&
+			(record.DecompressedSize == 0 || record.DecompressedSize == size) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// quarantineFile 将损坏的文件移到隔离目录，保留以便排查
+func quarantineFile(modelDir string, p Pair, path string) (string, error) {
+	dir := filepath.Join(modelDir, quarantineDirName, p.String())
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+	dst := filepath.Join(dir, fmt.Sprintf("%s.%s", filepath.Base(path), time.Now().Format("20060102150405")))
+	if err := os.Rename(path, dst); err != nil {
+		return "", fmt.Errorf("failed to quarantine %s: %w", path, err)
+	}
+	logger.Info("Moved corrupt model file %s to %s", path, dst)
+	return dst, nil
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xxnuo/MTranServer/internal/config"
+)
+
+func TestCheckModel(t *testing.T) {
+	modelDir := setupBundleTest(t)
+
+	c := CheckModel(modelDir, Pair{"en", "ja"}, false)
+	assert.True(t, c.Healthy)
+	require.Len(t, c.Files, 3)
+	for _, f := range c.Files {
+		assert.Equal(t, FileOK, f.Status)
+	}
+
+	dir := PairDir(modelDir, "en", "ja")
+	// 截断的文件
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lex.enja.bin"), []byte("en_ja"), 0644))
+	// 大小相同但内容不同
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "model.enja.bin"), []byte("xx_xx/model"), 0644))
+
+	c = CheckModel(modelDir, Pair{"en", "ja"}, false)
+	assert.False(t, c.Healthy)
+	statuses := make(map[string]string)
+	for _, f := range c.Files {
+		statuses[f.File] = f.Status
+	}
+	assert.Equal(t, FileSizeMismatch, statuses["lex.enja.bin"])
+	assert.Equal(t, FileHashMismatch, statuses["model.enja.bin"])
+	assert.Equal(t, FileOK, statuses["vocab.enja.bin"])
+
+	err := CheckModelSizes(modelDir, "en", "ja")
+	assert.True(t, errors.Is(err, ErrModelCorrupt))
+	assert.NoError(t, CheckModelSizes(modelDir, "de", "en"))
+}
+
+func TestVerifyModelOfflineRepair(t *testing.T) {
+	modelDir := setupBundleTest(t)
+	config.GlobalConfig.EnableOfflineMode = true
+
+	dir := PairDir(modelDir, "en", "ja")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lex.enja.bin"), []byte("corrupted"), 0644))
+
+	c := VerifyModel(modelDir, Pair{"en", "ja"}, true)
+	assert.False(t, c.Healthy)
+	assert.False(t, c.Repaired)
+	assert.Contains(t, c.Error, "import a model bundle")
+
+	var quarantined string
+	for _, f := range c.Files {
+		if f.File == "lex.enja.bin" {
+			quarantined = f.Quarantined
+		}
+	}
+	require.NotEmpty(t, quarantined)
+	assert.FileExists(t, quarantined)
+	assert.NoFileExists(t, filepath.Join(dir, "lex.enja.bin"))
+	assert.FileExists(t, filepath.Join(dir, "model.enja.bin"))
+}
+
+func TestInstalledPairs(t *testing.T) {
+	modelDir := setupBundleTest(t)
+	GlobalRecords.Data = append(GlobalRecords.Data, RecordItem{SourceLanguage: "fr", TargetLanguage: "en"})
+
+	pairs, err := InstalledPairs(modelDir)
+	require.NoError(t, err)
+	assert.Equal(t, []Pair{{"de", "en"}, {"en", "ja"}}, pairs)
+}
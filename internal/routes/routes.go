@@ -7,16 +7,24 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"github.com/xxnuo/MTranServer/internal/auth"
 	"github.com/xxnuo/MTranServer/internal/config"
 	"github.com/xxnuo/MTranServer/internal/docs"
 	"github.com/xxnuo/MTranServer/internal/handlers"
+	"github.com/xxnuo/MTranServer/internal/logger"
 	"github.com/xxnuo/MTranServer/internal/middleware"
 	"github.com/xxnuo/MTranServer/ui"
 )
 
+// pluginPaths 插件兼容接口的路径前缀，使用独立的跨域策略
+var pluginPaths = []string{"/imme", "/kiss", "/deepl", "/google", "/hcfy", "/libre"}
+
 func Setup(r *gin.Engine, apiToken string) {
+	cfg := config.GetConfig()
 
-	r.Use(middleware.CORS())
+	cors := middleware.NewCORSRouter(parseCORS(cfg.CORSAPI))
+	cors.Set(pluginPaths, parseCORS(cfg.CORSPlugin))
+	r.Use(cors.Handler())
 
 	docs.SwaggerInfo.BasePath = "/"
 	r.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -26,23 +34,80 @@ func Setup(r *gin.Engine, apiToken string) {
 	r.GET("/__heartbeat__", handlers.HandleHeartbeat)
 	r.GET("/__lbheartbeat__", handlers.HandleLBHeartbeat)
 
-	auth := r.Group("/")
-	if apiToken != "" {
-		auth.Use(middleware.Auth(apiToken))
+	store := auth.GetStore()
+	store.SetLegacyToken(apiToken)
+
+	apiLimit := newRateLimiter(cfg.RateLimitAPI)
+	pluginLimit := newRateLimiter(cfg.RateLimitPlugin)
+
+	config.Subscribe(func(prev, next *config.Config) {
+		if prev.RateLimitAPI != next.RateLimitAPI {
+			apiLimit.SetPolicy(parseRateLimit(next.RateLimitAPI))
+		}
+		if prev.RateLimitPlugin != next.RateLimitPlugin {
+			pluginLimit.SetPolicy(parseRateLimit(next.RateLimitPlugin))
+		}
+		if prev.CORSAPI != next.CORSAPI {
+			cors.SetFallback(parseCORS(next.CORSAPI))
+		}
+		if prev.CORSPlugin != next.CORSPlugin {
+			cors.Set(pluginPaths, parseCORS(next.CORSPlugin))
+		}
+	})
+
+	api := r.Group("/")
+	api.Use(middleware.AuthStore(store, auth.ScopeAPI))
+	api.Use(middleware.RateLimit(apiLimit, nil))
+	api.Use(middleware.RequestLimit(requestLimits, nil))
+
+	api.GET("/languages", handlers.HandleLanguages)
+	api.POST("/translate", handlers.HandleTranslate)
+	api.POST("/translate/batch", handlers.HandleTranslateBatch)
+	api.POST("/detect", handlers.HandleDetect)
+
+	// plugin 插件兼容接口的认证与限流，各插件携带凭证的位置和错误格式不同
+	plugin := func(body middleware.ErrorBodyFunc, sources ...middleware.CredentialSource) []gin.HandlerFunc {
+		return []gin.HandlerFunc{
+			middleware.Authenticate(middleware.AuthOptions{
+				Store:   store,
+				Scope:   auth.ScopePlugin,
+				Sources: sources,
+				Body:    body,
+			}),
+			middleware.RateLimit(pluginLimit, body),
+			middleware.RequestLimit(requestLimits, body),
+		}
 	}
 
-	auth.GET("/languages", handlers.HandleLanguages)
-	auth.POST("/translate", handlers.HandleTranslate)
-	auth.POST("/translate/batch", handlers.HandleTranslateBatch)
+	r.POST("/imme", append(plugin(nil, middleware.CredTokenQuery), handlers.HandleImmeTranslate)...)
+	r.POST("/kiss", append(plugin(nil, middleware.CredKeyHeader), handlers.HandleKissTranslate)...)
+	r.POST("/deepl", append(plugin(handlers.DeeplErrorBody, middleware.CredDeepL, middleware.CredTokenQuery), handlers.HandleDeeplTranslate)...)
+	r.POST("/google/language/translate/v2", append(plugin(handlers.GoogleErrorBody, middleware.CredKeyQuery, middleware.CredBearer, middleware.CredTokenQuery), handlers.HandleGoogleCompatTranslate)...)
+	r.POST("/google/language/translate/v2/detect", append(plugin(handlers.GoogleErrorBody, middleware.CredKeyQuery, middleware.CredBearer, middleware.CredTokenQuery), handlers.HandleGoogleDetect)...)
+	r.GET("/google/translate_a/single", append(plugin(handlers.GoogleErrorBody, middleware.CredKeyQuery, middleware.CredBearer, middleware.CredTokenQuery), handlers.HandleGoogleTranslateSingle)...)
+	r.POST("/hcfy", append(plugin(nil, middleware.CredTokenQuery, middleware.CredBearer), handlers.HandleHcfyTranslate)...)
+	r.POST("/libre/detect", append(plugin(handlers.LibreErrorBody, middleware.QueryCredential("api_key"), middleware.FormCredential("api_key"), middleware.JSONCredential("api_key"), middleware.CredBearer), handlers.HandleLibreDetect)...)
 
-	r.POST("/imme", handlers.HandleImmeTranslate(apiToken))
-	r.POST("/kiss", handlers.HandleKissTranslate(apiToken))
-	r.POST("/deepl", handlers.HandleDeeplTranslate(apiToken))
-	r.POST("/google/language/translate/v2", handlers.HandleGoogleCompatTranslate(apiToken))
-	r.GET("/google/translate_a/single", handlers.HandleGoogleTranslateSingle(apiToken))
-	r.POST("/hcfy", handlers.HandleHcfyTranslate(apiToken))
+	admin := r.Group("/admin")
+	admin.Use(middleware.AuthStore(store, auth.ScopeAdmin))
+
+	admin.GET("/tokens", handlers.HandleListTokens(store))
+	admin.POST("/tokens", handlers.HandleCreateToken(store))
+	admin.DELETE("/tokens/:id", handlers.HandleDeleteToken(store))
+
+	admin.GET("/models", handlers.HandleListModels)
+	admin.POST("/models/download", handlers.HandleDownloadModel)
+	admin.GET("/models/downloads", handlers.HandleModelDownloads)
+	admin.GET("/models/events", handlers.HandleModelEvents)
+	admin.GET("/models/export", handlers.HandleExportModels)
+	admin.POST("/models/import", handlers.HandleImportModels)
+	admin.POST("/models/verify", handlers.HandleVerifyModels)
+	admin.POST("/models/gc", handlers.HandleGCModels)
+	admin.POST("/models/upgrade", handlers.HandleUpgradeModels)
+	admin.GET("/models/:from/:to", handlers.HandleGetModel)
+	admin.DELETE("/models/:from/:to", handlers.HandleDeleteModel)
+	admin.GET("/routes", handlers.HandleRoutes)
 
-	cfg := config.GetConfig()
 	if cfg.EnableWebUI {
 		distFS, err := ui.GetDistFS()
 		if err == nil {
@@ -54,3 +119,40 @@ func Setup(r *gin.Engine, apiToken string) {
 		}
 	}
 }
+
+// newRateLimiter 按配置创建限流器，未配置时限流器不生效，可在重新加载配置时启用
+func newRateLimiter(spec string) *middleware.RateLimiter {
+	l := middleware.NewRateLimiter(middleware.RateLimitPolicy{})
+	l.SetPolicy(parseRateLimit(spec))
+	return l
+}
+
+// parseRateLimit 解析限流配置，配置无效时记录错误并不启用限流
+func parseRateLimit(spec string) *middleware.RateLimitPolicy {
+	policy, err := middleware.ParseRateLimit(spec)
+	if err != nil {
+		logger.Error("Invalid rate limit %q, rate limiting disabled: %v", spec, err)
+		return nil
+	}
+	return policy
+}
+
+// requestLimits 从当前配置读取请求大小限制
+func requestLimits() middleware.RequestLimits {
+	cfg := config.GetConfig()
+	return middleware.RequestLimits{
+		MaxBodyBytes:  int64(cfg.MaxBodySize),
+		MaxTextChars:  cfg.MaxTextLength,
+		MaxBatchItems: cfg.MaxBatchSize,
+	}
+}
+
+// parseCORS 解析跨域配置，配置无效时记录错误并使用默认策略
+func parseCORS(spec string) *middleware.CORSPolicy {
+	policy, err := middleware.ParseCORS(spec)
+	if err != nil {
+		logger.Error("Invalid CORS policy %q, using default policy: %v", spec, err)
+		return middleware.DefaultCORSPolicy()
+	}
+	return policy
+}
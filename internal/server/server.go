@@ -11,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/xxnuo/MTranServer/internal/auth"
 	"github.com/xxnuo/MTranServer/internal/config"
 	"github.com/xxnuo/MTranServer/internal/logger"
 	"github.com/xxnuo/MTranServer/internal/manager"
@@ -18,8 +19,11 @@ import (
 	"github.com/xxnuo/MTranServer/internal/models"
 	"github.com/xxnuo/MTranServer/internal/routes"
 	"github.com/xxnuo/MTranServer/internal/services"
+	"github.com/xxnuo/MTranServer/internal/tracing"
 )
 
+const configWatchInterval = 5 * time.Second
+
 func Run() error {
 
 	cfg := config.GetConfig()
@@ -32,31 +36,97 @@ func Run() error {
 		return fmt.Errorf("failed to create model directory: %w", err)
 	}
 
+	if err := models.LoadLocalModels(); err != nil {
+		return fmt.Errorf("failed to load local models: %w", err)
+	}
+	config.Subscribe(reloadLocalModels)
+
 	if err := manager.EnsureWorkerBinary(cfg); err != nil {
 		return fmt.Errorf("failed to initialize worker binary: %w", err)
 	}
 
+	if err := validatePolicies(cfg); err != nil {
+		return err
+	}
+	config.AddValidator(validatePolicies)
+
+	if err := auth.InitStore(cfg); err != nil {
+		return fmt.Errorf("failed to load API tokens: %w", err)
+	}
+	config.AddValidator(func(c *config.Config) error {
+		return auth.ValidateFile(auth.TokensFilePath(c))
+	})
+	config.Subscribe(reloadTokens)
+	defer func() {
+		if err := auth.GetStore().FlushUsage(); err != nil {
+			logger.Warn("Failed to save token usage: %v", err)
+		}
+	}()
+
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.OtelEndpoint)
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Warn("Failed to flush traces: %v", err)
+		}
+	}()
+
 	gin.SetMode(gin.ReleaseMode)
 
 	r := gin.New()
+	// 只使用受信任的代理转发的客户端 IP，未配置时忽略 X-Forwarded-For
+	if err := r.SetTrustedProxies(cfg.TrustedProxyList()); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
 
 	r.Use(middleware.Recovery())
+	r.Use(middleware.Tracing())
+	r.Use(middleware.RequestID())
 	r.Use(middleware.Logger())
 
 	routes.Setup(r, cfg.APIToken)
 
+	tlsConfig, certReloader, err := newTLSConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize TLS: %w", err)
+	}
+
 	addr := fmt.Sprintf("%s:%s", cfg.Host, cfg.Port)
 	srv := &http.Server{
-		Addr:    addr,
-		Handler: r,
+		Addr:      addr,
+		Handler:   r,
+		TLSConfig: tlsConfig,
+	}
+
+	scheme := "http"
+	if certReloader != nil {
+		scheme = "https"
+		watchCtx, stopWatch := context.WithCancel(context.Background())
+		defer stopWatch()
+		go certReloader.Watch(watchCtx, tlsReloadInterval)
 	}
 
 	shutdownDone := make(chan struct{})
 
+	reloadCtx, reloadCancel := context.WithCancel(context.Background())
+	defer reloadCancel()
+	go config.Watch(reloadCtx, configWatchInterval)
+	go auth.Watch(reloadCtx, configWatchInterval)
+	go models.WatchRecords(reloadCtx)
+	go services.WatchRecords(reloadCtx)
+	go services.WatchUpgrades(reloadCtx)
+	config.Subscribe(services.ReloadDetector)
+	go reloadOnSIGHUP(reloadCtx)
+
 	go func() {
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 		<-sigChan
+		reloadCancel()
 
 		logger.Info("Shutting down server...")
 
@@ -72,15 +142,20 @@ func Run() error {
 		close(shutdownDone)
 	}()
 
-	fmt.Fprintf(os.Stderr, "[INFO] %s HTTP Service URL: http://%s\n",
-		time.Now().Format("2006/01/02 15:04:05"), addr)
-	fmt.Fprintf(os.Stderr, "[INFO] %s Swagger UI: http://%s/docs/index.html\n",
-		time.Now().Format("2006/01/02 15:04:05"), addr)
+	fmt.Fprintf(os.Stderr, "[INFO] %s HTTP Service URL: %s://%s\n",
+		time.Now().Format("2006/01/02 15:04:05"), scheme, addr)
+	fmt.Fprintf(os.Stderr, "[INFO] %s Swagger UI: %s://%s/docs/index.html\n",
+		time.Now().Format("2006/01/02 15:04:05"), scheme, addr)
 
 	fmt.Fprintf(os.Stderr, "[INFO] %s Log level set to: %s\n",
 		time.Now().Format("2006/01/02 15:04:05"), cfg.LogLevel)
 
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	if tlsConfig != nil {
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
 
 		services.CleanupAllEngines()
 		return fmt.Errorf("failed to start server: %w", err)
@@ -91,3 +166,55 @@ func Run() error {
 
 	return nil
 }
+
+// validatePolicies 校验限流与跨域配置
+func validatePolicies(cfg *config.Config) error {
+	for _, spec := range []string{cfg.RateLimitAPI, cfg.RateLimitPlugin} {
+		if _, err := middleware.ParseRateLimit(spec); err != nil {
+			return fmt.Errorf("invalid rate limit configuration: %w", err)
+		}
+	}
+	for _, spec := range []string{cfg.CORSAPI, cfg.CORSPlugin} {
+		if _, err := middleware.ParseCORS(spec); err != nil {
+			return fmt.Errorf("invalid CORS configuration: %w", err)
+		}
+	}
+	return nil
+}
+
+// reloadTokens 重新加载配置时更新全局令牌存储，并重新读取令牌文件
+func reloadTokens(prev, next *config.Config) {
+	store := auth.GetStore()
+	if prev.APIToken != next.APIToken {
+		store.SetLegacyToken(next.APIToken)
+	}
+	if err := store.Reload(auth.TokensFilePath(next)); err != nil {
+		logger.Error("Failed to reload API tokens: %v", err)
+	}
+}
+
+// reloadLocalModels 重新加载配置时重新读取本地模型清单，失败时保留已注册的模型
+func reloadLocalModels(prev, next *config.Config) {
+	if err := models.LoadLocalModels(); err != nil {
+		logger.Error("Failed to reload local models: %v", err)
+	}
+}
+
+// reloadOnSIGHUP 收到 SIGHUP 时重新加载配置
+func reloadOnSIGHUP(ctx context.Context) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+	defer signal.Stop(sigChan)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigChan:
+			logger.Info("Received SIGHUP, reloading configuration")
+			if err := config.Reload(); err != nil {
+				logger.Error("Failed to reload config: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/logger"
+)
+
+const tlsReloadInterval = 10 * time.Second
+
+// CertReloader 持有当前的证书与客户端 CA，文件变化时自动重新加载
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	caPool  *x509.CertPool
+	modTime map[string]time.Time
+}
+
+func NewCertReloader(certFile, keyFile, caFile string) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		modTime:  make(map[string]time.Time),
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *CertReloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.caFile != "" {
+		files = append(files, r.caFile)
+	}
+	return files
+}
+
+func (r *CertReloader) reload() error {
+	modTime := make(map[string]time.Time, 3)
+	for _, f := range r.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", f, err)
+		}
+		modTime[f] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		data, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("no valid certificates found in %s", r.caFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.caPool = pool
+	r.modTime = modTime
+	r.mu.Unlock()
+	return nil
+}
+
+// changed 判断证书文件是否有变化
+func (r *CertReloader) changed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, f := range r.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().Equal(r.modTime[f]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Watch 定期检查证书文件，变化时重新加载，加载失败时继续使用旧证书
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.reload(); err != nil {
+				logger.Error("Failed to reload TLS certificate, keeping the previous one: %v", err)
+				continue
+			}
+			logger.Info("Reloaded TLS certificate from %s", r.certFile)
+		}
+	}
+}
+
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// TLSConfig 构造服务端 TLS 配置，每次握手使用最新的证书与客户端 CA
+func (r *CertReloader) TLSConfig(clientAuth tls.ClientAuthType) *tls.Config {
+	base := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.GetCertificate,
+	}
+	if r.caFile == "" {
+		return base
+	}
+
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		pool := r.caPool
+		r.mu.RUnlock()
+
+		c := base.Clone()
+		c.GetConfigForClient = nil
+		c.ClientCAs = pool
+		c.ClientAuth = clientAuth
+		return c, nil
+	}
+	return base
+}
+
+// parseClientAuth 解析客户端证书校验模式
+func parseClientAuth(mode string) (tls.ClientAuthType, error) {
+	switch mode {
+	case "", "require":
+		return tls.RequireAndVerifyClientCert, nil
+	case "verify-if-given":
+		return tls.VerifyClientCertIfGiven, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("unknown TLS client auth mode: %s", mode)
+	}
+}
+
+// newTLSConfig 按配置创建 TLS 配置，未配置证书时返回 nil
+func newTLSConfig(cfg *config.Config) (*tls.Config, *CertReloader, error) {
+	if cfg.TLSCert == "" && cfg.TLSKey == "" {
+		if cfg.TLSClientCA != "" {
+			return nil, nil, fmt.Errorf("TLS client CA requires a certificate and key")
+		}
+		return nil, nil, nil
+	}
+	if cfg.TLSCert == "" || cfg.TLSKey == "" {
+		return nil, nil, fmt.Errorf("both TLS certificate and key must be set")
+	}
+
+	clientAuth, err := parseClientAuth(cfg.TLSClientAuth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reloader, err := NewCertReloader(cfg.TLSCert, cfg.TLSKey, cfg.TLSClientCA)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reloader.TLSConfig(clientAuth), reloader, nil
+}
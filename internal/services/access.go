@@ -0,0 +1,24 @@
+package services
+
+import "context"
+
+type pairCheckKey struct{}
+
+// PairCheck 检查是否允许将 fromLang 翻译为 toLang，不允许时返回错误
+type PairCheck func(fromLang, toLang string) error
+
+// ContextWithPairCheck 将语言对检查写入 context
+// 源语言为 auto 或按检测到的语言分段翻译时，TranslateWithPivot 使用每段实际的源语言检查
+func ContextWithPairCheck(ctx context.Context, check PairCheck) context.Context {
+	if check == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, pairCheckKey{}, check)
+}
+
+func checkPair(ctx context.Context, fromLang, toLang string) error {
+	if check, ok := ctx.Value(pairCheckKey{}).(PairCheck); ok {
+		return check(fromLang, toLang)
+	}
+	return nil
+}
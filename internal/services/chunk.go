@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// splitText 将超过 maxChars 个字符的文本切分为多段，依次优先在段落、换行、句子和空白处切分
+// 各段拼接后与原文完全一致，maxChars <= 0 时不切分
+func splitText(text string, maxChars int) []string {
+	if maxChars <= 0 || len(text) <= maxChars || utf8.RuneCountInString(text) <= maxChars {
+		return []string{text}
+	}
+
+	var chunks []string
+	for utf8.RuneCountInString(text) > maxChars {
+		cut := chunkBoundary(text, maxChars)
+		chunks = append(chunks, text[:cut])
+		text = text[cut:]
+	}
+	if text != "" {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
+
+// chunkBoundary 返回前 maxChars 个字符内最合适的切分位置（字节偏移）
+// 只接受位于后半部分的边界，避免切出过短的片段
+func chunkBoundary(text string, maxChars int) int {
+	limit := len(text)
+	n := 0
+	for i := range text {
+		if n == maxChars {
+			limit = i
+			break
+		}
+		n++
+	}
+	window := text[:limit]
+	minCut := limit / 2
+
+	if i := strings.LastIndex(window, "\n\n"); i >= minCut {
+		return i + 2
+	}
+	if i := strings.LastIndex(window, "\n"); i >= minCut {
+		return i + 1
+	}
+	if i := lastSentenceEnd(window); i >= minCut {
+		return i
+	}
+	if i := strings.LastIndexFunc(window, unicode.IsSpace); i >= minCut {
+		_, size := utf8.DecodeRuneInString(window[i:])
+		return i + size
+	}
+	return limit
+}
+
+// lastSentenceEnd 返回最后一个句末标点之后的位置，西文标点要求后面紧跟空白并包含该空白，未找到时返回 -1
+func lastSentenceEnd(s string) int {
+	end := -1
+	for i, r := range s {
+		size := utf8.RuneLen(r)
+		switch r {
+		case '。', '！', '？', '；', '…':
+			end = i + size
+		case '.', '!', '?', ';':
+			next, nextSize := utf8.DecodeRuneInString(s[i+size:])
+			if unicode.IsSpace(next) {
+				end = i + size + nextSize
+			}
+		}
+	}
+	return end
+}
+
+// translateChunks 逐段翻译并拼接结果，保留每段首尾的空白
+func translateChunks(ctx context.Context, fromLang, toLang string, chunks []string) (string, error) {
+	var result strings.Builder
+	for _, chunk := range chunks {
+		core := strings.TrimSpace(chunk)
+		if core == "" {
+			result.WriteString(chunk)
+			continue
+		}
+
+		translated, err := translateSingleLanguageText(ctx, fromLang, toLang, core, false)
+		if err != nil {
+			return "", err
+		}
+
+		start := strings.Index(chunk, core)
+		result.WriteString(chunk[:start])
+		result.WriteString(translated)
+		result.WriteString(chunk[start+len(core):])
+	}
+	return result.String(), nil
+}
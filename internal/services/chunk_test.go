@@ -0,0 +1,71 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitTextShort(t *testing.T) {
+	assert.Equal(t, []string{"Hello world."}, splitText("Hello world.", 100))
+	assert.Equal(t, []string{"Hello world."}, splitText("Hello world.", 0))
+}
+
+func TestSplitTextBoundaries(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxChars int
+		want     []string
+	}{
+		{
+			name:     "paragraph",
+			text:     "First paragraph here.\n\nSecond one.",
+			maxChars: 25,
+			want:     []string{"First paragraph here.\n\n", "Second one."},
+		},
+		{
+			name:     "sentence",
+			text:     "One sentence here. Another sentence here.",
+			maxChars: 30,
+			want:     []string{"One sentence here. ", "Another sentence here."},
+		},
+		{
+			name:     "cjk sentence",
+			text:     "这是第一句话。这是第二句话。这是第三句话。",
+			maxChars: 10,
+			want:     []string{"这是第一句话。", "这是第二句话。", "这是第三句话。"},
+		},
+		{
+			name:     "abbreviation is not a sentence end",
+			text:     "Version 3.0 is out now and works",
+			maxChars: 20,
+			want:     []string{"Version 3.0 is out ", "now and works"},
+		},
+		{
+			name:     "hard cut",
+			text:     strings.Repeat("a", 25),
+			maxChars: 10,
+			want:     []string{strings.Repeat("a", 10), strings.Repeat("a", 10), strings.Repeat("a", 5)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, splitText(tt.text, tt.maxChars))
+		})
+	}
+}
+
+func TestSplitTextRoundTrip(t *testing.T) {
+	text := strings.Repeat("Lorem ipsum dolor sit amet. 这是一个测试！\n", 50) + "\n\n" + strings.Repeat("word ", 300)
+
+	chunks := splitText(text, 120)
+	assert.Greater(t, len(chunks), 1)
+	assert.Equal(t, text, strings.Join(chunks, ""))
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, utf8.RuneCountInString(chunk), 120)
+	}
+}
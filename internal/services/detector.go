@@ -1,73 +1,129 @@
 package services
 
 import (
+	"context"
+	"slices"
 	"strings"
 	"sync"
 	"unicode"
 
 	"github.com/pemistahl/lingua-go"
+	"github.com/xxnuo/MTranServer/internal/config"
 	"github.com/xxnuo/MTranServer/internal/logger"
 	"github.com/xxnuo/MTranServer/internal/models"
+	"github.com/xxnuo/MTranServer/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-const (
-	defaultConfidenceThreshold = 0.5
-	minimumRelativeDistance    = 0.99
-	maximumLanguagesInOneText  = 2
-)
+// defaultConfidenceThreshold 混合语言分段使用的置信度阈值，与 DetectorMinConfidence 无关
+const defaultConfidenceThreshold = 0.5
 
 var (
-	detector           lingua.LanguageDetector
-	detectorOnce       sync.Once
+	detectorMu sync.RWMutex
+	detector   lingua.LanguageDetector
+	// supportedLanguages 记录中的语言，记录刷新后语言变化时与 detector 一起重建
 	supportedLanguages map[string]bool
 )
 
-func initDetector() {
-	detectorOnce.Do(func() {
+// getDetector 返回语言检测器和支持的语言，首次调用时构建
+func getDetector() (lingua.LanguageDetector, map[string]bool) {
+	detectorMu.RLock()
+	if detector != nil {
+		defer detectorMu.RUnlock()
+		return detector, supportedLanguages
+	}
+	detectorMu.RUnlock()
+
+	detectorMu.Lock()
+	defer detectorMu.Unlock()
+	if detector == nil {
 		logger.Debug("Initializing language detector")
+		detector, supportedLanguages = buildDetector()
+		logger.Debug("Language detector initialized, %d supported languages", len(supportedLanguages))
+	}
+	return detector, supportedLanguages
+}
+
+// RebuildDetector 按当前记录中的语言重建语言检测器，构建期间继续使用原有的检测器
+func RebuildDetector() {
+	d, langs := buildDetector()
+	detectorMu.Lock()
+	detector, supportedLanguages = d, langs
+	detectorMu.Unlock()
+	logger.Info("Language detector rebuilt, %d supported languages", len(langs))
+}
 
-		supportedLanguages = make(map[string]bool)
-		langs, err := models.GetSupportedLanguages()
-		if err != nil {
-			logger.Warn("Failed to get supported languages: %v, using all languages", err)
-			detector = lingua.NewLanguageDetectorBuilder().
-				FromAllLanguages().
-				WithMinimumRelativeDistance(minimumRelativeDistance).
-				WithLowAccuracyMode().
-				WithPreloadedLanguageModels().
-				Build()
+// WatchRecords 记录刷新后支持的语言变化时重建语言检测器
+func WatchRecords(ctx context.Context) {
+	diffs, cancel := models.SubscribeRecords()
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
 			return
+		case diff := <-diffs:
+			if !diff.LanguagesChanged {
+				continue
+			}
+			detectorMu.RLock()
+			built := detector != nil
+			detectorMu.RUnlock()
+			// 尚未使用的检测器在首次使用时按新的记录构建
+			if built {
+				RebuildDetector()
+			}
 		}
+	}
+}
 
-		for _, lang := range langs {
-			supportedLanguages[lang] = true
-		}
+// ReloadDetector 检测精度或最小相对距离变化时重建已构建的语言检测器
+func ReloadDetector(prev, next *config.Config) {
+	if prev.DetectorHighAccuracy == next.DetectorHighAccuracy && prev.DetectorMinDistance == next.DetectorMinDistance {
+		return
+	}
+	detectorMu.RLock()
+	built := detector != nil
+	detectorMu.RUnlock()
+	if built {
+		RebuildDetector()
+	}
+}
 
-		linguaLangs := make([]lingua.Language, 0, len(langs))
-		for _, lang := range langs {
-			linguaLang := bcp47ToLingua(lang)
-			if linguaLang != lingua.Unknown {
-				linguaLangs = append(linguaLangs, linguaLang)
-			}
-		}
+func buildDetector() (lingua.LanguageDetector, map[string]bool) {
+	supported := make(map[string]bool)
+	langs, err := models.GetSupportedLanguages()
+	if err != nil {
+		logger.Warn("Failed to get supported languages: %v, using all languages", err)
+		return newDetector(lingua.NewLanguageDetectorBuilder().FromAllLanguages()), supported
+	}
 
-		if len(linguaLangs) < 2 {
-			logger.Warn("Not enough supported languages (%d), using all languages", len(linguaLangs))
-			detector = lingua.NewLanguageDetectorBuilder().
-				FromAllLanguages().
-				WithLowAccuracyMode().
-				WithPreloadedLanguageModels().
-				Build()
-		} else {
-			detector = lingua.NewLanguageDetectorBuilder().
-				FromLanguages(linguaLangs...).
-				WithLowAccuracyMode().
-				WithPreloadedLanguageModels().
-				Build()
+	for _, lang := range langs {
+		supported[lang] = true
+	}
+
+	linguaLangs := make([]lingua.Language, 0, len(langs))
+	for _, lang := range langs {
+		linguaLang := bcp47ToLingua(lang)
+		if linguaLang != lingua.Unknown {
+			linguaLangs = append(linguaLangs, linguaLang)
 		}
+	}
 
-		logger.Debug("Language detector initialized, %d supported languages", len(supportedLanguages))
-	})
+	if len(linguaLangs) < 2 {
+		logger.Warn("Not enough supported languages (%d), using all languages", len(linguaLangs))
+		return newDetector(lingua.NewLanguageDetectorBuilder().FromAllLanguages()), supported
+	}
+	return newDetector(lingua.NewLanguageDetectorBuilder().FromLanguages(linguaLangs...)), supported
+}
+
+// newDetector 按配置的精度和最小相对距离构建检测器
+func newDetector(builder lingua.LanguageDetectorBuilder) lingua.LanguageDetector {
+	cfg := config.GetConfig()
+	builder = builder.WithMinimumRelativeDistance(cfg.DetectorMinDistance).WithPreloadedLanguageModels()
+	if !cfg.DetectorHighAccuracy {
+		builder = builder.WithLowAccuracyMode()
+	}
+	return builder.Build()
 }
 
 func bcp47ToLingua(code string) lingua.Language {
@@ -80,11 +136,11 @@ func bcp47ToLingua(code string) lingua.Language {
 	}
 }
 
-func isSupportedLanguage(lang string) bool {
-	if len(supportedLanguages) == 0 {
+func isSupportedLanguage(supported map[string]bool, lang string) bool {
+	if len(supported) == 0 {
 		return true
 	}
-	return supportedLanguages[lang]
+	return supported[lang]
 }
 
 func linguaToBCP47(lang lingua.Language) string {
@@ -105,7 +161,7 @@ func DetectLanguage(text string) string {
 		return ""
 	}
 
-	initDetector()
+	detector, _ := getDetector()
 
 	lang, exists := detector.DetectLanguageOf(text)
 	if !exists {
@@ -115,26 +171,123 @@ func DetectLanguage(text string) string {
 	return linguaToBCP47(lang)
 }
 
+// DetectLanguageWithHints 检测文本的语言，提示非空时使用按提示调整后置信度最高的语言
+func DetectLanguageWithHints(text string, hints DetectHints) string {
+	if !hints.empty() {
+		if candidates := DetectCandidatesWithHints(text, nil, hints); len(candidates) > 0 {
+			return candidates[0].Language
+		}
+	}
+	return DetectLanguage(text)
+}
+
+// detectLanguage 检测文本的语言，context 中有检测提示时按提示调整
+func detectLanguage(ctx context.Context, text string) string {
+	_, span := tracing.Start(ctx, "services.DetectLanguage", attribute.Int("text_length", len(text)))
+	defer span.End()
+
+	lang := DetectLanguageWithHints(text, detectHintsFrom(ctx))
+	span.SetAttributes(attribute.String("detected_language", lang))
+	return lang
+}
+
+// DetectLanguageWithConfidence 返回置信度最高的语言，置信度低于 minConfidence 时语言为空
 func DetectLanguageWithConfidence(text string, minConfidence float64) (string, float64) {
-	if text == "" {
+	candidates := DetectCandidates(text, nil)
+	if len(candidates) == 0 {
 		return "", 0.0
 	}
 
-	initDetector()
+	top := candidates[0]
+	if top.Confidence < minConfidence {
+		return "", top.Confidence
+	}
+	return top.Language, top.Confidence
+}
 
-	confidenceValues := detector.ComputeLanguageConfidenceValues(text)
-	if len(confidenceValues) == 0 {
-		return "", 0.0
+// LanguageCandidate 语言检测的候选语言
+type LanguageCandidate struct {
+	Language   string  `json:"language" example:"en"`
+	Confidence float64 `json:"confidence" example:"0.92"`
+}
+
+// DetectCandidates 返回按置信度从高到低排列的候选语言，不包含置信度为 0 的语言
+// candidates 非空时只在这些语言中选择，置信度在这些语言之间重新归一化
+func DetectCandidates(text string, candidates []string) []LanguageCandidate {
+	return DetectCandidatesWithHints(text, candidates, DetectHints{})
+}
+
+// DetectCandidatesWithHints 与 DetectCandidates 相同，文本较短或置信度较低时按提示调整候选语言的顺序
+// 无法检测时返回提示中的语言，置信度为 0
+func DetectCandidatesWithHints(text string, candidates []string, hints DetectHints) []LanguageCandidate {
+	if text == "" {
+		return nil
 	}
 
-	topResult := confidenceValues[0]
-	confidence := topResult.Value()
+	var allowed map[string]bool
+	if len(candidates) > 0 {
+		allowed = make(map[string]bool, len(candidates))
+		for _, lang := range candidates {
+			allowed[lang] = true
+		}
+	}
 
-	if confidence < minConfidence {
-		return "", confidence
+	detector, supported := getDetector()
+	var list []LanguageCandidate
+	var total float64
+	for _, v := range detector.ComputeLanguageConfidenceValues(text) {
+		lang := linguaToBCP47(v.Language())
+		if v.Value() <= 0 || (allowed != nil && !allowed[lang]) {
+			continue
+		}
+		list = append(list, LanguageCandidate{Language: lang, Confidence: v.Value()})
+		total += v.Value()
+	}
+	if allowed != nil && total > 0 {
+		for i := range list {
+			list[i].Confidence /= total
+		}
+	}
+	hints = hints.normalize()
+	if len(list) == 0 && allowed == nil {
+		return hints.fallback(supported)
 	}
+	return hints.apply(text, list)
+}
+
+// DetectSegments 返回混合语言文本中各段的语言和置信度
+// candidates 非空时置信度在这些语言之间归一化，不在其中的段改为其中置信度最高的语言，无法归入任何候选语言的段不返回
+func DetectSegments(text string, candidates []string) []TextSegment {
+	segments := DetectMultipleLanguages(text)
+	result := make([]TextSegment, 0, len(segments))
+	for _, seg := range segments {
+		if len(candidates) == 0 {
+			seg.Confidence = languageConfidence(seg.Text, seg.Language)
+			result = append(result, seg)
+			continue
+		}
+		c := DetectCandidates(seg.Text, candidates)
+		if len(c) == 0 {
+			continue
+		}
+		best := c[0]
+		if i := slices.IndexFunc(c, func(c LanguageCandidate) bool { return c.Language == seg.Language }); i >= 0 {
+			best = c[i]
+		}
+		seg.Language, seg.Confidence = best.Language, best.Confidence
+		result = append(result, seg)
+	}
+	return result
+}
 
-	return linguaToBCP47(topResult.Language()), confidence
+// languageConfidence 返回文本属于 lang 的置信度，检测器不支持该语言时返回 0
+func languageConfidence(text, lang string) float64 {
+	l := bcp47ToLingua(lang)
+	if text == "" || l == lingua.Unknown {
+		return 0
+	}
+	detector, _ := getDetector()
+	return detector.ComputeLanguageConfidence(text, l)
 }
 
 type TextSegment struct {
@@ -149,6 +302,21 @@ func DetectMultipleLanguages(text string) []TextSegment {
 	return DetectMultipleLanguagesWithThreshold(text, defaultConfidenceThreshold)
 }
 
+// detectSegments 按语言切分文本，只有一种语言且 context 中有检测提示时按提示调整该语言
+func detectSegments(ctx context.Context, text string) []TextSegment {
+	_, span := tracing.Start(ctx, "services.DetectMultipleLanguages", attribute.Int("text_length", len(text)))
+	defer span.End()
+
+	segments := DetectMultipleLanguages(text)
+	if hints := detectHintsFrom(ctx); len(segments) == 1 && !hints.empty() {
+		if candidates := DetectCandidatesWithHints(text, nil, hints); len(candidates) > 0 {
+			segments[0].Language = candidates[0].Language
+		}
+	}
+	span.SetAttributes(attribute.Int("segments", len(segments)))
+	return segments
+}
+
 func hasMixedScripts(text string) bool {
 	var hasCJK, hasLatin bool
 	for _, r := range text {
@@ -169,11 +337,11 @@ func DetectMultipleLanguagesWithThreshold(text string, threshold float64) []Text
 		return nil
 	}
 
-	initDetector()
+	detector, supported := getDetector()
 
 	fallbackLang, _ := detector.DetectLanguageOf(text)
 	fallbackBCP47 := linguaToBCP47(fallbackLang)
-	if !isSupportedLanguage(fallbackBCP47) {
+	if !isSupportedLanguage(supported, fallbackBCP47) {
 		fallbackBCP47 = "en"
 	}
 
@@ -205,7 +373,7 @@ func DetectMultipleLanguagesWithThreshold(text string, threshold float64) []Text
 
 		var lang string
 		var usedFallback bool
-		if isSupportedLanguage(detectedLang) {
+		if isSupportedLanguage(supported, detectedLang) {
 			lang = detectedLang
 		} else {
 			lang = fallbackBCP47
@@ -227,7 +395,7 @@ func DetectMultipleLanguagesWithThreshold(text string, threshold float64) []Text
 	segments := mergeAdjacentSegments(rawSegments, text)
 	logger.Debug("DetectMultipleLanguages: merged %d -> %d segments", len(rawSegments), len(segments))
 
-	segments = limitLanguages(segments, text, maximumLanguagesInOneText)
+	segments = limitLanguages(segments, text, config.GetConfig().DetectorLanguageLimit())
 
 	return segments
 }
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xxnuo/MTranServer/internal/models"
+	"github.com/xxnuo/MTranServer/internal/models/modelstest"
+)
+
+// setupDetector 使用只包含 en、de、fr 的记录构建检测器
+func setupDetector(t *testing.T) {
+	t.Cleanup(func() {
+		detectorMu.Lock()
+		detector, supportedLanguages = nil, nil
+		detectorMu.Unlock()
+	})
+	modelstest.UseGlobals(t, nil,
+		models.RecordItem{SourceLanguage: "en", TargetLanguage: "de"},
+		models.RecordItem{SourceLanguage: "de", TargetLanguage: "en"},
+		models.RecordItem{SourceLanguage: "en", TargetLanguage: "fr"},
+	)
+	RebuildDetector()
+}
+
+func TestDetectCandidates(t *testing.T) {
+	setupDetector(t)
+
+	candidates := DetectCandidates("Das ist ein schöner Tag und wir gehen heute spazieren.", nil)
+	require.NotEmpty(t, candidates)
+	assert.Equal(t, "de", candidates[0].Language)
+	for i := 1; i < len(candidates); i++ {
+		assert.GreaterOrEqual(t, candidates[i-1].Confidence, candidates[i].Confidence)
+	}
+
+	// 限定候选语言后置信度在候选语言之间归一化
+	restricted := DetectCandidates("Das ist gut.", []string{"en", "fr"})
+	require.NotEmpty(t, restricted)
+	var total float64
+	for _, c := range restricted {
+		assert.Contains(t, []string{"en", "fr"}, c.Language)
+		total += c.Confidence
+	}
+	assert.InDelta(t, 1.0, total, 1e-9)
+
+	// 文本明确不属于任何候选语言时没有候选
+	assert.Empty(t, DetectCandidates("Das ist ein schöner Tag und wir gehen heute spazieren.", []string{"en", "fr"}))
+	assert.Empty(t, DetectCandidates("", nil))
+
+	lang, confidence := DetectLanguageWithConfidence("The weather is lovely today and we are going for a walk.", 0.5)
+	assert.Equal(t, "en", lang)
+	assert.GreaterOrEqual(t, confidence, 0.5)
+}
+
+func TestDetectSegmentsRestricted(t *testing.T) {
+	setupDetector(t)
+
+	text := "The weather is lovely today."
+	segments := DetectSegments(text, []string{"de"})
+	require.Len(t, segments, 1)
+	assert.Equal(t, "de", segments[0].Language)
+	assert.Equal(t, 0, segments[0].Start)
+	assert.Equal(t, len(text), segments[0].End)
+	assert.InDelta(t, 1.0, segments[0].Confidence, 1e-9)
+}
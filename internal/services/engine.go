@@ -9,22 +9,30 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/xxnuo/MTranServer/internal/config"
 	"github.com/xxnuo/MTranServer/internal/logger"
 	"github.com/xxnuo/MTranServer/internal/manager"
 	"github.com/xxnuo/MTranServer/internal/models"
+	"github.com/xxnuo/MTranServer/internal/tracing"
 	"github.com/xxnuo/MTranServer/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type EngineInfo struct {
-	Managers  []*manager.Manager
-	LastUsed  time.Time
-	FromLang  string
-	ToLang    string
+	Managers []*manager.Manager
+	LastUsed time.Time
+	FromLang string
+	ToLang   string
+	// Version Worker 使用的模型版本
+	Version   string
 	stopTimer *time.Timer
 	mu        sync.Mutex
 	nextIdx   int
+	// stagedDir 滚动升级后 Worker 使用的暂存目录，引擎停止时删除
+	stagedDir string
 }
 
 var (
@@ -65,8 +73,7 @@ func (ei *EngineInfo) resetIdleTimer() {
 		ei.stopTimer.Stop()
 	}
 
-	cfg := config.GetConfig()
-	timeout := time.Duration(cfg.WorkerIdleTimeout) * time.Second
+	timeout := config.GetConfig().WorkerIdleTimeoutFor(ei.FromLang, ei.ToLang)
 
 	ei.stopTimer = time.AfterFunc(timeout, func() {
 		defer func() {
@@ -82,13 +89,7 @@ func (ei *EngineInfo) resetIdleTimer() {
 		defer engMu.Unlock()
 
 		if info, ok := engines[key]; ok {
-			for _, m := range info.Managers {
-				if m != nil {
-					if err := m.Cleanup(); err != nil {
-						logger.Error("Failed to cleanup manager: %v", err)
-					}
-				}
-			}
+			info.stop()
 			delete(engines, key)
 			logger.Info("Engine %s stopped due to idle timeout", key)
 		}
@@ -121,6 +122,61 @@ func (ei *EngineInfo) getNextManager() *manager.Manager {
 	return ei.Managers[idx]
 }
 
+// managers 返回当前 Worker 的快照，滚动升级会替换 Managers
+func (ei *EngineInfo) managers() []*manager.Manager {
+	ei.mu.Lock()
+	defer ei.mu.Unlock()
+	return append([]*manager.Manager(nil), ei.Managers...)
+}
+
+// has Worker 是否仍在引擎中，已被滚动升级替换时返回 false
+func (ei *EngineInfo) has(m *manager.Manager) bool {
+	ei.mu.Lock()
+	defer ei.mu.Unlock()
+	for _, cur := range ei.Managers {
+		if cur == m {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceManager 用新 Worker 替换旧 Worker，旧 Worker 不在引擎中时返回 false
+func (ei *EngineInfo) replaceManager(old, m *manager.Manager) bool {
+	ei.mu.Lock()
+	defer ei.mu.Unlock()
+	for i, cur := range ei.Managers {
+		if cur == old {
+			ei.Managers[i] = m
+			return true
+		}
+	}
+	return false
+}
+
+// stop 停止所有 Worker，调用方需持有 engMu
+func (ei *EngineInfo) stop() {
+	ei.mu.Lock()
+	if ei.stopTimer != nil {
+		ei.stopTimer.Stop()
+	}
+	stagedDir := ei.stagedDir
+	ei.mu.Unlock()
+
+	for _, m := range ei.managers() {
+		if m != nil {
+			if err := m.Cleanup(); err != nil {
+				logger.Error("Failed to cleanup manager: %v", err)
+			} else {
+				logger.Debug("Manager cleaned up successfully")
+			}
+		}
+	}
+	if stagedDir != "" {
+		models.ReleaseStaged(stagedDir)
+	}
+}
+
 // Helper to get engine info without creating one if not exists
 func getEngineInfo(fromLang, toLang string) *EngineInfo {
 	key := fmt.Sprintf("%s-%s", fromLang, toLang)
@@ -129,20 +185,55 @@ func getEngineInfo(fromLang, toLang string) *EngineInfo {
 	return engines[key]
 }
 
-func getOrCreateSingleEngine(fromLang, toLang string) (*manager.Manager, error) {
+func getOrCreateSingleEngine(ctx context.Context, fromLang, toLang string) (m *manager.Manager, err error) {
 	key := fmt.Sprintf("%s-%s", fromLang, toLang)
 
+	ctx, span := tracing.Start(ctx, "services.getOrCreateSingleEngine",
+		attribute.String("translation.from", fromLang),
+		attribute.String("translation.to", toLang),
+	)
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
 	engMu.RLock()
 	if info, ok := engines[key]; ok && info != nil {
 		m := info.getNextManager()
 		if m != nil {
 			engMu.RUnlock()
 			info.resetIdleTimer()
+			span.SetAttributes(attribute.Bool("engine.created", false))
 			return m, nil
 		}
 	}
 	engMu.RUnlock()
 
+	log := logger.Ctx(ctx).With(logger.Fields{logger.FieldPair: key})
+
+	// 下载模型时不持有 engMu，避免阻塞其他语言对，同一语言对的下载由 models 加锁
+	cfg := config.GetConfig()
+	langPairDir := filepath.Join(cfg.ModelDir, fmt.Sprintf("%s_%s", fromLang, toLang))
+	version := ""
+	if models.UsesLocalModel(fromLang, toLang) {
+		log.Info("Using local model for %s -> %s", fromLang, toLang)
+		if langPairDir, version, err = models.PrepareLocalModel(cfg.ModelDir, fromLang, toLang); err != nil {
+			return nil, fmt.Errorf("failed to prepare local model: %w", err)
+		}
+	} else if cfg.EnableOfflineMode {
+		log.Info("Offline mode enabled, skipping model download")
+		// 离线模式不校验哈希，至少在启动 Worker 前发现截断的文件
+		if err := models.CheckModelSizes(cfg.ModelDir, fromLang, toLang); errors.Is(err, models.ErrModelCorrupt) {
+			return nil, err
+		}
+	} else {
+		log.Info("Downloading model for %s -> %s", fromLang, toLang)
+		span.AddEvent("model.download")
+		if err := models.DownloadModel(toLang, fromLang, ""); err != nil {
+			return nil, fmt.Errorf("failed to download model: %w", err)
+		}
+	}
+
 	engMu.Lock()
 	defer engMu.Unlock()
 
@@ -150,9 +241,11 @@ func getOrCreateSingleEngine(fromLang, toLang string) (*manager.Manager, error)
 		m := info.getNextManager()
 		if m != nil {
 			info.resetIdleTimer()
+			span.SetAttributes(attribute.Bool("engine.created", false))
 			return m, nil
 		}
 	}
+	span.SetAttributes(attribute.Bool("engine.created", true))
 
 	if !canCreateNewWorker() {
 		availableMB := getAvailableMemoryMB()
@@ -160,76 +253,28 @@ func getOrCreateSingleEngine(fromLang, toLang string) (*manager.Manager, error)
 			ErrInsufficientMemory, availableMB, workerMemoryMB+reservedMemoryMB)
 	}
 
-	logger.Info("Creating new engine pool for %s -> %s", fromLang, toLang)
-
-	cfg := config.GetConfig()
-	if cfg.EnableOfflineMode {
-		logger.Info("Offline mode enabled, skipping model download")
-	} else {
-		logger.Info("Downloading model for %s -> %s", fromLang, toLang)
-		if err := models.DownloadModel(toLang, fromLang, ""); err != nil {
-			return nil, fmt.Errorf("failed to download model: %w", err)
-		}
-	}
+	log.Info("Creating new engine pool for %s -> %s", fromLang, toLang)
 
-	langPairDir := filepath.Join(cfg.ModelDir, fmt.Sprintf("%s_%s", fromLang, toLang))
 	if err := os.MkdirAll(langPairDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create work directory: %w", err)
 	}
-
-	numWorkers := cfg.WorkersPerLanguage
-	if numWorkers <= 0 {
-		numWorkers = 1
+	if version == "" {
+		version = models.InstalledVersion(cfg.ModelDir, fromLang, toLang)
 	}
 
+	numWorkers := cfg.WorkersFor(fromLang, toLang)
+
 	managers := make([]*manager.Manager, 0, numWorkers)
 	for i := 0; i < numWorkers; i++ {
-		port, err := utils.GetFreePort()
+		m, err := startWorker(ctx, fromLang, toLang, langPairDir)
 		if err != nil {
 			for _, m := range managers {
 				m.Cleanup()
 			}
-			return nil, fmt.Errorf("failed to allocate port: %w", err)
-		}
-
-		args := manager.NewWorkerArgs()
-		args.Port = port
-		args.LogLevel = cfg.LogLevel
-		args.WorkDir = langPairDir
-		args.ModelDir = langPairDir
-
-		m := manager.NewManager(args)
-
-		if err := m.Start(); err != nil {
-			for _, m := range managers {
-				m.Cleanup()
-			}
-			return nil, fmt.Errorf("failed to start manager: %w", err)
+			return nil, fmt.Errorf("worker %d: %w", i+1, err)
 		}
-
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		ready := false
-		for j := 0; j < 30; j++ {
-			var err error
-			ready, err = m.Health(ctx)
-			logger.Debug("Worker %d health check %d: ready=%v, err=%v", i+1, j+1, ready, err)
-			if err == nil && ready {
-				break
-			}
-			time.Sleep(100 * time.Millisecond)
-		}
-		cancel()
-
-		if !ready {
-			m.Cleanup()
-			for _, m := range managers {
-				m.Cleanup()
-			}
-			return nil, fmt.Errorf("worker %d failed to become ready", i+1)
-		}
-
 		managers = append(managers, m)
-		logger.Info("Worker %d/%d created for %s -> %s on port %d", i+1, numWorkers, fromLang, toLang, port)
+		log.Info("Worker %d/%d created for %s -> %s", i+1, numWorkers, fromLang, toLang)
 	}
 
 	info := &EngineInfo{
@@ -237,125 +282,279 @@ func getOrCreateSingleEngine(fromLang, toLang string) (*manager.Manager, error)
 		LastUsed: time.Now(),
 		FromLang: fromLang,
 		ToLang:   toLang,
+		Version:  version,
 		nextIdx:  0,
 	}
 	info.resetIdleTimer()
 
 	engines[key] = info
-	logger.Info("Engine pool created successfully for %s -> %s with %d workers", fromLang, toLang, numWorkers)
+	log.Info("Engine pool created successfully for %s -> %s with %d workers", fromLang, toLang, numWorkers)
 
 	return managers[0], nil
 }
 
-func needsPivotTranslation(fromLang, toLang string) bool {
+// startWorker 在 modelDir 上启动一个 Worker 并等待健康检查通过
+func startWorker(ctx context.Context, fromLang, toLang, modelDir string) (*manager.Manager, error) {
+	cfg := config.GetConfig()
+	log := logger.Ctx(ctx).With(logger.Fields{logger.FieldPair: fmt.Sprintf("%s-%s", fromLang, toLang)})
 
-	if fromLang == "en" || toLang == "en" {
-		return false
+	port, err := utils.GetFreePort()
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate port: %w", err)
 	}
 
-	if models.GlobalRecords != nil && models.GlobalRecords.HasLanguagePair(fromLang, toLang) {
-		return false
-	}
+	args := manager.NewWorkerArgs()
+	args.Port = port
+	args.LogLevel = cfg.LogLevel
+	args.WorkDir = modelDir
+	args.ModelDir = modelDir
 
-	return true
-}
+	m := manager.NewManager(args)
 
-func GetOrCreateEngine(fromLang, toLang string) (*manager.Manager, error) {
+	trace.SpanFromContext(ctx).AddEvent("worker.start", trace.WithAttributes(attribute.Int("worker.port", port)))
+	if err := m.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start manager: %w", err)
+	}
 
-	if !needsPivotTranslation(fromLang, toLang) {
-		return getOrCreateSingleEngine(fromLang, toLang)
+	healthCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	ready := false
+	for j := 0; j < 30; j++ {
+		var err error
+		ready, err = m.Health(healthCtx)
+		log.Debug("Worker on port %d health check %d: ready=%v, err=%v", port, j+1, ready, err)
+		if err == nil && ready {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
 	}
 
-	logger.Debug("Translation %s -> %s requires pivot through English", fromLang, toLang)
-	return getOrCreateSingleEngine(fromLang, "en")
+	if !ready {
+		m.Cleanup()
+		return nil, fmt.Errorf("worker on port %d failed to become ready", port)
+	}
+	return m, nil
 }
 
-func translateSegment(ctx context.Context, fromLang, toLang, text string, isHTML bool) (string, error) {
-	if fromLang == toLang {
-		return text, nil
+// GetOrCreateEngine 返回翻译路径上第一个语言对的引擎
+func GetOrCreateEngine(fromLang, toLang string) (*manager.Manager, error) {
+	route, err := FindRoute(fromLang, toLang)
+	if err != nil {
+		return nil, err
 	}
-
-	if !needsPivotTranslation(fromLang, toLang) {
-		return translateSingleLanguageText(ctx, fromLang, toLang, text, isHTML)
+	if len(route) > 2 {
+		logger.Debug("Translation %s -> %s routed through %s", fromLang, toLang, strings.Join(route, " -> "))
 	}
+	return getOrCreateSingleEngine(context.Background(), route[0], route[1])
+}
 
-	// Pivot Translation
+// translateSegment 沿最短路径翻译一段单一语言的文本，返回译文和使用的路径
+func translateSegment(ctx context.Context, fromLang, toLang, text string, isHTML bool) (_ string, _ []string, err error) {
+	if fromLang == toLang {
+		return text, nil, nil
+	}
 
-	// Step 1: from -> en
-	intermediateText, err := translateSingleLanguageText(ctx, fromLang, "en", text, isHTML)
+	route, err := FindRoute(fromLang, toLang)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+	legs := models.RoutePairs(route)
+	ctx, span := tracing.Start(ctx, "services.translateSegment",
+		attribute.String("translation.from", fromLang),
+		attribute.String("translation.to", toLang),
+		attribute.Int("translation.text_length", len(text)),
+		attribute.Bool("translation.pivot", len(legs) > 1),
+		attribute.String("translation.route", strings.Join(route, ">")),
+		attribute.Int("translation.hops", len(legs)),
+	)
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	// 同时检查路径上的所有模型，需要下载的一起开始
+	var notReady error
+	for _, leg := range legs {
+		if err := checkModelReady(leg.From, leg.To); err != nil && notReady == nil {
+			notReady = err
+		}
+	}
+	if notReady != nil {
+		return "", nil, notReady
 	}
 
-	// Step 2: en -> to
-	return translateSingleLanguageText(ctx, "en", toLang, intermediateText, isHTML)
+	result := text
+	for _, leg := range legs {
+		if result, err = translateSingleLanguageText(ctx, leg.From, leg.To, result, isHTML); err != nil {
+			return "", nil, err
+		}
+	}
+	recordRoute(route, utf8.RuneCountInString(text))
+	return result, route, nil
 }
 
-func TranslateWithPivot(ctx context.Context, fromLang, toLang, text string, isHTML bool) (string, error) {
-	logger.Debug("TranslateWithPivot: %s -> %s, text length: %d, isHTML: %v", fromLang, toLang, len(text), isHTML)
+// TranslateWithPivot 翻译文本，源语言为 auto 或文本较长时按检测到的语言分段翻译
+// 返回译文以及检测到的语言、各段语言、翻译路径和耗时
+func TranslateWithPivot(ctx context.Context, fromLang, toLang, text string, isHTML bool) (_ *TranslationResult, err error) {
+	logger.Ctx(ctx).Debug("TranslateWithPivot: %s -> %s, text length: %d, isHTML: %v", fromLang, toLang, len(text), isHTML)
+	start := time.Now()
+
+	ctx, span := tracing.Start(ctx, "services.TranslateWithPivot",
+		attribute.String("translation.from", fromLang),
+		attribute.String("translation.to", toLang),
+		attribute.Int("translation.text_length", len(text)),
+		attribute.Bool("translation.html", isHTML),
+	)
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
 
+	res, err := translateWithPivot(withoutTargetLocale(ctx, toLang), fromLang, toLang, text, isHTML)
+	if err != nil {
+		return nil, err
+	}
+	res.Duration = time.Since(start)
+	span.SetAttributes(
+		attribute.String("translation.detected_language", res.DetectedLanguage),
+		attribute.Int("translation.segments", len(res.Segments)),
+	)
+	return res, nil
+}
+
+func translateWithPivot(ctx context.Context, fromLang, toLang, text string, isHTML bool) (*TranslationResult, error) {
 	if fromLang != "auto" && len(text) <= 128 {
-		if fromLang == toLang {
-			return text, nil
-		}
-		return translateSegment(ctx, fromLang, toLang, text, isHTML)
+		return translateWhole(ctx, toLang, text, isHTML, SegmentResult{Language: fromLang, End: len(text)}, false)
 	}
 
-	segments := DetectMultipleLanguages(text)
+	segments := detectSegments(ctx, text)
 	if len(segments) <= 1 {
-		var effectiveFromLang string
+		lang, detected := fromLang, false
 		if len(segments) == 1 {
-			effectiveFromLang = segments[0].Language
+			lang, detected = segments[0].Language, true
 		} else if fromLang == "auto" {
-			detected := DetectLanguage(text)
-			if detected == "" {
-				return "", fmt.Errorf("failed to detect source language")
+			if lang = detectLanguage(ctx, text); lang == "" {
+				return nil, fmt.Errorf("failed to detect source language")
 			}
-			effectiveFromLang = detected
-		} else {
-			effectiveFromLang = fromLang
+			detected = true
 		}
-		if effectiveFromLang == toLang {
-			return text, nil
+		seg := SegmentResult{Language: lang, End: len(text)}
+		if detected {
+			seg.Confidence = languageConfidence(text, lang)
 		}
-		return translateSegment(ctx, effectiveFromLang, toLang, text, isHTML)
+		return translateWhole(ctx, toLang, text, isHTML, seg, detected)
 	}
 
-	logger.Debug("Detected %d language segments", len(segments))
+	logger.Ctx(ctx).Debug("Detected %d language segments", len(segments))
+	res := &TranslationResult{Detected: true, Segments: make([]SegmentResult, 0, len(segments))}
 	var result strings.Builder
 	lastEnd := 0
+	// 不允许翻译的段保留原文，所有需要翻译的段都不允许时返回错误
+	var denied error
+	translatedAny := false
 
 	for _, seg := range segments {
 		if seg.Start > lastEnd {
 			result.WriteString(text[lastEnd:seg.Start])
 		}
 
+		sr := SegmentResult{
+			Language:   seg.Language,
+			Confidence: languageConfidence(seg.Text, seg.Language),
+			Start:      seg.Start,
+			End:        seg.End,
+		}
 		if seg.Language == toLang {
 			result.WriteString(seg.Text)
+		} else if err := checkPair(ctx, seg.Language, toLang); err != nil {
+			logger.Ctx(ctx).Debug("Segment %s -> %s not translated: %v", seg.Language, toLang, err)
+			result.WriteString(seg.Text)
+			denied = err
 		} else {
-			translated, err := translateSegment(ctx, seg.Language, toLang, seg.Text, isHTML)
+			translated, route, err := translateSegment(ctx, seg.Language, toLang, seg.Text, isHTML)
+			if errors.Is(err, ErrModelLoading) {
+				return nil, err
+			}
 			if err != nil {
-				logger.Error("Failed to translate segment: %v", err)
+				logger.Ctx(ctx).Error("Failed to translate segment: %v", err)
 				result.WriteString(seg.Text)
 			} else {
 				result.WriteString(translated)
+				sr.Route = route
+				translatedAny = true
 			}
 		}
+		res.Segments = append(res.Segments, sr)
 		lastEnd = seg.End
 	}
+	if denied != nil && !translatedAny {
+		return nil, denied
+	}
 
 	if lastEnd < len(text) {
 		result.WriteString(text[lastEnd:])
 	}
 
-	return result.String(), nil
+	res.Text = result.String()
+	res.DetectedLanguage = dominantLanguage(res.Segments)
+	res.Confidence = languageConfidence(text, res.DetectedLanguage)
+	for _, seg := range res.Segments {
+		if seg.Language == res.DetectedLanguage && seg.Route != nil {
+			res.Route = seg.Route
+			break
+		}
+	}
+	return res, nil
 }
 
-func translateSingleLanguageText(ctx context.Context, fromLang, toLang, text string, isHTML bool) (string, error) {
+// translateWhole 将整段文本作为 seg 的语言翻译
+func translateWhole(ctx context.Context, toLang, text string, isHTML bool, seg SegmentResult, detected bool) (*TranslationResult, error) {
+	res := &TranslationResult{
+		Text:             text,
+		DetectedLanguage: seg.Language,
+		Detected:         detected,
+		Confidence:       seg.Confidence,
+	}
+	if seg.Language != toLang {
+		if err := checkPair(ctx, seg.Language, toLang); err != nil {
+			return nil, err
+		}
+		translated, route, err := translateSegment(ctx, seg.Language, toLang, text, isHTML)
+		if err != nil {
+			return nil, err
+		}
+		res.Text, res.Route, seg.Route = translated, route, route
+	}
+	res.Segments = []SegmentResult{seg}
+	return res, nil
+}
+
+func translateSingleLanguageText(ctx context.Context, fromLang, toLang, text string, isHTML bool) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "services.translateHop",
+		attribute.String("translation.from", fromLang),
+		attribute.String("translation.to", toLang),
+		attribute.Int("translation.text_length", len(text)),
+	)
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	log := logger.Ctx(ctx).With(logger.Fields{logger.FieldPair: fmt.Sprintf("%s-%s", fromLang, toLang)})
+
+	// 超长纯文本切分后逐段翻译，HTML 切分可能破坏标签结构
+	if !isHTML {
+		if chunks := splitText(text, config.GetConfig().ChunkSize); len(chunks) > 1 {
+			log.Debug("translateSingleLanguageText: splitting text into %d chunks", len(chunks))
+			span.SetAttributes(attribute.Int("translation.chunks", len(chunks)))
+			return translateChunks(ctx, fromLang, toLang, chunks)
+		}
+	}
+
 	// 1. Get initial manager (will ensure pool is created)
-	m, err := getOrCreateSingleEngine(fromLang, toLang)
+	m, err := getOrCreateSingleEngine(ctx, fromLang, toLang)
 	if err != nil {
-		logger.Error("translateSingleLanguageText: failed to get engine: %v", err)
+		log.Error("translateSingleLanguageText: failed to get engine: %v", err)
 		return "", err
 	}
 
@@ -363,7 +562,7 @@ func translateSingleLanguageText(ctx context.Context, fromLang, toLang, text str
 	info := getEngineInfo(fromLang, toLang)
 	var maxRetries int = 1
 	if info != nil {
-		maxRetries = len(info.Managers) * 2 // Try twice per manager on average
+		maxRetries = len(info.managers()) * 2 // Try twice per manager on average
 		if maxRetries < 3 {
 			maxRetries = 3
 		}
@@ -381,7 +580,7 @@ func translateSingleLanguageText(ctx context.Context, fromLang, toLang, text str
 			}
 		}
 
-		logger.Debug("translateSingleLanguageText: attempting translation (try %d/%d)", i+1, maxRetries)
+		log.Debug("translateSingleLanguageText: attempting translation (try %d/%d)", i+1, maxRetries)
 		var result string
 		if isHTML {
 			result, err = m.TranslateHTML(ctx, text)
@@ -393,9 +592,16 @@ func translateSingleLanguageText(ctx context.Context, fromLang, toLang, text str
 			return result, nil
 		}
 
+		// 滚动升级替换了该 Worker，立即使用新的 Worker 重试
+		if info != nil && !info.has(m) {
+			log.Debug("Worker was replaced during upgrade, retrying with next manager")
+			continue
+		}
+
 		// Check if error is retryable (worker failure)
 		if isConnectionError(err) {
-			logger.Debug("Translation attempt %d failed (connection error): %v. Retrying with next manager...", i+1, err)
+			log.Debug("Translation attempt %d failed (connection error): %v. Retrying with next manager...", i+1, err)
+			span.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", i+1), attribute.String("error", err.Error())))
 			lastErr = err
 			// Backoff: 500ms, 1000ms, 2000ms...
 			backoff := time.Duration(500*(1<<i)) * time.Millisecond
@@ -413,7 +619,7 @@ func translateSingleLanguageText(ctx context.Context, fromLang, toLang, text str
 	// If all retries failed, fallback to segmented translation if applicable?
 	// The original code did that. Let's preserve it if appropriate.
 	if lastErr != nil {
-		logger.Warn("All translation attempts failed. Last error: %v. Trying segmented translation.", lastErr)
+		log.Warn("All translation attempts failed. Last error: %v. Trying segmented translation.", lastErr)
 		segResult, segErr := translateWithSegments(ctx, fromLang, toLang, text, isHTML)
 		if segErr != nil {
 			return "", lastErr // Return the main error
@@ -447,22 +653,7 @@ func CleanupAllEngines() {
 			}()
 
 			logger.Debug("Stopping engine: %s", k)
-
-			ei.mu.Lock()
-			if ei.stopTimer != nil {
-				ei.stopTimer.Stop()
-			}
-			ei.mu.Unlock()
-
-			for _, m := range ei.Managers {
-				if m != nil {
-					if err := m.Cleanup(); err != nil {
-						logger.Error("Failed to cleanup manager: %v", err)
-					} else {
-						logger.Debug("Manager cleaned up successfully")
-					}
-				}
-			}
+			ei.stop()
 		}(key, info)
 	}
 
@@ -499,12 +690,12 @@ func isConnectionError(err error) bool {
 }
 
 func translateWithSegments(ctx context.Context, fromLang, toLang, text string, isHTML bool) (string, error) {
-	segments := DetectMultipleLanguages(text)
+	segments := detectSegments(ctx, text)
 	if len(segments) <= 1 {
 		return "", fmt.Errorf("segmented translation not applicable")
 	}
 
-	logger.Debug("Attempting segmented translation with %d segments", len(segments))
+	logger.Ctx(ctx).Debug("Attempting segmented translation with %d segments", len(segments))
 	var result strings.Builder
 	lastEnd := 0
 
@@ -520,7 +711,7 @@ func translateWithSegments(ctx context.Context, fromLang, toLang, text string, i
 			if fromLang != "auto" && fromLang != "" {
 				segFromLang = fromLang
 			}
-			translated, err := translateSegment(ctx, segFromLang, toLang, seg.Text, isHTML)
+			translated, _, err := translateSegment(ctx, segFromLang, toLang, seg.Text, isHTML)
 			if err != nil {
 				return "", fmt.Errorf("segmented translation failed: %w", err)
 			}
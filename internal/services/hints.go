@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/utils"
+)
+
+const (
+	// shortTextRunes 少于该字符数的文本总是按提示调整检测结果
+	shortTextRunes = 24
+
+	// 提示语言的置信度倍数，同时命中多个提示时取最大值
+	hintWeightExpected = 4.0
+	hintWeightPrevious = 3.0
+	hintWeightLocale   = 2.0
+)
+
+// DetectHints 请求携带的语言检测提示，用于纠正短文本和低置信度的检测结果
+type DetectHints struct {
+	// Languages 预期的语言
+	Languages []string `json:"languages,omitempty" example:"en,de"`
+	// Locale 用户界面语言
+	Locale string `json:"locale,omitempty" example:"de-DE"`
+	// Previous 上一次检测到的语言
+	Previous string `json:"previous,omitempty" example:"en"`
+}
+
+type detectHintsKey struct{}
+
+// ContextWithDetectHints 将检测提示写入 context，TranslateWithPivot 检测源语言时使用
+func ContextWithDetectHints(ctx context.Context, hints DetectHints) context.Context {
+	if hints.empty() {
+		return ctx
+	}
+	return context.WithValue(ctx, detectHintsKey{}, hints.normalize())
+}
+
+func detectHintsFrom(ctx context.Context) DetectHints {
+	hints, _ := ctx.Value(detectHintsKey{}).(DetectHints)
+	return hints
+}
+
+// withoutTargetLocale 去掉与目标语言相同的界面语言提示
+// 界面语言通常就是目标语言，按它调整会把短文本检测为目标语言而不翻译
+func withoutTargetLocale(ctx context.Context, toLang string) context.Context {
+	hints := detectHintsFrom(ctx)
+	if hints.Locale == "" || hints.Locale != toLang {
+		return ctx
+	}
+	hints.Locale = ""
+	return context.WithValue(ctx, detectHintsKey{}, hints)
+}
+
+func (h DetectHints) empty() bool {
+	return len(h.Languages) == 0 && h.Locale == "" && h.Previous == ""
+}
+
+func (h DetectHints) normalize() DetectHints {
+	n := DetectHints{
+		Locale:   utils.NormalizeLanguageCode(h.Locale),
+		Previous: utils.NormalizeLanguageCode(h.Previous),
+	}
+	for _, lang := range h.Languages {
+		if lang = utils.NormalizeLanguageCode(lang); lang != "" {
+			n.Languages = append(n.Languages, lang)
+		}
+	}
+	return n
+}
+
+// weight 返回语言的置信度倍数，不在提示中的语言为 1
+func (h DetectHints) weight(lang string) float64 {
+	w := 1.0
+	for _, l := range h.Languages {
+		if l == lang {
+			w = max(w, hintWeightExpected)
+		}
+	}
+	if h.Previous == lang {
+		w = max(w, hintWeightPrevious)
+	}
+	if h.Locale == lang {
+		w = max(w, hintWeightLocale)
+	}
+	return w
+}
+
+// fallback 检测器没有给出任何候选语言时，依次使用上一次检测到的语言、预期的语言和界面语言中支持的第一个
+func (h DetectHints) fallback(supported map[string]bool) []LanguageCandidate {
+	langs := append([]string{h.Previous}, h.Languages...)
+	for _, lang := range append(langs, h.Locale) {
+		if lang != "" && isSupportedLanguage(supported, lang) {
+			return []LanguageCandidate{{Language: lang}}
+		}
+	}
+	return nil
+}
+
+// apply 文本较短或最可能的语言置信度低于 DetectorMinConfidence 时，提高提示语言的置信度后重新归一化排序
+func (h DetectHints) apply(text string, list []LanguageCandidate) []LanguageCandidate {
+	if h.empty() || len(list) == 0 {
+		return list
+	}
+	if utf8.RuneCountInString(text) >= shortTextRunes && list[0].Confidence >= config.GetConfig().DetectorMinConfidence {
+		return list
+	}
+
+	weighted := make([]LanguageCandidate, len(list))
+	var total float64
+	for i, c := range list {
+		c.Confidence *= h.weight(c.Language)
+		weighted[i] = c
+		total += c.Confidence
+	}
+	for i := range weighted {
+		weighted[i].Confidence /= total
+	}
+	sort.SliceStable(weighted, func(i, j int) bool {
+		return weighted[i].Confidence > weighted[j].Confidence
+	})
+	return weighted
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectHints(t *testing.T) {
+	setupDetector(t)
+
+	// 短文本按提示调整候选语言的顺序
+	candidates := DetectCandidatesWithHints("Hallo", nil, DetectHints{Previous: "de"})
+	require.NotEmpty(t, candidates)
+	assert.Equal(t, "de", candidates[0].Language)
+
+	// 提示中的语言代码会被规范化
+	candidates = DetectCandidatesWithHints("Hallo", nil, DetectHints{Previous: "de-AT"})
+	require.NotEmpty(t, candidates)
+	assert.Equal(t, "de", candidates[0].Language)
+
+	// 长文本置信度足够时不受提示影响
+	text := "The weather is lovely today and we are going for a walk."
+	candidates = DetectCandidatesWithHints(text, nil, DetectHints{Languages: []string{"fr"}})
+	require.NotEmpty(t, candidates)
+	assert.Equal(t, "en", candidates[0].Language)
+
+	// 检测器无法判断时使用提示中的语言
+	assert.Empty(t, DetectCandidates("OK", nil))
+	candidates = DetectCandidatesWithHints("OK", nil, DetectHints{Languages: []string{"xx", "fr"}, Locale: "de"})
+	require.Len(t, candidates, 1)
+	assert.Equal(t, LanguageCandidate{Language: "fr"}, candidates[0])
+}
+
+func TestDetectSegmentsWithContextHints(t *testing.T) {
+	setupDetector(t)
+
+	ctx := ContextWithDetectHints(context.Background(), DetectHints{Previous: "de"})
+	segments := detectSegments(ctx, "OK")
+	require.Len(t, segments, 1)
+	assert.Equal(t, "de", segments[0].Language)
+
+	assert.Equal(t, "de", detectLanguage(ctx, "OK"))
+	assert.Equal(t, ctx, ContextWithDetectHints(ctx, DetectHints{}))
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/models"
+)
+
+var ErrModelLoading = errors.New("model is loading")
+
+// ModelLoadingError 模型正在下载，请求未等待下载完成
+type ModelLoadingError struct {
+	From     string
+	To       string
+	Progress *models.DownloadProgress
+}
+
+func (e *ModelLoadingError) Error() string {
+	if p := e.Progress; p != nil && p.Total > 0 {
+		return fmt.Sprintf("model for %s -> %s is loading (%d%%), retry later", e.From, e.To, p.Bytes*100/p.Total)
+	}
+	return fmt.Sprintf("model for %s -> %s is loading, retry later", e.From, e.To)
+}
+
+func (e *ModelLoadingError) Is(target error) bool {
+	return target == ErrModelLoading
+}
+
+// RetryAfter 根据下载进度估算剩余时间，无法估算时返回 5 秒
+func (e *ModelLoadingError) RetryAfter() time.Duration {
+	p := e.Progress
+	if p == nil || p.Rate <= 0 || p.Total <= p.Bytes {
+		return 5 * time.Second
+	}
+	seconds := math.Ceil(float64(p.Total-p.Bytes) / p.Rate)
+	return time.Duration(seconds) * time.Second
+}
+
+// checkModelReady 在不等待下载的模式下，模型未就绪时开始后台下载并返回 ModelLoadingError
+func checkModelReady(fromLang, toLang string) error {
+	cfg := config.GetConfig()
+	if cfg.ModelLoading == "" || cfg.ModelLoading == "wait" || cfg.EnableOfflineMode {
+		return nil
+	}
+	if IsEngineLoaded(fromLang, toLang) {
+		return nil
+	}
+
+	if !models.IsDownloading(fromLang, toLang) {
+		if models.IsModelDownloaded(cfg.ModelDir, fromLang, toLang) {
+			return nil
+		}
+		if err := models.StartDownload(fromLang, toLang); err != nil && !errors.Is(err, models.ErrDownloadInProcess) {
+			return err
+		}
+	}
+	progress := models.GetProgress(fromLang, toLang)
+	if progress != nil && progress.Done {
+		// 上一次下载的记录，本次下载尚未开始
+		progress = nil
+	}
+	return &ModelLoadingError{From: fromLang, To: toLang, Progress: progress}
+}
@@ -0,0 +1,25 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xxnuo/MTranServer/internal/models"
+)
+
+func TestModelLoadingError(t *testing.T) {
+	err := fmt.Errorf("translate: %w", &ModelLoadingError{From: "en", To: "ja"})
+	assert.True(t, errors.Is(err, ErrModelLoading))
+
+	var loading *ModelLoadingError
+	assert.True(t, errors.As(err, &loading))
+	assert.Equal(t, "model for en -> ja is loading, retry later", loading.Error())
+	assert.Equal(t, 5*time.Second, loading.RetryAfter())
+
+	loading.Progress = &models.DownloadProgress{Bytes: 25, Total: 100, Rate: 10}
+	assert.Equal(t, "model for en -> ja is loading (25%), retry later", loading.Error())
+	assert.Equal(t, 8*time.Second, loading.RetryAfter())
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/models"
+)
+
+var ErrEngineRunning = errors.New("model is in use by a running engine")
+
+// IsEngineLoaded 语言对是否有运行中的 Worker
+func IsEngineLoaded(fromLang, toLang string) bool {
+	return getEngineInfo(fromLang, toLang) != nil
+}
+
+// ListModels 返回所有语言对的模型状态，并标记已加载的语言对
+func ListModels() ([]models.ModelStatus, error) {
+	statuses, err := models.ListModels(config.GetConfig().ModelDir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range statuses {
+		statuses[i].Loaded = IsEngineLoaded(statuses[i].From, statuses[i].To)
+	}
+	return statuses, nil
+}
+
+// GetModelStatus 返回语言对的模型状态
+func GetModelStatus(fromLang, toLang string) models.ModelStatus {
+	s := models.GetModelStatus(config.GetConfig().ModelDir, fromLang, toLang)
+	s.Loaded = IsEngineLoaded(fromLang, toLang)
+	return s
+}
+
+// DeleteModel 删除语言对的模型，语言对的 Worker 正在运行时返回 ErrEngineRunning
+// 正在下载时返回 models.ErrDownloadInProcess，不会在持有 engMu 时等待下载完成
+func DeleteModel(fromLang, toLang string) error {
+	if models.IsDownloading(fromLang, toLang) {
+		return models.ErrDownloadInProcess
+	}
+
+	// 持有 engMu 期间不会创建新的引擎
+	engMu.Lock()
+	defer engMu.Unlock()
+
+	if _, ok := engines[fmt.Sprintf("%s-%s", fromLang, toLang)]; ok {
+		return ErrEngineRunning
+	}
+	return models.DeleteModel(config.GetConfig().ModelDir, fromLang, toLang)
+}
+
+// VerifyModels 校验语言对的模型，pairs 为空时校验模型目录中的所有语言对
+// Worker 正在运行的语言对只校验不修复
+func VerifyModels(pairs []models.Pair, repair bool) ([]models.ModelCheck, error) {
+	modelDir := config.GetConfig().ModelDir
+	if len(pairs) == 0 {
+		var err error
+		if pairs, err = models.InstalledPairs(modelDir); err != nil {
+			return nil, err
+		}
+	}
+
+	checks := make([]models.ModelCheck, 0, len(pairs))
+	for _, p := range pairs {
+		running := IsEngineLoaded(p.From, p.To)
+		c := models.VerifyModel(modelDir, p, repair && !running)
+		if repair && running && !c.Healthy {
+			c.Error = ErrEngineRunning.Error()
+		}
+		checks = append(checks, c)
+	}
+	return checks, nil
+}
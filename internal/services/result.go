@@ -0,0 +1,44 @@
+package services
+
+import "time"
+
+// SegmentResult 按语言切分后一段文本的检测和翻译信息，Start 和 End 为原文中的字节偏移
+type SegmentResult struct {
+	Language string
+	// Confidence 检测置信度，请求指定源语言时为 0
+	Confidence float64
+	Start      int
+	End        int
+	// Route 该段的翻译路径，已是目标语言或翻译失败保留原文时为空
+	Route []string
+}
+
+// TranslationResult TranslateWithPivot 的翻译结果
+type TranslationResult struct {
+	Text string
+	// DetectedLanguage 实际使用的源语言，多语言文本为占比最大的语言
+	DetectedLanguage string
+	// Detected 源语言由检测得到，而不是请求指定
+	Detected bool
+	// Confidence DetectedLanguage 的检测置信度，请求指定源语言时为 0
+	Confidence float64
+	Segments   []SegmentResult
+	// Route DetectedLanguage 所在段的翻译路径，无需翻译时为空
+	Route []string
+	// Cached 结果来自翻译缓存，目前没有缓存层，始终为 false
+	Cached   bool
+	Duration time.Duration
+}
+
+// dominantLanguage 返回各段中字节数最多的语言，相同时取先出现的
+func dominantLanguage(segments []SegmentResult) string {
+	sizes := make(map[string]int)
+	best := ""
+	for _, seg := range segments {
+		sizes[seg.Language] += seg.End - seg.Start
+		if best == "" || sizes[seg.Language] > sizes[best] {
+			best = seg.Language
+		}
+	}
+	return best
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDominantLanguage(t *testing.T) {
+	assert.Equal(t, "", dominantLanguage(nil))
+	assert.Equal(t, "ja", dominantLanguage([]SegmentResult{
+		{Language: "en", Start: 0, End: 10},
+		{Language: "ja", Start: 10, End: 30},
+		{Language: "en", Start: 30, End: 35},
+	}))
+	// 字节数相同时取先出现的语言
+	assert.Equal(t, "en", dominantLanguage([]SegmentResult{
+		{Language: "en", Start: 0, End: 10},
+		{Language: "ja", Start: 10, End: 20},
+	}))
+}
+
+func TestTranslateWithPivotSameLanguage(t *testing.T) {
+	res, err := TranslateWithPivot(context.Background(), "en", "en", "Hello, world!", false)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello, world!", res.Text)
+	assert.Equal(t, "en", res.DetectedLanguage)
+	assert.False(t, res.Detected)
+	assert.Zero(t, res.Confidence)
+	assert.Nil(t, res.Route)
+	assert.Equal(t, []SegmentResult{{Language: "en", End: len("Hello, world!")}}, res.Segments)
+}
+
+func TestTranslateWithPivotPairCheck(t *testing.T) {
+	setupDetector(t)
+
+	errDenied := errors.New("denied")
+	ctx := ContextWithPairCheck(context.Background(), func(fromLang, toLang string) error {
+		if fromLang != "en" {
+			return errDenied
+		}
+		return nil
+	})
+
+	// 自动检测到的源语言同样需要通过检查
+	_, err := TranslateWithPivot(ctx, "auto", "fr", "Das ist ein schöner Tag und wir gehen heute spazieren.", false)
+	assert.ErrorIs(t, err, errDenied)
+
+	// 无需翻译的文本不检查
+	res, err := TranslateWithPivot(ctx, "de", "de", "Hallo", false)
+	require.NoError(t, err)
+	assert.Equal(t, "Hallo", res.Text)
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/models"
+)
+
+// RouteStats 翻译路径的使用统计
+type RouteStats struct {
+	Route []string `json:"route" example:"ja,en,ko"`
+	// Hops 经过的语言对数量
+	Hops int `json:"hops" example:"2"`
+	// Count 使用该路径翻译的文本段数
+	Count int64 `json:"count" example:"12"`
+	// Characters 使用该路径翻译的字符数
+	Characters int64 `json:"characters" example:"3456"`
+}
+
+var (
+	routeStatsMu sync.Mutex
+	routeStats   = make(map[string]*RouteStats)
+)
+
+// FindRoute 返回从 fromLang 到 toLang 经过语言对最少的翻译路径，包含首尾语言
+// 长度相同时优先经过配置的中转语言
+func FindRoute(fromLang, toLang string) ([]string, error) {
+	cfg := config.GetConfig()
+	return models.PairGraph().Route(fromLang, toLang, cfg.PivotLanguage, cfg.PivotHops())
+}
+
+func recordRoute(route []string, chars int) {
+	key := strings.Join(route, ">")
+
+	routeStatsMu.Lock()
+	defer routeStatsMu.Unlock()
+	s, ok := routeStats[key]
+	if !ok {
+		s = &RouteStats{Route: route, Hops: len(route) - 1}
+		routeStats[key] = s
+	}
+	s.Count++
+	s.Characters += int64(chars)
+}
+
+// ListRouteStats 返回启动以来各翻译路径的使用统计，按使用次数从多到少排序
+func ListRouteStats() []RouteStats {
+	routeStatsMu.Lock()
+	stats := make([]RouteStats, 0, len(routeStats))
+	for _, s := range routeStats {
+		stats = append(stats, *s)
+	}
+	routeStatsMu.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return strings.Join(stats[i].Route, ">") < strings.Join(stats[j].Route, ">")
+	})
+	return stats
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/models"
+	"github.com/xxnuo/MTranServer/internal/models/modelstest"
+)
+
+func TestFindRoute(t *testing.T) {
+	modelstest.UseGlobals(t, &config.Config{PivotLanguage: "en", MaxPivotHops: 2},
+		models.RecordItem{SourceLanguage: "ja", TargetLanguage: "en"},
+		models.RecordItem{SourceLanguage: "en", TargetLanguage: "de"},
+		models.RecordItem{SourceLanguage: "de", TargetLanguage: "xx"},
+	)
+
+	route, err := FindRoute("ja", "de")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ja", "en", "de"}, route)
+
+	_, err = FindRoute("ja", "xx")
+	assert.ErrorIs(t, err, models.ErrNoRoute)
+
+	config.GlobalConfig.MaxPivotHops = 3
+	route, err = FindRoute("ja", "xx")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ja", "en", "de", "xx"}, route)
+
+	config.GlobalConfig.MaxPivotHops = 1
+	_, err = FindRoute("ja", "de")
+	assert.ErrorIs(t, err, models.ErrNoRoute)
+}
+
+func TestRouteStats(t *testing.T) {
+	t.Cleanup(func() {
+		routeStatsMu.Lock()
+		routeStats = make(map[string]*RouteStats)
+		routeStatsMu.Unlock()
+	})
+
+	recordRoute([]string{"ja", "en", "de"}, 10)
+	recordRoute([]string{"en", "de"}, 3)
+	recordRoute([]string{"ja", "en", "de"}, 5)
+
+	assert.Equal(t, []RouteStats{
+		{Route: []string{"ja", "en", "de"}, Hops: 2, Count: 2, Characters: 15},
+		{Route: []string{"en", "de"}, Hops: 1, Count: 1, Characters: 3},
+	}, ListRouteStats())
+}
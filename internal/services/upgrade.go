@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/logger"
+	"github.com/xxnuo/MTranServer/internal/manager"
+	"github.com/xxnuo/MTranServer/internal/models"
+)
+
+// drainTimeout 替换 Worker 后等待旧 Worker 处理完请求的最长时间
+const drainTimeout = 2 * time.Minute
+
+var (
+	ErrUpgradeInProgress = errors.New("upgrade already in progress")
+	ErrEngineNotRunning  = errors.New("engine is not running")
+)
+
+var (
+	upgradingMu sync.Mutex
+	upgrading   = make(map[string]bool)
+)
+
+// EngineUpgrade 运行中的引擎需要切换的模型版本
+type EngineUpgrade struct {
+	From string `json:"from" example:"en"`
+	To   string `json:"to" example:"ja"`
+	// Version Worker 当前使用的版本
+	Version string `json:"version" example:"1.0"`
+	// Target 记录中的最新版本或配置中固定的版本
+	Target string `json:"target" example:"1.1"`
+}
+
+// PendingUpgrades 返回模型版本与应使用的版本不同的运行中引擎
+func PendingUpgrades() []EngineUpgrade {
+	engMu.RLock()
+	infos := make([]*EngineInfo, 0, len(engines))
+	for _, info := range engines {
+		infos = append(infos, info)
+	}
+	engMu.RUnlock()
+
+	var pending []EngineUpgrade
+	for _, info := range infos {
+		info.mu.Lock()
+		u := EngineUpgrade{From: info.FromLang, To: info.ToLang, Version: info.Version}
+		info.mu.Unlock()
+		if models.UsesLocalModel(u.From, u.To) {
+			// 本地模型不从记录升级，重新加载清单后在空闲超时后生效
+			continue
+		}
+
+		target, err := models.TargetVersion(u.From, u.To)
+		if err != nil {
+			logger.Warn("Cannot determine model version for %s -> %s: %v", u.From, u.To, err)
+			continue
+		}
+		if target == "" || target == u.Version {
+			continue
+		}
+		u.Target = target
+		pending = append(pending, u)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		if pending[i].From != pending[j].From {
+			return pending[i].From < pending[j].From
+		}
+		return pending[i].To < pending[j].To
+	})
+	return pending
+}
+
+// StartUpgrades 在后台滚动升级所有需要切换版本的引擎，返回开始升级的引擎
+func StartUpgrades() []EngineUpgrade {
+	started := []EngineUpgrade{}
+	for _, u := range PendingUpgrades() {
+		if !beginUpgrade(u.From, u.To) {
+			continue
+		}
+		started = append(started, u)
+		go func(u EngineUpgrade) {
+			defer endUpgrade(u.From, u.To)
+			if err := upgradeEngine(context.Background(), u.From, u.To); err != nil {
+				logger.Error("Failed to upgrade engine %s -> %s to %s: %v", u.From, u.To, u.Target, err)
+			}
+		}(u)
+	}
+	return started
+}
+
+// WatchUpgrades 记录刷新后有语言对版本更新时，按 ModelAutoUpgrade 配置滚动升级运行中的引擎
+func WatchUpgrades(ctx context.Context) {
+	diffs, cancel := models.SubscribeRecords()
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case diff := <-diffs:
+			if len(diff.Updated) == 0 || !config.GetConfig().ModelAutoUpgrade {
+				continue
+			}
+			for _, u := range StartUpgrades() {
+				logger.Info("Upgrading engine %s -> %s from %s to %s", u.From, u.To, u.Version, u.Target)
+			}
+		}
+	}
+}
+
+// UpgradeEngine 滚动升级语言对的引擎，已在升级时返回 ErrUpgradeInProgress
+func UpgradeEngine(ctx context.Context, fromLang, toLang string) error {
+	if !beginUpgrade(fromLang, toLang) {
+		return ErrUpgradeInProgress
+	}
+	defer endUpgrade(fromLang, toLang)
+	return upgradeEngine(ctx, fromLang, toLang)
+}
+
+func beginUpgrade(fromLang, toLang string) bool {
+	upgradingMu.Lock()
+	defer upgradingMu.Unlock()
+	key := fmt.Sprintf("%s-%s", fromLang, toLang)
+	if upgrading[key] {
+		return false
+	}
+	upgrading[key] = true
+	return true
+}
+
+func endUpgrade(fromLang, toLang string) {
+	upgradingMu.Lock()
+	defer upgradingMu.Unlock()
+	delete(upgrading, fmt.Sprintf("%s-%s", fromLang, toLang))
+}
+
+// upgradeEngine 将新版本准备到暂存目录，启动同样数量的 Worker 并全部通过健康检查后，
+// 逐个替换引擎中的旧 Worker，等待旧 Worker 处理完已接收的请求后停止，切换期间请求不会失败
+// 新 Worker 启动失败时保持原有引擎不变
+func upgradeEngine(ctx context.Context, fromLang, toLang string) error {
+	key := fmt.Sprintf("%s-%s", fromLang, toLang)
+	log := logger.Ctx(ctx).With(logger.Fields{logger.FieldPair: key})
+	modelDir := config.GetConfig().ModelDir
+
+	info := getEngineInfo(fromLang, toLang)
+	if info == nil {
+		return ErrEngineNotRunning
+	}
+	if models.UsesLocalModel(fromLang, toLang) {
+		return nil
+	}
+	info.mu.Lock()
+	oldVersion := info.Version
+	info.mu.Unlock()
+
+	if target, err := models.TargetVersion(fromLang, toLang); err != nil {
+		return err
+	} else if target == oldVersion {
+		return nil
+	}
+
+	dir, version, err := models.StageModel(modelDir, fromLang, toLang)
+	if err != nil {
+		return fmt.Errorf("failed to stage model: %w", err)
+	}
+	log.Info("Upgrading engine %s from model version %s to %s", key, oldVersion, version)
+
+	old := info.managers()
+	fresh := make([]*manager.Manager, 0, len(old))
+	cleanup := func() {
+		for _, m := range fresh {
+			m.Cleanup()
+		}
+		models.ReleaseStaged(dir)
+	}
+	for i := range old {
+		if !canCreateNewWorker() {
+			cleanup()
+			return fmt.Errorf("%w: need at least %dMB for each new worker",
+				ErrInsufficientMemory, workerMemoryMB+reservedMemoryMB)
+		}
+		m, err := startWorker(ctx, fromLang, toLang, dir)
+		if err != nil {
+			cleanup()
+			return fmt.Errorf("new worker %d: %w", i+1, err)
+		}
+		fresh = append(fresh, m)
+		log.Info("New worker %d/%d ready for %s on model version %s", i+1, len(old), key, version)
+	}
+
+	// 先记录暂存目录，替换过程中引擎停止时随引擎删除
+	info.mu.Lock()
+	oldStaged := info.stagedDir
+	info.stagedDir = dir
+	info.mu.Unlock()
+	release := func() {
+		if oldStaged != "" && oldStaged != dir {
+			models.ReleaseStaged(oldStaged)
+		}
+	}
+
+	// 逐个替换，持有 engMu 读锁以免与空闲超时的停止过程交错
+	for i, m := range fresh {
+		engMu.RLock()
+		if engines[key] != info || !info.replaceManager(old[i], m) {
+			engMu.RUnlock()
+			// 引擎已因空闲超时停止，已换入的 Worker 随引擎停止，未换入的不再需要
+			for _, m := range fresh[i:] {
+				m.Cleanup()
+			}
+			models.ReleaseStaged(dir)
+			release()
+			return ErrEngineNotRunning
+		}
+		engMu.RUnlock()
+
+		drainCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+		if err := old[i].Drain(drainCtx); err != nil {
+			log.Warn("Worker %d of %s did not drain in time: %v", i+1, key, err)
+		}
+		cancel()
+		if err := old[i].Cleanup(); err != nil {
+			log.Error("Failed to cleanup replaced worker: %v", err)
+		}
+		log.Info("Replaced worker %d/%d of %s", i+1, len(fresh), key)
+	}
+
+	info.mu.Lock()
+	info.Version = version
+	info.mu.Unlock()
+	release()
+
+	// 之后新建的引擎直接使用语言对目录中的新版本
+	if err := models.PromoteStaged(modelDir, fromLang, toLang, dir); err != nil {
+		log.Warn("Engine %s upgraded but failed to install model version %s: %v", key, version, err)
+	}
+	log.Info("Engine %s upgraded to model version %s", key, version)
+	return nil
+}
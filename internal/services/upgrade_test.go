@@ -0,0 +1,47 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xxnuo/MTranServer/internal/config"
+	"github.com/xxnuo/MTranServer/internal/manager"
+	"github.com/xxnuo/MTranServer/internal/models"
+	"github.com/xxnuo/MTranServer/internal/models/modelstest"
+)
+
+func TestPendingUpgrades(t *testing.T) {
+	t.Cleanup(func() {
+		engMu.Lock()
+		engines = make(map[string]*EngineInfo)
+		engMu.Unlock()
+	})
+	modelstest.UseGlobals(t, &config.Config{ConfigDir: t.TempDir(), ModelDir: t.TempDir()},
+		models.RecordItem{SourceLanguage: "en", TargetLanguage: "ja", Version: "1.0", FileType: "model"},
+		models.RecordItem{SourceLanguage: "en", TargetLanguage: "ja", Version: "1.1", FileType: "model"},
+		models.RecordItem{SourceLanguage: "ja", TargetLanguage: "en", Version: "1.0", FileType: "model"},
+	)
+	engMu.Lock()
+	engines["en-ja"] = &EngineInfo{FromLang: "en", ToLang: "ja", Version: "1.0"}
+	engines["ja-en"] = &EngineInfo{FromLang: "ja", ToLang: "en", Version: "1.0"}
+	engMu.Unlock()
+
+	assert.Equal(t, []EngineUpgrade{{From: "en", To: "ja", Version: "1.0", Target: "1.1"}}, PendingUpgrades())
+
+	// 固定版本后不再升级
+	config.GlobalConfig.Pairs = map[string]config.PairConfig{"en-ja": {Version: "1.0"}}
+	assert.Empty(t, PendingUpgrades())
+}
+
+func TestReplaceManager(t *testing.T) {
+	a := manager.NewManager(manager.NewWorkerArgs())
+	b := manager.NewManager(manager.NewWorkerArgs())
+	c := manager.NewManager(manager.NewWorkerArgs())
+	info := &EngineInfo{Managers: []*manager.Manager{a, b}}
+
+	assert.True(t, info.replaceManager(b, c))
+	assert.Equal(t, []*manager.Manager{a, c}, info.managers())
+	assert.False(t, info.has(b))
+	assert.True(t, info.has(c))
+	assert.False(t, info.replaceManager(b, c))
+}
@@ -31,3 +31,13 @@ func GetIntEnv(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func GetFloatEnv(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		result, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			return result
+		}
+	}
+	return defaultValue
+}
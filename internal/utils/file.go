@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/klauspost/compress/zstd"
 )
@@ -45,7 +46,9 @@ func ComputeSHA256(filepath string) (string, error) {
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
-func DecompressZstd(src, dst string) error {
+// DecompressZstd 解压到同目录下的临时文件，完成后重命名为 dst，
+// 解压中断时不会留下不完整的 dst
+func DecompressZstd(src, dst string) (err error) {
 	// Open the source file
 	inputFile, err := os.Open(src)
 	if err != nil {
@@ -60,17 +63,34 @@ func DecompressZstd(src, dst string) error {
 	}
 	defer decoder.Close()
 
-	// Create the destination file
-	outputFile, err := os.Create(dst)
+	// Create the temporary file next to the destination
+	outputFile, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*.tmp")
 	if err != nil {
 		return fmt.Errorf("failed to create destination file: %w", err)
 	}
-	defer outputFile.Close()
+	tmp := outputFile.Name()
+	defer func() {
+		if err != nil {
+			outputFile.Close()
+			os.Remove(tmp)
+		}
+	}()
 
 	// Copy the decompressed data
 	if _, err := io.Copy(outputFile, decoder); err != nil {
 		return fmt.Errorf("failed to decompress data: %w", err)
 	}
-
+	if err := outputFile.Sync(); err != nil {
+		return fmt.Errorf("failed to sync destination file: %w", err)
+	}
+	if err := outputFile.Close(); err != nil {
+		return fmt.Errorf("failed to close destination file: %w", err)
+	}
+	if err := os.Chmod(tmp, 0644); err != nil {
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return fmt.Errorf("failed to move decompressed file: %w", err)
+	}
 	return nil
 }
@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/klauspost/compress/zstd"
 )
 
 func TestCalculateSHA256(t *testing.T) {
@@ -31,3 +33,65 @@ func TestCalculateSHA256(t *testing.T) {
 		t.Fatalf("SHA256 不匹配: 期望 %s, 实际 %s", expectedSHA256, hash)
 	}
 }
+
+func TestDecompressZstd(t *testing.T) {
+	tempDir := t.TempDir()
+
+	src := filepath.Join(tempDir, "test.txt.zst")
+	out, err := os.Create(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := zstd.NewWriter(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc.Write([]byte("Hello, World!"))
+	enc.Close()
+	out.Close()
+
+	dst := filepath.Join(tempDir, "test.txt")
+	if err := DecompressZstd(src, dst); err != nil {
+		t.Fatalf("解压失败: %v", err)
+	}
+	content, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "Hello, World!" {
+		t.Fatalf("文件内容不匹配: %s", content)
+	}
+}
+
+func TestDecompressZstdCorrupt(t *testing.T) {
+	tempDir := t.TempDir()
+
+	src := filepath.Join(tempDir, "test.txt.zst")
+	if err := os.WriteFile(src, []byte("not zstd data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 解压失败时保留原有文件，且不留下临时文件
+	dst := filepath.Join(tempDir, "test.txt")
+	if err := os.WriteFile(dst, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := DecompressZstd(src, dst); err == nil {
+		t.Fatal("应该返回解压失败错误")
+	}
+
+	content, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "old" {
+		t.Fatalf("原有文件被修改: %s", content)
+	}
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("期望 2 个文件, 实际 %d 个", len(entries))
+	}
+}
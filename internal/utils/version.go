@@ -19,6 +19,11 @@ func GetLargestVersion(versions []string) string {
 	return largest
 }
 
+// CompareVersions 比较两个版本号，v1 较大时返回正数
+func CompareVersions(v1, v2 string) int {
+	return compareVersions(v1, v2)
+}
+
 func compareVersions(v1, v2 string) int {
 
 	parts1 := strings.Split(v1, "-")